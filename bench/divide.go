@@ -0,0 +1,54 @@
+package bench
+
+import (
+	"sort"
+
+	"github.com/daystram/gambit/board"
+)
+
+// DivideResult is the node count below a root move.
+type DivideResult struct {
+	Move  board.Move
+	Nodes uint64
+}
+
+// Count returns the number of leaf nodes of the legal move tree to the depth, cached in the table if not nil.
+func Count(b *board.Board, depth int, tt *PerftTable) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	if nodes, ok := tt.Get(b.Hash(), depth); ok {
+		return nodes
+	}
+
+	var buf [board.MaxLegalMoves]board.Move
+	mvs := b.GenerateLegalMoves(buf[:0])
+	if depth == 1 {
+		return uint64(len(mvs)) // bulk counted, not worth caching
+	}
+	var nodes uint64
+	for _, mv := range mvs {
+		unApply, _ := b.Apply(mv)
+		nodes += Count(b, depth-1, tt)
+		unApply()
+	}
+	tt.Set(b.Hash(), depth, nodes)
+	return nodes
+}
+
+// Divide returns the node count below each legal root move to the depth, ordered by the moves in UCI notation.
+func Divide(b *board.Board, depth int, tt *PerftTable) []DivideResult {
+	var results []DivideResult
+	if depth < 1 {
+		return results
+	}
+	for _, mv := range b.GenerateLegalMoves(nil) {
+		unApply, _ := b.Apply(mv)
+		results = append(results, DivideResult{Move: mv, Nodes: Count(b, depth-1, tt)})
+		unApply()
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Move.UCI() < results[j].Move.UCI()
+	})
+	return results
+}
@@ -48,21 +48,15 @@ func runPerft(b *board.Board, d int, root, verbose bool, out chan string, nodes,
 	}
 
 	var sum uint64
-	for _, mv := range b.GeneratePseudoLegalMoves() {
+	var buf, leafBuf [board.MaxLegalMoves]board.Move
+	for _, mv := range b.GenerateLegalMoves(buf[:0]) {
 		var child uint64
-		unApply, ok := b.Apply(mv)
-		if !ok {
-			unApply()
-			continue
-		}
+		unApply, _ := b.Apply(mv)
 
 		if d != 2 {
 			child = runPerft(b, d-1, false, verbose, out, nodes, cap, enp, cas, pro, chk)
 		} else {
-			for _, leaf := range b.GeneratePseudoLegalMoves() {
-				if !b.IsLegal(leaf) {
-					continue
-				}
+			for _, leaf := range b.GenerateLegalMoves(leafBuf[:0]) {
 				child++
 				*nodes++
 				if leaf.IsCapture {
@@ -77,7 +71,7 @@ func runPerft(b *board.Board, d int, root, verbose bool, out chan string, nodes,
 				if leaf.IsPromote != board.PieceUnknown {
 					*pro++
 				}
-				if leaf.IsCheck {
+				if b.GivesCheck(leaf) {
 					*chk++
 				}
 			}
@@ -100,10 +94,7 @@ func runPerftParallel(b *board.Board, d int, root, verbose bool, out chan string
 
 	var sum uint64
 	var wg sync.WaitGroup
-	for _, mv := range b.GeneratePseudoLegalMoves() {
-		if !b.IsLegal(mv) {
-			continue
-		}
+	for _, mv := range b.GenerateLegalMoves(nil) {
 		mv := mv
 		wg.Add(1)
 		go func() {
@@ -114,10 +105,8 @@ func runPerftParallel(b *board.Board, d int, root, verbose bool, out chan string
 			if d != 2 {
 				child = runPerftParallel(bb, d-1, false, verbose, out, nodes, cap, enp, cas, pro, chk)
 			} else {
-				for _, leaf := range bb.GeneratePseudoLegalMoves() {
-					if !bb.IsLegal(leaf) {
-						continue
-					}
+				var leafBuf [board.MaxLegalMoves]board.Move
+				for _, leaf := range bb.GenerateLegalMoves(leafBuf[:0]) {
 					child++
 					if leaf.IsCapture {
 						atomic.AddUint64(cap, 1)
@@ -131,7 +120,7 @@ func runPerftParallel(b *board.Board, d int, root, verbose bool, out chan string
 					if leaf.IsPromote != board.PieceUnknown {
 						atomic.AddUint64(pro, 1)
 					}
-					if leaf.IsCheck {
+					if bb.GivesCheck(leaf) {
 						atomic.AddUint64(chk, 1)
 					}
 				}
@@ -156,9 +156,24 @@ func TestPerft(t *testing.T) {
 					if pro != tt.wantPro {
 						t.Errorf("unexpected pro: got=%d want=%d", pro, tt.wantPro)
 					}
-					// TODO: yield check count
+					if chk != tt.wantChk {
+						t.Errorf("unexpected chk: got=%d want=%d", chk, tt.wantChk)
+					}
 				}
 			})
 		}
 	}
 }
+
+func TestPerftParallel(t *testing.T) {
+	t.Parallel()
+	b, err := board.NewBoard(board.WithFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	var nodes, cap, enp, cas, pro, chk uint64
+	runPerftParallel(b, 3, true, false, nil, &nodes, &cap, &enp, &cas, &pro, &chk)
+	if got, want := [6]uint64{nodes, cap, enp, cas, pro, chk}, [6]uint64{97_862, 17_102, 45, 3_162, 0, 993}; got != want {
+		t.Errorf("unexpected counts: got=%v want=%v", got, want)
+	}
+}
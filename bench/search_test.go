@@ -0,0 +1,58 @@
+package bench
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+	var results []*SearchResult
+	for run := 0; run < 2; run++ {
+		var positions int
+		r, err := Search(context.Background(), 3, DefaultSearchHashTableMB, func(index int, fen string, nodes uint32) {
+			if index != positions || nodes == 0 {
+				t.Errorf("unexpected position %d: got=%d nodes at index %d", positions, nodes, index)
+			}
+			positions++
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if r.Positions != len(searchPositions) || positions != len(searchPositions) {
+			t.Errorf("unexpected positions: got=%d want=%d", r.Positions, len(searchPositions))
+		}
+		results = append(results, r)
+	}
+	if results[0].Nodes != results[1].Nodes {
+		t.Errorf("unexpected nondeterministic nodes: got=%d want=%d", results[1].Nodes, results[0].Nodes)
+	}
+}
+
+func TestSearchFreshHash(t *testing.T) {
+	t.Parallel()
+	want := make([]uint32, len(searchPositions))
+	if _, err := Search(context.Background(), 3, DefaultSearchHashTableMB, func(index int, _ string, nodes uint32) {
+		want[index] = nodes
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, i := range []int{len(searchPositions) - 1, len(searchPositions) / 2} {
+		got, _, err := searchPosition(context.Background(), searchPositions[i], 3, DefaultSearchHashTableMB)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want[i] {
+			t.Errorf("unexpected nodes of position %d alone: got=%d want=%d", i, got, want[i])
+		}
+	}
+}
+
+func TestSearchCancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Search(ctx, DefaultSearchDepth, 1, nil); err == nil {
+		t.Errorf("error expected: got=nil")
+	}
+}
@@ -0,0 +1,139 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daystram/gambit/board"
+)
+
+// PerftCase is a position of a perft suite with the expected node counts by depth.
+type PerftCase struct {
+	FEN    string
+	Depths []PerftDepth
+}
+
+type PerftDepth struct {
+	Depth int
+	Nodes uint64
+}
+
+// PerftResult is the node count of a case at a depth.
+type PerftResult struct {
+	Case    *PerftCase
+	Depth   int
+	Want    uint64
+	Got     uint64
+	Elapsed time.Duration
+}
+
+func (r *PerftResult) Passed() bool {
+	return r.Got == r.Want
+}
+
+// LoadPerftSuite reads the perft suite file, see ReadPerftSuite.
+func LoadPerftSuite(path string) ([]*PerftCase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPerftSuite(f)
+}
+
+// ReadPerftSuite reads a perft suite of one case per line, e.g. "<fen> ;D1 20 ;D2 400", where the FEN move
+// clocks may be omitted. Empty lines and lines starting with # are skipped.
+func ReadPerftSuite(r io.Reader) ([]*PerftCase, error) {
+	var cases []*PerftCase
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		c, err := parsePerftCase(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cases, nil
+}
+
+func parsePerftCase(text string) (*PerftCase, error) {
+	parts := strings.Split(text, ";")
+	fields := strings.Fields(parts[0])
+	switch len(fields) {
+	case 4:
+		fields = append(fields, "0", "1")
+	case 6:
+	default:
+		return nil, board.ErrInvalidFEN
+	}
+	c := &PerftCase{
+		FEN: strings.Join(fields, " "),
+	}
+	if _, err := board.NewBoard(board.WithFEN(c.FEN)); err != nil {
+		return nil, err
+	}
+
+	for _, part := range parts[1:] {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "D") {
+			return nil, fmt.Errorf("invalid depth: %s", strings.TrimSpace(part))
+		}
+		depth, err := strconv.Atoi(fields[0][1:])
+		if err != nil || depth < 0 {
+			return nil, fmt.Errorf("invalid depth: %s", fields[0])
+		}
+		nodes, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node count: %s", fields[1])
+		}
+		c.Depths = append(c.Depths, PerftDepth{Depth: depth, Nodes: nodes})
+	}
+	return c, nil
+}
+
+// RunPerftSuite counts the nodes of each case up to the max depth, or all depths if 0, calling onResult after
+// each count. It returns the number of failed counts.
+func RunPerftSuite(cases []*PerftCase, maxDepth int, tt *PerftTable, onResult func(*PerftResult)) (int, error) {
+	var failed int
+	for _, c := range cases {
+		b, err := board.NewBoard(board.WithFEN(c.FEN))
+		if err != nil {
+			return failed, err
+		}
+		for _, d := range c.Depths {
+			if maxDepth != 0 && d.Depth > maxDepth {
+				continue
+			}
+			start := time.Now()
+			r := &PerftResult{
+				Case:    c,
+				Depth:   d.Depth,
+				Want:    d.Nodes,
+				Got:     Count(b, d.Depth, tt),
+				Elapsed: time.Since(start),
+			}
+			if !r.Passed() {
+				failed++
+			}
+			if onResult != nil {
+				onResult(r)
+			}
+		}
+	}
+	return failed, nil
+}
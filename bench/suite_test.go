@@ -0,0 +1,145 @@
+package bench
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daystram/gambit/board"
+)
+
+func TestRunPerftSuite(t *testing.T) {
+	t.Parallel()
+	cases, err := LoadPerftSuite("testdata/perftsuite.epd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name     string
+		maxDepth int
+		tt       *PerftTable
+	}{
+		{name: "without table", maxDepth: 3},
+		{name: "with table", maxDepth: 4, tt: NewPerftTable(16)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var counts int
+			failed, err := RunPerftSuite(cases, tt.maxDepth, tt.tt, func(r *PerftResult) {
+				counts++
+				if !r.Passed() {
+					t.Errorf("unexpected nodes of %s at depth %d: got=%d want=%d", r.Case.FEN, r.Depth, r.Got, r.Want)
+				}
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if failed != 0 || counts != len(cases)*tt.maxDepth {
+				t.Errorf("unexpected results: got=%d failed of %d want=0 failed of %d", failed, counts, len(cases)*tt.maxDepth)
+			}
+		})
+	}
+}
+
+func TestReadPerftSuite(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		suite      string
+		wantFEN    string
+		wantDepths []PerftDepth
+		wantErr    string
+	}{
+		{
+			name:       "full FEN",
+			suite:      "4k3/8/8/8/8/8/8/4K3 w - - 3 9 ;D1 5 ;D2 25",
+			wantFEN:    "4k3/8/8/8/8/8/8/4K3 w - - 3 9",
+			wantDepths: []PerftDepth{{Depth: 1, Nodes: 5}, {Depth: 2, Nodes: 25}},
+		},
+		{
+			name:       "missing move clocks",
+			suite:      "# comment\n\n4k3/8/8/8/8/8/8/4K3 b - - ;D1 5;",
+			wantFEN:    "4k3/8/8/8/8/8/8/4K3 b - - 0 1",
+			wantDepths: []PerftDepth{{Depth: 1, Nodes: 5}},
+		},
+		{
+			name:    "invalid FEN",
+			suite:   "4k3/8/8/8/8/8/8/4K3 w ;D1 5",
+			wantErr: "line 1: invalid fen",
+		},
+		{
+			name:    "invalid depth",
+			suite:   "4k3/8/8/8/8/8/8/4K3 w - - ;X1 5",
+			wantErr: "line 1: invalid depth: X1 5",
+		},
+		{
+			name:    "invalid node count",
+			suite:   "4k3/8/8/8/8/8/8/4K3 w - - ;D1 five",
+			wantErr: "line 1: invalid node count: five",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cases, err := ReadPerftSuite(strings.NewReader(tt.suite))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("unexpected error: got=%v want=%s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(cases) != 1 || cases[0].FEN != tt.wantFEN {
+				t.Fatalf("unexpected cases: got=%+v want fen=%s", cases, tt.wantFEN)
+			}
+			if len(cases[0].Depths) != len(tt.wantDepths) {
+				t.Fatalf("unexpected depths: got=%v want=%v", cases[0].Depths, tt.wantDepths)
+			}
+			for i, d := range cases[0].Depths {
+				if d != tt.wantDepths[i] {
+					t.Errorf("unexpected depth: got=%v want=%v", d, tt.wantDepths[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDivide(t *testing.T) {
+	t.Parallel()
+	b, err := board.NewBoard(board.WithFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results := Divide(b, 2, NewPerftTable(1))
+	if len(results) != 48 {
+		t.Fatalf("unexpected root moves: got=%d want=%d", len(results), 48)
+	}
+	var sum uint64
+	for i, r := range results {
+		if i > 0 && results[i-1].Move.UCI() >= r.Move.UCI() {
+			t.Errorf("unexpected order: %s before %s", results[i-1].Move.UCI(), r.Move.UCI())
+		}
+		sum += r.Nodes
+	}
+	if sum != 2039 {
+		t.Errorf("unexpected nodes: got=%d want=%d", sum, 2039)
+	}
+	for _, want := range []string{"e1g1", "e1c1"} {
+		if !containsDivide(results, want) {
+			t.Errorf("missing root move: %s", want)
+		}
+	}
+}
+
+func containsDivide(results []DivideResult, uci string) bool {
+	for _, r := range results {
+		if r.Move.UCI() == uci {
+			return true
+		}
+	}
+	return false
+}
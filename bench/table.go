@@ -0,0 +1,49 @@
+package bench
+
+import (
+	"unsafe"
+)
+
+// PerftTable caches the node counts of positions by their hash and depth. It is not safe for concurrent use.
+type PerftTable struct {
+	table []perftEntry
+}
+
+type perftEntry struct {
+	hash  uint64
+	nodes uint64
+	depth int32
+}
+
+// NewPerftTable creates a table of the size in MB, or nil if the size is 0. A nil table caches nothing.
+func NewPerftTable(sizeMB uint32) *PerftTable {
+	count := uint64(sizeMB) * 1e6 / uint64(unsafe.Sizeof(perftEntry{}))
+	if count == 0 {
+		return nil
+	}
+	return &PerftTable{
+		table: make([]perftEntry, count),
+	}
+}
+
+func (t *PerftTable) Get(hash uint64, depth int) (uint64, bool) {
+	if t == nil {
+		return 0, false
+	}
+	e := t.table[t.index(hash, depth)]
+	if e.depth != int32(depth) || e.hash != hash {
+		return 0, false
+	}
+	return e.nodes, true
+}
+
+func (t *PerftTable) Set(hash uint64, depth int, nodes uint64) {
+	if t == nil {
+		return
+	}
+	t.table[t.index(hash, depth)] = perftEntry{hash: hash, nodes: nodes, depth: int32(depth)}
+}
+
+func (t *PerftTable) index(hash uint64, depth int) uint64 {
+	return (hash ^ uint64(depth)*0x9e3779b97f4a7c15) % uint64(len(t.table))
+}
@@ -0,0 +1,113 @@
+package board
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/daystram/gambit/position"
+)
+
+// Bitboard is a set of cells, where the bit n is set if the cell position.Pos(n) is in the set, i.e. A1 is the
+// least significant bit and H8 the most significant.
+type Bitboard uint64
+
+// NewBitboard returns the set of the cells.
+func NewBitboard(poss ...position.Pos) Bitboard {
+	var bm Bitboard
+	for _, pos := range poss {
+		bm.Set(pos)
+	}
+	return bm
+}
+
+// RankMask returns the cells of the rank, e.g. position.Rank1.
+func RankMask(rank position.Pos) Bitboard {
+	return maskRow[rank]
+}
+
+// FileMask returns the cells of the file, e.g. position.FileA.
+func FileMask(file position.Pos) Bitboard {
+	return maskCol[file]
+}
+
+// DiagonalMask returns the cells of the A1-H8 direction diagonal through the cell.
+func DiagonalMask(pos position.Pos) Bitboard {
+	return maskDia[pos]
+}
+
+// AntiDiagonalMask returns the cells of the A8-H1 direction diagonal through the cell.
+func AntiDiagonalMask(pos position.Pos) Bitboard {
+	return maskADia[pos]
+}
+
+// Attacks returns the cells attacked by the piece on the cell, where sliding pieces are blocked by the
+// occupancy. Pawn attacks depend on their side, see PawnAttacks.
+func Attacks(p Piece, pos position.Pos, occupancy Bitboard) Bitboard {
+	return attacksPiece(p, pos, occupancy)
+}
+
+// PawnAttacks returns the cells attacked by the Pawn of the side on the cell.
+func PawnAttacks(s Side, pos position.Pos) Bitboard {
+	return attacksPawn(s, maskCell[pos])
+}
+
+func (bm Bitboard) Has(pos position.Pos) bool {
+	return bm&maskCell[pos] != 0
+}
+
+func (bm Bitboard) BitCount() uint8 {
+	return uint8(bits.OnesCount64(uint64(bm)))
+}
+
+// PopLSB removes the least significant cell from the set, returning it.
+func (bm *Bitboard) PopLSB() position.Pos {
+	pos := bm.LS1B()
+	*bm &= *bm - 1
+	return pos
+}
+
+// Squares returns the cells of the set, in ascending order.
+func (bm Bitboard) Squares() []position.Pos {
+	poss := make([]position.Pos, 0, bm.BitCount())
+	for bm != 0 {
+		poss = append(poss, bm.PopLSB())
+	}
+	return poss
+}
+
+func (bm *Bitboard) Set(pos position.Pos) {
+	*bm |= maskCell[pos]
+}
+
+func (bm *Bitboard) Unset(pos position.Pos) {
+	*bm &^= maskCell[pos]
+}
+
+func (bm Bitboard) LS1B() position.Pos {
+	return position.Pos(bits.TrailingZeros64(uint64(bm)))
+}
+
+func (bm Bitboard) Dump(sym ...rune) string {
+	builder := strings.Builder{}
+	for y := position.Pos(Height); y > 0; y-- {
+		_, _ = builder.WriteString(fmt.Sprintf(" %d |", y))
+		for x := position.Pos(0); x < Width; x++ {
+			if bm&maskCell[(y-1)*Height+x] != 0 {
+				s := "#"
+				if len(sym) == 1 {
+					s = string(sym[0])
+				}
+				_, _ = builder.WriteString(fmt.Sprintf(" %s ", s))
+			} else {
+				_, _ = builder.WriteString(" . ")
+			}
+		}
+		_, _ = builder.WriteString("\n")
+	}
+	_, _ = builder.WriteString("    ------------------------\n    ")
+	for x := position.Pos(0); x < Width; x++ {
+		_, _ = builder.WriteString(fmt.Sprintf(" %s ", x.NotationComponentX()))
+	}
+	return builder.String()
+}
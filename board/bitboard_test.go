@@ -0,0 +1,138 @@
+package board
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/daystram/gambit/position"
+)
+
+func TestBitboard(t *testing.T) {
+	t.Parallel()
+	bm := NewBitboard(position.H8, position.A1, position.E4)
+	if got, want := fmt.Sprint(bm.Squares()), fmt.Sprint([]position.Pos{position.A1, position.E4, position.H8}); got != want {
+		t.Errorf("unexpected squares: got=%s want=%s", got, want)
+	}
+	if !bm.Has(position.E4) || bm.Has(position.E5) {
+		t.Errorf("unexpected cells: got=%v", bm.Squares())
+	}
+	if got := bm.PopLSB(); got != position.A1 {
+		t.Errorf("unexpected LSB: got=%s want=%s", got, position.A1)
+	}
+	if got := bm.BitCount(); got != 2 {
+		t.Errorf("unexpected count: got=%d want=%d", got, 2)
+	}
+
+	tests := []struct {
+		name string
+		got  Bitboard
+		want Bitboard
+	}{
+		{
+			name: "rank",
+			got:  RankMask(position.Rank2),
+			want: NewBitboard(position.A2, position.B2, position.C2, position.D2, position.E2, position.F2, position.G2, position.H2),
+		},
+		{
+			name: "file",
+			got:  FileMask(position.FileC),
+			want: NewBitboard(position.C1, position.C2, position.C3, position.C4, position.C5, position.C6, position.C7, position.C8),
+		},
+		{
+			name: "diagonal",
+			got:  DiagonalMask(position.C2),
+			want: NewBitboard(position.B1, position.C2, position.D3, position.E4, position.F5, position.G6, position.H7),
+		},
+		{
+			name: "anti-diagonal",
+			got:  AntiDiagonalMask(position.C2),
+			want: NewBitboard(position.D1, position.C2, position.B3, position.A4),
+		},
+		{
+			name: "knight",
+			got:  Attacks(PieceKnight, position.A1, 0),
+			want: NewBitboard(position.B3, position.C2),
+		},
+		{
+			name: "rook blocked",
+			got:  Attacks(PieceRook, position.A1, NewBitboard(position.A3, position.C1)),
+			want: NewBitboard(position.A2, position.A3, position.B1, position.C1),
+		},
+		{
+			name: "bishop blocked",
+			got:  Attacks(PieceBishop, position.D4, NewBitboard(position.F6, position.B2)),
+			want: NewBitboard(position.C3, position.B2, position.E5, position.F6, position.C5, position.B6, position.A7, position.E3, position.F2, position.G1),
+		},
+		{
+			name: "white pawn",
+			got:  PawnAttacks(SideWhite, position.A2),
+			want: NewBitboard(position.B3),
+		},
+		{
+			name: "black pawn",
+			got:  PawnAttacks(SideBlack, position.E7),
+			want: NewBitboard(position.D6, position.F6),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if tt.got != tt.want {
+				t.Errorf("unexpected cells: got=%v want=%v", tt.got.Squares(), tt.want.Squares())
+			}
+		})
+	}
+}
+
+func TestAttackedBy(t *testing.T) {
+	t.Parallel()
+	for _, fen := range fuzzFENs {
+		fen := fen
+		t.Run(fen, func(t *testing.T) {
+			t.Parallel()
+			b, err := NewBoard(WithFEN(fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, s := range []Side{SideWhite, SideBlack} {
+				attacked := b.AttackedBy(s)
+				for pos := position.Pos(0); pos < TotalCells; pos++ {
+					if got, want := attacked.Has(pos), b.attackers(s, pos, b.occupied) != 0; got != want {
+						t.Errorf("unexpected attack of %s by %s: got=%v want=%v", pos, s, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPinned(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen  string
+		side Side
+		want Bitboard
+	}{
+		{fen: DefaultStartingPositionFEN, side: SideWhite, want: 0},
+		{fen: "4k3/4r3/8/8/8/8/4B3/4K3 w - - 0 1", side: SideWhite, want: NewBitboard(position.E2)},
+		{fen: "4k3/4r3/8/8/8/8/4B3/4K3 w - - 0 1", side: SideBlack, want: 0},
+		{fen: "4k3/4r3/8/8/8/4P3/4B3/4K3 w - - 0 1", side: SideWhite, want: 0},
+		{fen: "q3k3/1n6/8/8/8/8/6B1/4K2R b - - 0 1", side: SideBlack, want: 0},
+		{fen: "4k3/3n4/8/1B6/8/8/8/4K3 b - - 0 1", side: SideBlack, want: NewBitboard(position.D7)},
+		{fen: "k7/8/8/8/8/8/8/KR5q w - - 0 1", side: SideWhite, want: NewBitboard(position.B1)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.fen, func(t *testing.T) {
+			t.Parallel()
+			b, err := NewBoard(WithFEN(tt.fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := b.Pinned(tt.side); got != tt.want {
+				t.Errorf("unexpected pinned: got=%v want=%v", got.Squares(), tt.want.Squares())
+			}
+		})
+	}
+}
@@ -9,20 +9,20 @@ import (
 )
 
 var (
-	ErrInvalidFEN  = errors.New("invalid fen")
-	ErrInvalidMove = errors.New("invalid move")
+	ErrInvalidFEN   = errors.New("invalid fen")
+	ErrInvalidMove  = errors.New("invalid move")
+	ErrInvalidBoard = errors.New("invalid board")
 )
 
-type bitmap uint64
-type sideBitmaps [3]bitmap
-type pieceBitmaps [7]bitmap
+type sideBitmaps [3]Bitboard
+type pieceBitmaps [7]Bitboard
 type cellList [64]uint8
 type sideValue [3]int16
 
 // Little-endian rank-file (LERF) mapping
 type Board struct {
 	// grid data
-	occupied        bitmap
+	occupied        Bitboard
 	sides           sideBitmaps
 	pieces          pieceBitmaps
 	cells           cellList
@@ -32,7 +32,7 @@ type Board struct {
 	phase           int8
 
 	// meta
-	enPassant     bitmap
+	enPassant     Bitboard
 	castleRights  CastleRights
 	halfMoveClock uint8
 	fullMoveClock uint8
@@ -43,7 +43,8 @@ type Board struct {
 }
 
 type boardConfig struct {
-	fen string
+	fen     string
+	fenMode FENMode
 }
 
 type BoardOption func(*boardConfig)
@@ -54,6 +55,13 @@ func WithFEN(fen string) BoardOption {
 	}
 }
 
+// WithFENMode sets how the FEN is parsed, defaulting to FENModeStandard.
+func WithFENMode(mode FENMode) BoardOption {
+	return func(cfg *boardConfig) {
+		cfg.fenMode = mode
+	}
+}
+
 func NewBoard(opts ...BoardOption) (*Board, error) {
 	cfg := &boardConfig{
 		fen: DefaultStartingPositionFEN,
@@ -63,7 +71,7 @@ func NewBoard(opts ...BoardOption) (*Board, error) {
 	}
 
 	b := Board{}
-	err := UnmarshalFEN(cfg.fen, &b)
+	err := unmarshalFEN(cfg.fen, &b, cfg.fenMode)
 	if err != nil {
 		return nil, err
 	}
@@ -110,9 +118,9 @@ func (b *Board) GeneratePseudoLegalMoves() []Move {
 	return mvs
 }
 
-func (b *Board) GetCellAttackers(attackerSide Side, pos position.Pos, limit uint8) (uint8, bitmap) {
+func (b *Board) GetCellAttackers(attackerSide Side, pos position.Pos, limit uint8) (uint8, Bitboard) {
 	var count uint8
-	var attackBM bitmap
+	var attackBM Bitboard
 	attackerSideMask := b.sides[attackerSide]
 	posMask := maskCell[pos]
 
@@ -193,14 +201,14 @@ func (b *Board) IsKingChecked(s Side) bool {
 	return c != 0
 }
 
-func (b *Board) generateMovePawn(mvs *[]Move, fromMask, allowedToMask bitmap) {
+func (b *Board) generateMovePawn(mvs *[]Move, fromMask, allowedToMask Bitboard) {
 	for fromMask != 0 {
 		fromPos := fromMask.LS1B()
 		fromCell := maskCell[fromPos] & fromMask
 		fromMask &= fromMask - 1
 
-		var candidateToBM bitmap
-		var candidateEnPassantTargetBM bitmap
+		var candidateToBM Bitboard
+		var candidateEnPassantTargetBM Bitboard
 		if b.turn == SideWhite {
 			moveN1 := ShiftN(fromCell&^maskRow[7]) &^ b.occupied
 			moveN2 := ShiftN(moveN1&maskRow[2]) &^ b.occupied
@@ -255,7 +263,7 @@ func (b *Board) generateMovePawn(mvs *[]Move, fromMask, allowedToMask bitmap) {
 	}
 }
 
-func (b *Board) generateMoveKnight(mvs *[]Move, fromMask, allowedToMask bitmap) {
+func (b *Board) generateMoveKnight(mvs *[]Move, fromMask, allowedToMask Bitboard) {
 	for fromMask != 0 {
 		fromPos := fromMask.LS1B()
 		fromMask &= fromMask - 1
@@ -279,7 +287,7 @@ func (b *Board) generateMoveKnight(mvs *[]Move, fromMask, allowedToMask bitmap)
 	}
 }
 
-func (b *Board) generateMoveBishop(mvs *[]Move, fromMask, allowedToMask bitmap) {
+func (b *Board) generateMoveBishop(mvs *[]Move, fromMask, allowedToMask Bitboard) {
 	for fromMask != 0 {
 		fromPos := fromMask.LS1B()
 		fromMask &= fromMask - 1
@@ -304,7 +312,7 @@ func (b *Board) generateMoveBishop(mvs *[]Move, fromMask, allowedToMask bitmap)
 	}
 }
 
-func (b *Board) generateMoveRook(mvs *[]Move, fromMask, allowedToMask bitmap) {
+func (b *Board) generateMoveRook(mvs *[]Move, fromMask, allowedToMask Bitboard) {
 	for fromMask != 0 {
 		fromPos := fromMask.LS1B()
 		fromMask &= fromMask - 1
@@ -329,7 +337,7 @@ func (b *Board) generateMoveRook(mvs *[]Move, fromMask, allowedToMask bitmap) {
 	}
 }
 
-func (b *Board) generateMoveQueen(mvs *[]Move, fromMask, allowedToMask bitmap) {
+func (b *Board) generateMoveQueen(mvs *[]Move, fromMask, allowedToMask Bitboard) {
 	for fromMask != 0 {
 		fromPos := fromMask.LS1B()
 		fromMask &= fromMask - 1
@@ -354,7 +362,7 @@ func (b *Board) generateMoveQueen(mvs *[]Move, fromMask, allowedToMask bitmap) {
 	}
 }
 
-func (b *Board) generateMoveKing(mvs *[]Move, fromPos position.Pos, allowedToMask bitmap) {
+func (b *Board) generateMoveKing(mvs *[]Move, fromPos position.Pos, allowedToMask Bitboard) {
 	candidateToBM := maskKing[fromPos] & allowedToMask
 
 	for candidateToBM != 0 {
@@ -504,7 +512,7 @@ func (b *Board) ApplyNull() UnApplyFunc {
 	// disable enpassant
 	prevEnPassant := b.enPassant
 	b.hash ^= zobristConstantEnPassant[b.enPassant.LS1B()]
-	b.enPassant = bitmap(0)
+	b.enPassant = Bitboard(0)
 	b.hash ^= zobristConstantEnPassant[b.enPassant.LS1B()]
 
 	// reset half move clock
@@ -590,6 +598,7 @@ func (b *Board) Apply(mv Move) (UnApplyFunc, bool) {
 		b.materialValue[ourTurn] -= scoreMaterial[fromPiece]
 		b.positionValueMG[ourTurn] -= scorePositionMG[fromPiece][scorePositionMap[ourTurn][fromPos]]
 		b.positionValueEG[ourTurn] -= scorePositionEG[fromPiece][scorePositionMap[ourTurn][fromPos]]
+		b.phase -= phaseConstant[fromPiece]
 
 		// remove captured piece at capturedPos
 		if isCapture {
@@ -617,12 +626,13 @@ func (b *Board) Apply(mv Move) (UnApplyFunc, bool) {
 		b.materialValue[ourTurn] += scoreMaterial[toPiece]
 		b.positionValueMG[ourTurn] += scorePositionMG[toPiece][scorePositionMap[ourTurn][toPos]]
 		b.positionValueEG[ourTurn] += scorePositionEG[toPiece][scorePositionMap[ourTurn][toPos]]
+		b.phase += phaseConstant[toPiece]
 	}
 
 	// update enPassant
 	prevEnPassant := b.enPassant
 	b.hash ^= zobristConstantEnPassant[b.enPassant.LS1B()]
-	b.enPassant = bitmap(0)
+	b.enPassant = Bitboard(0)
 	if fromPiece == PiecePawn {
 		if ourTurn == SideWhite && toPos-fromPos == 16 {
 			b.enPassant = maskCell[toPos-Width]
@@ -734,6 +744,7 @@ func (b *Board) Apply(mv Move) (UnApplyFunc, bool) {
 			b.materialValue[ourTurn] -= scoreMaterial[toPiece]
 			b.positionValueMG[ourTurn] -= scorePositionMG[toPiece][scorePositionMap[ourTurn][toPos]]
 			b.positionValueEG[ourTurn] -= scorePositionEG[toPiece][scorePositionMap[ourTurn][toPos]]
+			b.phase -= phaseConstant[toPiece]
 
 			// place captured piece at capturedPos
 			if isCapture {
@@ -751,6 +762,7 @@ func (b *Board) Apply(mv Move) (UnApplyFunc, bool) {
 			b.materialValue[ourTurn] += scoreMaterial[fromPiece]
 			b.positionValueMG[ourTurn] += scorePositionMG[fromPiece][scorePositionMap[ourTurn][fromPos]]
 			b.positionValueEG[ourTurn] += scorePositionEG[fromPiece][scorePositionMap[ourTurn][fromPos]]
+			b.phase += phaseConstant[fromPiece]
 		}
 
 		// revert enPassant
@@ -782,10 +794,21 @@ func (b *Board) Apply(mv Move) (UnApplyFunc, bool) {
 	}, !b.IsKingChecked(ourTurn)
 }
 
-func (b *Board) GetBitmap(s Side, p Piece) bitmap {
+// GetBitmap returns the cells of the pieces of the side.
+func (b *Board) GetBitmap(s Side, p Piece) Bitboard {
 	return b.sides[s] & b.pieces[p]
 }
 
+// GetSideBitmap returns the cells of all pieces of the side.
+func (b *Board) GetSideBitmap(s Side) Bitboard {
+	return b.sides[s]
+}
+
+// Occupied returns the cells of all pieces.
+func (b *Board) Occupied() Bitboard {
+	return b.occupied
+}
+
 func (b *Board) GetSideAndPieces(pos position.Pos) (Side, Piece) {
 	l := b.cells[pos]
 	return Side(l >> 4), Piece(l & 0x0F)
@@ -859,14 +882,9 @@ func (b *Board) State() State {
 		return b.state
 	}
 
-	var legalMoves int
-	for _, mv := range b.GeneratePseudoLegalMoves() {
-		if b.IsLegal(mv) {
-			legalMoves++
-		}
-	}
+	hasLegalMoves := b.HasLegalMoves()
 	if b.IsKingChecked(b.turn) {
-		if legalMoves == 0 {
+		if !hasLegalMoves {
 			if b.turn == SideWhite {
 				return StateCheckmateWhite
 			}
@@ -877,7 +895,7 @@ func (b *Board) State() State {
 		}
 		return StateCheckBlack
 	}
-	if legalMoves == 0 {
+	if !hasLegalMoves {
 		return StateStalemate
 	}
 
@@ -918,6 +936,15 @@ func (b *Board) FullMoveClock() uint8 {
 	return b.fullMoveClock
 }
 
+func (b *Board) CastleRights() CastleRights {
+	return b.castleRights
+}
+
+// EnPassant returns the en passant target cell, if any.
+func (b *Board) EnPassant() (position.Pos, bool) {
+	return b.enPassant.LS1B(), b.enPassant != 0
+}
+
 func (b *Board) Clone() *Board {
 	return &Board{
 		occupied:        b.occupied,
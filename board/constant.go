@@ -17,7 +17,7 @@ const (
 var (
 	DefaultStartingPositionFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
 
-	maskCol = [Width]bitmap{
+	maskCol = [Width]Bitboard{
 		position.FileA: 0x_01_01_01_01_01_01_01_01,
 		position.FileB: 0x_02_02_02_02_02_02_02_02,
 		position.FileC: 0x_04_04_04_04_04_04_04_04,
@@ -27,7 +27,7 @@ var (
 		position.FileG: 0x_40_40_40_40_40_40_40_40,
 		position.FileH: 0x_80_80_80_80_80_80_80_80,
 	}
-	maskRow = [Height]bitmap{
+	maskRow = [Height]Bitboard{
 		position.Rank1: 0x_00_00_00_00_00_00_00_FF,
 		position.Rank2: 0x_00_00_00_00_00_00_FF_00,
 		position.Rank3: 0x_00_00_00_00_00_FF_00_00,
@@ -37,13 +37,13 @@ var (
 		position.Rank7: 0x_00_FF_00_00_00_00_00_00,
 		position.Rank8: 0x_FF_00_00_00_00_00_00_00,
 	}
-	maskCell   [TotalCells + 1]bitmap
-	maskDia    [TotalCells + 1]bitmap
-	maskADia   [TotalCells + 1]bitmap
-	maskKnight [TotalCells + 1]bitmap
-	maskKing   [TotalCells + 1]bitmap
+	maskCell   [TotalCells + 1]Bitboard
+	maskDia    [TotalCells + 1]Bitboard
+	maskADia   [TotalCells + 1]Bitboard
+	maskKnight [TotalCells + 1]Bitboard
+	maskKing   [TotalCells + 1]Bitboard
 
-	maskCastling = [4 + 1]bitmap{}
+	maskCastling = [4 + 1]Bitboard{}
 	posCastling  = [4 + 1][6 + 1][2]position.Pos{
 		CastleDirectionWhiteRight: {
 			PieceKing: {position.E1, position.G1},
@@ -270,11 +270,11 @@ func initMask() {
 	}
 
 	for pos := position.Pos(0); pos < TotalCells; pos++ {
-		mask := bitmap(0)
+		mask := Bitboard(0)
 		x, y := pos%Width, pos/Width
 		x, y = x-min(x, y), y-min(x, y)
 		for x < Width && y < Height {
-			mask |= bitmap(1 << (y*Width + x))
+			mask |= Bitboard(1 << (y*Width + x))
 			x++
 			y++
 		}
@@ -282,11 +282,11 @@ func initMask() {
 	}
 
 	for pos := position.Pos(0); pos < TotalCells; pos++ {
-		mask := bitmap(0)
+		mask := Bitboard(0)
 		x, y := pos%Width, pos/Width
 		x, y = x-min(x, Height-y-1), y+min(x, Height-y-1)
 		for x < Width && y >= 0 {
-			mask |= bitmap(1 << (y*Width + x))
+			mask |= Bitboard(1 << (y*Width + x))
 			x++
 			y--
 		}
@@ -295,7 +295,7 @@ func initMask() {
 
 	for pos := position.Pos(0); pos < TotalCells; pos++ {
 		cell := maskCell[pos]
-		mask := bitmap(0)
+		mask := Bitboard(0)
 		mask |= ShiftN(ShiftN(ShiftE(cell &^ maskRow[7] &^ maskRow[6] &^ maskCol[7])))
 		mask |= ShiftN(ShiftN(ShiftW(cell &^ maskRow[7] &^ maskRow[6] &^ maskCol[0])))
 		mask |= ShiftS(ShiftS(ShiftE(cell &^ maskRow[0] &^ maskRow[1] &^ maskCol[7])))
@@ -309,7 +309,7 @@ func initMask() {
 
 	for pos := position.Pos(0); pos < TotalCells; pos++ {
 		cell := maskCell[pos]
-		mask := bitmap(0)
+		mask := Bitboard(0)
 		mask |= ShiftN(cell &^ maskRow[7])
 		mask |= ShiftNE(cell &^ maskRow[7] &^ maskCol[7])
 		mask |= ShiftE(cell &^ maskCol[7])
@@ -321,7 +321,7 @@ func initMask() {
 		maskKing[pos] = mask
 	}
 
-	maskCastling = [5]bitmap{
+	maskCastling = [5]Bitboard{
 		CastleDirectionWhiteRight: maskCell[position.F1] | maskCell[position.G1],
 		CastleDirectionWhiteLeft:  maskCell[position.B1] | maskCell[position.C1] | maskCell[position.D1],
 		CastleDirectionBlackRight: maskCell[position.F8] | maskCell[position.G8],
@@ -349,20 +349,20 @@ func initZobrist() {
 
 func initMagic(p Piece) {
 	var magics *[TotalCells]Magic
-	var genMask func(position.Pos) bitmap
-	var genMovesBM func(position.Pos, bitmap) bitmap
+	var genMask func(position.Pos) Bitboard
+	var genMovesBM func(position.Pos, Bitboard) Bitboard
 	switch p {
 	case PieceBishop:
 		magics = &magicBishop
-		genMask = func(pos position.Pos) bitmap {
+		genMask = func(pos position.Pos) Bitboard {
 			edge := maskCol[position.FileA] | maskCol[position.FileH] | maskRow[position.Rank1] | maskRow[position.Rank8]
 			return HitDiagonals(pos, 0) &^ edge
 		}
 		genMovesBM = HitDiagonals
 	case PieceRook:
 		magics = &magicRook
-		genMask = func(pos position.Pos) bitmap {
-			var edge bitmap
+		genMask = func(pos position.Pos) Bitboard {
+			var edge Bitboard
 			if pos.X() != position.FileA {
 				edge |= maskCol[position.FileA]
 			}
@@ -389,9 +389,9 @@ func initMagic(p Piece) {
 		m.Shift = 64 - m.Mask.BitCount()
 
 		var size int
-		var blocker bitmap
-		var blockers, attacks [4096]bitmap
-		for size, blocker = 0, bitmap(0); size == 0 || blocker != 0; size++ {
+		var blocker Bitboard
+		var blockers, attacks [4096]Bitboard
+		for size, blocker = 0, Bitboard(0); size == 0 || blocker != 0; size++ {
 			blockers[size] = blocker
 			attacks[size] = genMovesBM(pos, blocker)
 			blocker = (blocker - m.Mask) & m.Mask
@@ -401,9 +401,9 @@ func initMagic(p Piece) {
 			m.Magic = 0
 			// ensure sparse magic, as seen on https://github.com/official-stockfish/Stockfish
 			for ((m.Magic * m.Mask) >> 56).BitCount() < 6 {
-				m.Magic = bitmap(r.SparseUint64())
+				m.Magic = Bitboard(r.SparseUint64())
 			}
-			m.Attacks = &[4096]bitmap{}
+			m.Attacks = &[4096]Bitboard{}
 			for i = 0; i < size; i++ {
 				idx := m.GetIndex(blockers[i])
 				if (*m.Attacks)[idx] != 0 && (*m.Attacks)[idx] != attacks[i] {
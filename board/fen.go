@@ -2,6 +2,7 @@ package board
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"unicode"
@@ -9,11 +10,41 @@ import (
 	"github.com/daystram/gambit/position"
 )
 
+var (
+	ErrInvalidKingCount    = fmt.Errorf("%w: invalid king count", ErrInvalidFEN)
+	ErrPawnOnBackRank      = fmt.Errorf("%w: pawn on back rank", ErrInvalidFEN)
+	ErrOpponentInCheck     = fmt.Errorf("%w: side not to move in check", ErrInvalidFEN)
+	ErrInvalidCastleRights = fmt.Errorf("%w: castling rights without king or rook on home cell", ErrInvalidFEN)
+	ErrInvalidEnPassant    = fmt.Errorf("%w: impossible enpassant position", ErrInvalidFEN)
+)
+
+// FENMode sets how a FEN is parsed.
+type FENMode uint8
+
+const (
+	// FENModeStandard requires all six fields, and only rejects positions without a king of each side.
+	FENModeStandard FENMode = iota
+
+	// FENModeStrict also rejects illegal positions, see ValidatePosition.
+	FENModeStrict
+
+	// FENModeLenient defaults the missing fields after the piece placement to "w - - 0 1", caps move clocks
+	// overflowing, and drops castling rights and enpassant positions that are impossible.
+	FENModeLenient
+)
+
 func UnmarshalFEN(fen string, b *Board) error {
+	return unmarshalFEN(fen, b, FENModeStandard)
+}
+
+func unmarshalFEN(fen string, b *Board, mode FENMode) error {
 	if b == nil {
 		return fmt.Errorf("invalid board")
 	}
 	segments := strings.Split(fen, " ")
+	if mode == FENModeLenient {
+		segments = completeSegments(strings.Fields(fen))
+	}
 	if len(segments) != 6 {
 		return fmt.Errorf("%w: incorrect number of segments", ErrInvalidFEN)
 	}
@@ -140,9 +171,100 @@ crLoop:
 	}
 	b.fullMoveClock = uint8(fullMoveClock)
 
+	switch mode {
+	case FENModeStrict:
+		return b.ValidatePosition()
+	case FENModeLenient:
+		b.dropImpossibleRights()
+	}
+	return nil
+}
+
+// ValidatePosition returns an error if the position cannot be reached in a legal game: a side without exactly
+// one king, pawns on the first or last rank, the side not to move in check, castling rights without the king
+// and rook on their home cells, or an enpassant position without the pawn that just moved past it.
+func (b *Board) ValidatePosition() error {
+	for _, s := range []Side{SideWhite, SideBlack} {
+		if kings := b.GetBitmap(s, PieceKing).BitCount(); kings != 1 {
+			return fmt.Errorf("%w: %s has %d", ErrInvalidKingCount, s, kings)
+		}
+	}
+	if pawns := b.pieces[PiecePawn] & (maskRow[0] | maskRow[Height-1]); pawns != 0 {
+		return fmt.Errorf("%w: %s", ErrPawnOnBackRank, pawns.LS1B().Notation())
+	}
+	if b.IsKingChecked(b.turn.Opposite()) {
+		return fmt.Errorf("%w: %s", ErrOpponentInCheck, b.turn.Opposite())
+	}
+	for _, d := range []CastleDirection{
+		CastleDirectionWhiteRight, CastleDirectionWhiteLeft, CastleDirectionBlackRight, CastleDirectionBlackLeft,
+	} {
+		if b.castleRights.IsAllowed(d) && !b.isCastleRightPossible(d) {
+			return fmt.Errorf("%w: %s", ErrInvalidCastleRights, d)
+		}
+	}
+	if b.enPassant != 0 && !b.isEnPassantPossible() {
+		return fmt.Errorf("%w: %s", ErrInvalidEnPassant, b.enPassant.LS1B().Notation())
+	}
 	return nil
 }
 
+// isCastleRightPossible returns true if the king and rook of the direction are on their home cells.
+func (b *Board) isCastleRightPossible(d CastleDirection) bool {
+	s := SideBlack
+	if d.IsWhite() {
+		s = SideWhite
+	}
+	return b.GetBitmap(s, PieceKing)&maskCell[posCastling[d][PieceKing][0]] != 0 &&
+		b.GetBitmap(s, PieceRook)&maskCell[posCastling[d][PieceRook][0]] != 0
+}
+
+// isEnPassantPossible returns true if the enpassant position is behind a pawn of the opponent that has just
+// moved two cells, i.e. the position and the cell the pawn moved from are empty.
+func (b *Board) isEnPassantPossible() bool {
+	pos := b.enPassant.LS1B()
+	pawnPos, fromPos, row := pos-Width, pos+Width, position.Pos(5)
+	if b.turn == SideBlack {
+		pawnPos, fromPos, row = pos+Width, pos-Width, 2
+	}
+	return pos.Y() == row &&
+		b.occupied&(maskCell[pos]|maskCell[fromPos]) == 0 &&
+		b.GetBitmap(b.turn.Opposite(), PiecePawn)&maskCell[pawnPos] != 0
+}
+
+// dropImpossibleRights removes the castling rights and enpassant position that are impossible.
+func (b *Board) dropImpossibleRights() {
+	b.hash ^= zobristConstantCastleRights[b.castleRights]
+	for _, d := range []CastleDirection{
+		CastleDirectionWhiteRight, CastleDirectionWhiteLeft, CastleDirectionBlackRight, CastleDirectionBlackLeft,
+	} {
+		if b.castleRights.IsAllowed(d) && !b.isCastleRightPossible(d) {
+			b.castleRights.Set(d, false)
+		}
+	}
+	b.hash ^= zobristConstantCastleRights[b.castleRights]
+
+	if b.enPassant != 0 && !b.isEnPassantPossible() {
+		b.hash ^= zobristConstantEnPassant[b.enPassant.LS1B()]
+		b.enPassant = 0
+		b.hash ^= zobristConstantEnPassant[b.enPassant.LS1B()]
+	}
+}
+
+// completeSegments defaults the missing segments after the piece placement, and caps the move clocks.
+func completeSegments(segments []string) []string {
+	defaults := []string{"", "w", "-", "-", "0", "1"}
+	if len(segments) == 0 || len(segments) > len(defaults) {
+		return segments
+	}
+	segments = append(segments, defaults[len(segments):]...)
+	for _, i := range []int{4, 5} {
+		if clock, err := strconv.ParseUint(segments[i], 10, 64); err == nil && clock > math.MaxUint8 {
+			segments[i] = strconv.Itoa(math.MaxUint8)
+		}
+	}
+	return segments
+}
+
 func MarshalFEN(b *Board) (string, error) {
 	builder := strings.Builder{}
 	var skip uint8
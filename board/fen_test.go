@@ -1,6 +1,9 @@
 package board
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestFEN(t *testing.T) {
 	t.Parallel()
@@ -93,3 +96,65 @@ func TestFEN(t *testing.T) {
 		})
 	}
 }
+
+func TestFENMode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		fen     string
+		mode    FENMode
+		wantFEN string
+		wantErr error
+	}{
+		{name: "standard start", fen: DefaultStartingPositionFEN, mode: FENModeStandard, wantFEN: DefaultStartingPositionFEN},
+		{name: "standard two kings", fen: "7k/8/8/8/8/8/8/K6K w - - 0 1", mode: FENModeStandard, wantFEN: "7k/8/8/8/8/8/8/K6K w - - 0 1"},
+		{name: "standard truncated", fen: "7k/8/8/8/8/8/8/7K w - -", mode: FENModeStandard, wantErr: ErrInvalidFEN},
+		{name: "strict start", fen: DefaultStartingPositionFEN, mode: FENModeStrict, wantFEN: DefaultStartingPositionFEN},
+		{name: "strict enpassant", fen: "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", mode: FENModeStrict, wantFEN: "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3"},
+		{name: "strict two kings", fen: "7k/8/8/8/8/8/8/K6K w - - 0 1", mode: FENModeStrict, wantErr: ErrInvalidKingCount},
+		{name: "strict no king", fen: "7k/8/8/8/8/8/8/8 w - - 0 1", mode: FENModeStrict, wantErr: ErrInvalidFEN},
+		{name: "strict pawn on first rank", fen: "7k/8/8/8/8/8/8/P6K w - - 0 1", mode: FENModeStrict, wantErr: ErrPawnOnBackRank},
+		{name: "strict pawn on last rank", fen: "p6k/8/8/8/8/8/8/7K w - - 0 1", mode: FENModeStrict, wantErr: ErrPawnOnBackRank},
+		{name: "strict opponent in check", fen: "R6k/8/8/8/8/8/8/7K w - - 0 1", mode: FENModeStrict, wantErr: ErrOpponentInCheck},
+		{name: "strict castling without rook", fen: "r3k3/8/8/8/8/8/8/R3K2R w KQkq - 0 1", mode: FENModeStrict, wantErr: ErrInvalidCastleRights},
+		{name: "strict castling without king", fen: "r3k2r/8/8/8/8/8/8/R2K3R w KQkq - 0 1", mode: FENModeStrict, wantErr: ErrInvalidCastleRights},
+		{name: "strict enpassant without pawn", fen: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1", mode: FENModeStrict, wantErr: ErrInvalidEnPassant},
+		{name: "strict enpassant wrong side", fen: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e3 0 1", mode: FENModeStrict, wantErr: ErrInvalidEnPassant},
+		{name: "strict truncated", fen: "7k/8/8/8/8/8/8/7K w - -", mode: FENModeStrict, wantErr: ErrInvalidFEN},
+		{name: "lenient placement only", fen: "7k/8/8/8/8/8/8/7K", mode: FENModeLenient, wantFEN: "7k/8/8/8/8/8/8/7K w - - 0 1"},
+		{name: "lenient missing clocks", fen: "7k/8/8/8/8/8/8/7K b - -", mode: FENModeLenient, wantFEN: "7k/8/8/8/8/8/8/7K b - - 0 1"},
+		{name: "lenient missing full move clock", fen: "7k/8/8/8/8/8/8/7K b - - 12", mode: FENModeLenient, wantFEN: "7k/8/8/8/8/8/8/7K b - - 12 1"},
+		{name: "lenient whitespace", fen: "  7k/8/8/8/8/8/8/7K   w  -  - 3 40 ", mode: FENModeLenient, wantFEN: "7k/8/8/8/8/8/8/7K w - - 3 40"},
+		{name: "lenient overflowing clocks", fen: "7k/8/8/8/8/8/8/7K w - - 300 400", mode: FENModeLenient, wantFEN: "7k/8/8/8/8/8/8/7K w - - 255 255"},
+		{name: "lenient impossible castling", fen: "r3k3/8/8/8/8/8/8/4K2R w KQkq - 0 1", mode: FENModeLenient, wantFEN: "r3k3/8/8/8/8/8/8/4K2R w Kq - 0 1"},
+		{name: "lenient impossible enpassant", fen: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1", mode: FENModeLenient, wantFEN: DefaultStartingPositionFEN},
+		{name: "lenient empty", fen: "", mode: FENModeLenient, wantErr: ErrInvalidFEN},
+		{name: "lenient extra segment", fen: "7k/8/8/8/8/8/8/7K w - - 0 1 extra", mode: FENModeLenient, wantErr: ErrInvalidFEN},
+		{name: "lenient invalid turn", fen: "7k/8/8/8/8/8/8/7K x", mode: FENModeLenient, wantErr: ErrInvalidFEN},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := NewBoard(WithFEN(tt.fen), WithFENMode(tt.mode))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("unexpected error: got=%v want=%v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if gotFEN := b.FEN(); gotFEN != tt.wantFEN {
+				t.Errorf("unexpected FEN: got=%s want=%s", gotFEN, tt.wantFEN)
+			}
+			if err := b.Validate(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
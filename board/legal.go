@@ -0,0 +1,341 @@
+package board
+
+import "github.com/daystram/gambit/position"
+
+// MaxLegalMoves is the most legal moves of any position, so that move buffers of this capacity never grow.
+const MaxLegalMoves = 218
+
+// moveStage selects the moves generated by generateLegal.
+type moveStage uint8
+
+const (
+	moveStageCaptures moveStage = 1 << iota // captures, including enpassant, and promotions
+	moveStageQuiets                         // non-capturing non-promotions, including castling
+	moveStageAll      = moveStageCaptures | moveStageQuiets
+)
+
+// GenerateLegalMoves appends the legal moves to mvs, returning the extended slice. Unlike
+// GeneratePseudoLegalMoves, the moves need not be checked with IsLegal. Passing a reused buffer, e.g. buf[:0],
+// avoids allocating.
+func (b *Board) GenerateLegalMoves(mvs []Move) []Move {
+	return b.generateLegal(mvs, moveStageAll)
+}
+
+// GenerateCaptures appends the legal captures and promotions to mvs, returning the extended slice.
+func (b *Board) GenerateCaptures(mvs []Move) []Move {
+	return b.generateLegal(mvs, moveStageCaptures)
+}
+
+// GenerateQuiets appends the legal moves that are neither captures nor promotions to mvs, returning the extended
+// slice. Together with GenerateCaptures, every legal move is generated exactly once.
+func (b *Board) GenerateQuiets(mvs []Move) []Move {
+	return b.generateLegal(mvs, moveStageQuiets)
+}
+
+// GenerateChecks appends the legal quiet moves giving check to mvs, returning the extended slice. Checking
+// captures and promotions are generated by GenerateCaptures instead.
+func (b *Board) GenerateChecks(mvs []Move) []Move {
+	start := len(mvs)
+	mvs = b.generateLegal(mvs, moveStageQuiets)
+	checks := mvs[:start]
+	for _, mv := range mvs[start:] {
+		if b.GivesCheck(mv) {
+			checks = append(checks, mv)
+		}
+	}
+	return checks
+}
+
+// IsLegalMove returns true if the move is one of the legal moves of the board. Unlike IsLegal, the move need not
+// have been generated for the board, e.g. when parsed from user input.
+func (b *Board) IsLegalMove(mv Move) bool {
+	var buf [MaxLegalMoves]Move
+	for _, legal := range b.GenerateLegalMoves(buf[:0]) {
+		if legal.Equals(mv) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLegalMoves returns true if the side to move has any legal move.
+func (b *Board) HasLegalMoves() bool {
+	var buf [MaxLegalMoves]Move
+	return len(b.GenerateLegalMoves(buf[:0])) != 0
+}
+
+// GivesCheck returns true if the move of the side to move checks the opponent King, directly or by discovery.
+func (b *Board) GivesCheck(mv Move) bool {
+	ourSide, theirSide := b.turn, b.turn.Opposite()
+	kingPos := b.GetBitmap(theirSide, PieceKing).LS1B()
+	fromCell, toCell := maskCell[mv.From], maskCell[mv.To]
+	toPiece := mv.Piece
+	if mv.IsPromote != PieceUnknown {
+		toPiece = mv.IsPromote
+	}
+
+	occupied := b.occupied&^fromCell | toCell
+	laterals := b.sides[ourSide] & (b.pieces[PieceRook] | b.pieces[PieceQueen]) &^ fromCell
+	diagonals := b.sides[ourSide] & (b.pieces[PieceBishop] | b.pieces[PieceQueen]) &^ fromCell
+	switch toPiece {
+	case PieceRook:
+		laterals |= toCell
+	case PieceBishop:
+		diagonals |= toCell
+	case PieceQueen:
+		laterals |= toCell
+		diagonals |= toCell
+	}
+	if mv.IsEnPassant {
+		occupied &^= maskCell[enPassantCapturedPos(ourSide, mv.To)]
+	}
+	if mv.IsCastle != CastleDirectionUnknown {
+		hopsRook := posCastling[mv.IsCastle][PieceRook]
+		occupied = occupied&^maskCell[hopsRook[0]] | maskCell[hopsRook[1]]
+		laterals = laterals&^maskCell[hopsRook[0]] | maskCell[hopsRook[1]]
+	}
+
+	if attacksLateral(kingPos, occupied)&laterals != 0 || attacksDiagonal(kingPos, occupied)&diagonals != 0 {
+		return true
+	}
+	switch toPiece {
+	case PieceKnight:
+		return maskKnight[mv.To]&maskCell[kingPos] != 0
+	case PiecePawn:
+		return attacksPawn(ourSide, toCell)&maskCell[kingPos] != 0
+	}
+	return false
+}
+
+// generateLegal generates the legal moves of the stage using the check and pin masks, without applying them.
+func (b *Board) generateLegal(mvs []Move, stage moveStage) []Move {
+	ourSide, theirSide := b.turn, b.turn.Opposite()
+	ourMask, theirMask := b.sides[ourSide], b.sides[theirSide]
+	kingPos := b.GetBitmap(ourSide, PieceKing).LS1B()
+
+	var targetMask Bitboard
+	if stage&moveStageCaptures != 0 {
+		targetMask |= theirMask
+	}
+	if stage&moveStageQuiets != 0 {
+		targetMask |= ^b.occupied
+	}
+
+	// King moves to cells not attacked once the King has moved off its ray
+	occupiedWithoutKing := b.occupied &^ maskCell[kingPos]
+	for toMask := maskKing[kingPos] & targetMask &^ ourMask; toMask != 0; toMask &= toMask - 1 {
+		toPos := toMask.LS1B()
+		if b.attackers(theirSide, toPos, occupiedWithoutKing) == 0 {
+			mvs = append(mvs, b.newMove(PieceKing, kingPos, toPos))
+		}
+	}
+
+	checkers := b.attackers(theirSide, kingPos, b.occupied)
+	if checkers.BitCount() > 1 {
+		return mvs // only the King can move out of a double check
+	}
+	checkMask := ^Bitboard(0)
+	if checkers != 0 {
+		checkMask = checkers | between(kingPos, checkers.LS1B())
+	} else if stage&moveStageQuiets != 0 {
+		b.generateCastling(&mvs)
+	}
+	pinMasks, pinned := b.pins(ourSide)
+
+	for fromMask := ourMask &^ b.pieces[PieceKing] &^ b.pieces[PiecePawn]; fromMask != 0; fromMask &= fromMask - 1 {
+		fromPos := fromMask.LS1B()
+		_, p := b.GetSideAndPieces(fromPos)
+		toMask := attacksPiece(p, fromPos, b.occupied) & targetMask & checkMask &^ ourMask
+		if pinned&maskCell[fromPos] != 0 {
+			toMask &= pinMasks[fromPos]
+		}
+		for ; toMask != 0; toMask &= toMask - 1 {
+			mvs = append(mvs, b.newMove(p, fromPos, toMask.LS1B()))
+		}
+	}
+
+	return b.appendLegalPawnMoves(mvs, stage, kingPos, checkMask, pinned, &pinMasks)
+}
+
+// appendLegalPawnMoves generates the legal Pawn moves of the stage, where quiet promotions are captures.
+func (b *Board) appendLegalPawnMoves(
+	mvs []Move, stage moveStage, kingPos position.Pos, checkMask, pinned Bitboard, pinMasks *[TotalCells]Bitboard,
+) []Move {
+	ourSide, theirSide := b.turn, b.turn.Opposite()
+	promoteRow, pushRow := maskRow[7], maskRow[2]
+	if ourSide == SideBlack {
+		promoteRow, pushRow = maskRow[0], maskRow[5]
+	}
+
+	for fromMask := b.GetBitmap(ourSide, PiecePawn); fromMask != 0; fromMask &= fromMask - 1 {
+		fromPos := fromMask.LS1B()
+		fromCell := maskCell[fromPos]
+		allowedMask := checkMask
+		if pinned&fromCell != 0 {
+			allowedMask &= pinMasks[fromPos]
+		}
+
+		var pushMask Bitboard
+		if ourSide == SideWhite {
+			pushMask = ShiftN(fromCell) &^ b.occupied
+			pushMask |= ShiftN(pushMask&pushRow) &^ b.occupied
+		} else {
+			pushMask = ShiftS(fromCell) &^ b.occupied
+			pushMask |= ShiftS(pushMask&pushRow) &^ b.occupied
+		}
+		pushMask &= allowedMask
+		captureMask := attacksPawn(ourSide, fromCell) & b.sides[theirSide] & allowedMask
+
+		if stage&moveStageCaptures != 0 {
+			for toMask := (pushMask & promoteRow) | captureMask; toMask != 0; toMask &= toMask - 1 {
+				mvs = b.appendPawnMove(mvs, fromPos, toMask.LS1B(), promoteRow)
+			}
+			if b.enPassant != 0 && attacksPawn(ourSide, fromCell)&b.enPassant != 0 && b.isEnPassantLegal(fromPos, kingPos) {
+				mv := b.newMove(PiecePawn, fromPos, b.enPassant.LS1B())
+				mv.IsCapture, mv.IsEnPassant = true, true
+				mvs = append(mvs, mv)
+			}
+		}
+		if stage&moveStageQuiets != 0 {
+			for toMask := pushMask &^ promoteRow; toMask != 0; toMask &= toMask - 1 {
+				mvs = append(mvs, b.newMove(PiecePawn, fromPos, toMask.LS1B()))
+			}
+		}
+	}
+	return mvs
+}
+
+func (b *Board) appendPawnMove(mvs []Move, fromPos, toPos position.Pos, promoteRow Bitboard) []Move {
+	mv := b.newMove(PiecePawn, fromPos, toPos)
+	if maskCell[toPos]&promoteRow == 0 {
+		return append(mvs, mv)
+	}
+	for _, prom := range PawnPromoteCandidates {
+		mv.IsPromote = prom
+		mvs = append(mvs, mv)
+	}
+	return mvs
+}
+
+// isEnPassantLegal returns true if the King is not attacked once both Pawns are removed, including along the
+// row of the Pawns, which pin masks cannot detect.
+func (b *Board) isEnPassantLegal(fromPos, kingPos position.Pos) bool {
+	toPos := b.enPassant.LS1B()
+	capturedPos := enPassantCapturedPos(b.turn, toPos)
+	occupied := b.occupied&^maskCell[fromPos]&^maskCell[capturedPos] | maskCell[toPos]
+	return b.attackers(b.turn.Opposite(), kingPos, occupied)&^maskCell[capturedPos] == 0
+}
+
+// pins returns the pieces of the side pinned to its King, and the cells each may move to, i.e. the ray to and
+// including its pinner.
+func (b *Board) pins(s Side) ([TotalCells]Bitboard, Bitboard) {
+	var pinMasks [TotalCells]Bitboard
+	var pinned Bitboard
+	kingPos := b.GetBitmap(s, PieceKing).LS1B()
+	theirMask := b.sides[s.Opposite()]
+	pinners := attacksLateral(kingPos, theirMask)&theirMask&(b.pieces[PieceRook]|b.pieces[PieceQueen]) |
+		attacksDiagonal(kingPos, theirMask)&theirMask&(b.pieces[PieceBishop]|b.pieces[PieceQueen])
+	for ; pinners != 0; pinners &= pinners - 1 {
+		pinnerPos := pinners.LS1B()
+		ray := between(kingPos, pinnerPos)
+		if blockers := ray & b.occupied; blockers.BitCount() == 1 && blockers&b.sides[s] != 0 {
+			pinned |= blockers
+			pinMasks[blockers.LS1B()] = ray | maskCell[pinnerPos]
+		}
+	}
+	return pinMasks, pinned
+}
+
+// Pinned returns the pieces of the side that cannot leave the ray between their King and an opponent sliding
+// piece without exposing the King.
+func (b *Board) Pinned(s Side) Bitboard {
+	_, pinned := b.pins(s)
+	return pinned
+}
+
+// AttackedBy returns the cells attacked by the pieces of the side, including the cells of their own pieces
+// defended.
+func (b *Board) AttackedBy(s Side) Bitboard {
+	attacked := attacksPawn(s, b.GetBitmap(s, PiecePawn))
+	for fromMask := b.sides[s] &^ b.pieces[PiecePawn]; fromMask != 0; {
+		pos := fromMask.PopLSB()
+		_, p := b.GetSideAndPieces(pos)
+		attacked |= attacksPiece(p, pos, b.occupied)
+	}
+	return attacked
+}
+
+// attackers returns the pieces of the side attacking the cell, with the sliding pieces blocked by occupied.
+func (b *Board) attackers(s Side, pos position.Pos, occupied Bitboard) Bitboard {
+	sideMask := b.sides[s]
+	return sideMask & (attacksLateral(pos, occupied)&(b.pieces[PieceRook]|b.pieces[PieceQueen]) |
+		attacksDiagonal(pos, occupied)&(b.pieces[PieceBishop]|b.pieces[PieceQueen]) |
+		maskKnight[pos]&b.pieces[PieceKnight] |
+		maskKing[pos]&b.pieces[PieceKing] |
+		attacksPawn(s.Opposite(), maskCell[pos])&b.pieces[PiecePawn])
+}
+
+func (b *Board) newMove(p Piece, fromPos, toPos position.Pos) Move {
+	return Move{
+		From:      fromPos,
+		To:        toPos,
+		Piece:     p,
+		IsTurn:    b.turn,
+		IsCapture: b.occupied&maskCell[toPos] != 0,
+	}
+}
+
+func attacksPiece(p Piece, pos position.Pos, occupied Bitboard) Bitboard {
+	switch p {
+	case PieceKnight:
+		return maskKnight[pos]
+	case PieceBishop:
+		return attacksDiagonal(pos, occupied)
+	case PieceRook:
+		return attacksLateral(pos, occupied)
+	case PieceQueen:
+		return attacksDiagonal(pos, occupied) | attacksLateral(pos, occupied)
+	case PieceKing:
+		return maskKing[pos]
+	default:
+		return 0
+	}
+}
+
+func attacksLateral(pos position.Pos, occupied Bitboard) Bitboard {
+	m := magicRook[pos]
+	return m.Attacks[m.GetIndex(occupied)]
+}
+
+func attacksDiagonal(pos position.Pos, occupied Bitboard) Bitboard {
+	m := magicBishop[pos]
+	return m.Attacks[m.GetIndex(occupied)]
+}
+
+// attacksPawn returns the cells attacked by the Pawns of the side.
+func attacksPawn(s Side, pawns Bitboard) Bitboard {
+	if s == SideWhite {
+		return ShiftNW(pawns&^maskRow[7]&^maskCol[0]) | ShiftNE(pawns&^maskRow[7]&^maskCol[7])
+	}
+	return ShiftSW(pawns&^maskRow[0]&^maskCol[0]) | ShiftSE(pawns&^maskRow[0]&^maskCol[7])
+}
+
+// between returns the cells strictly between the two cells if they share a row, column, or diagonal.
+func between(pos1, pos2 position.Pos) Bitboard {
+	cell1, cell2 := maskCell[pos1], maskCell[pos2]
+	if attacksLateral(pos1, cell2)&cell2 != 0 {
+		return attacksLateral(pos1, cell2) & attacksLateral(pos2, cell1)
+	}
+	if attacksDiagonal(pos1, cell2)&cell2 != 0 {
+		return attacksDiagonal(pos1, cell2) & attacksDiagonal(pos2, cell1)
+	}
+	return 0
+}
+
+// enPassantCapturedPos returns the cell of the Pawn captured by the side moving to the enpassant cell.
+func enPassantCapturedPos(s Side, toPos position.Pos) position.Pos {
+	if s == SideWhite {
+		return toPos - Width
+	}
+	return toPos + Width
+}
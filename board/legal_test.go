@@ -0,0 +1,152 @@
+package board
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/daystram/gambit/position"
+)
+
+func TestGenerateLegalMoves(t *testing.T) {
+	t.Parallel()
+	fens := append([]string{
+		"8/8/8/KPp4r/8/8/8/7k w - c6 0 2",    // enpassant exposing the King along the row
+		"8/8/8/8/k2Pp2Q/8/8/3K4 b - d3 0 1",  // enpassant exposing the King along the row
+		"8/8/3k4/3Pp3/8/8/8/4K3 w - e6 0 1",  // enpassant capturing the checking Pawn
+		"4k3/8/8/8/8/8/4r3/R3K2R w KQ - 0 1", // castling out of check
+		"4k3/8/8/8/8/5b2/8/R3K2R w KQ - 0 1", // castling through check
+		"4k3/4r3/8/8/8/8/4B3/4K3 w - - 0 1",  // pinned Bishop
+		"4k3/8/8/8/1b6/5n2/8/4K3 w - - 0 1",  // double check
+		"3rk3/2P5/8/8/8/8/8/4K3 w - - 0 1",   // promotions
+	}, fuzzFENs...)
+	for _, fen := range fens {
+		fen := fen
+		t.Run(fen, func(t *testing.T) {
+			t.Parallel()
+			b, err := NewBoard(WithFEN(fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkLegalMoves(t, b, 3)
+		})
+	}
+}
+
+// FuzzGenerateLegalMoves checks the legal moves of the positions reached by the moves selected by each byte.
+func FuzzGenerateLegalMoves(f *testing.F) {
+	for i := range fuzzFENs {
+		f.Add(uint8(i), []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	}
+	f.Fuzz(func(t *testing.T, fenIndex uint8, selectors []byte) {
+		b, err := NewBoard(WithFEN(fuzzFENs[int(fenIndex)%len(fuzzFENs)]))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, selector := range selectors {
+			legal := checkLegalMoves(t, b, 1)
+			if len(legal) == 0 {
+				break
+			}
+			b.Apply(legal[int(selector)%len(legal)])
+		}
+	})
+}
+
+// checkLegalMoves compares the legal moves and stages with the legal pseudo-legal moves down to the depth,
+// returning the legal moves of the board.
+func checkLegalMoves(t *testing.T, b *Board, depth int) []Move {
+	t.Helper()
+	var want []Move
+	for _, mv := range b.GeneratePseudoLegalMoves() {
+		if b.IsLegal(mv) {
+			want = append(want, mv)
+		}
+	}
+	var buf [MaxLegalMoves]Move
+	got := b.GenerateLegalMoves(buf[:0])
+	if gotUCI, wantUCI := sortedUCI(got), sortedUCI(want); gotUCI != wantUCI {
+		t.Fatalf("unexpected legal moves of %s: got=%s want=%s", b.FEN(), gotUCI, wantUCI)
+	}
+
+	var wantCaptures, wantQuiets, wantChecks []Move
+	for _, mv := range want {
+		unApply, _ := b.Apply(mv)
+		givesCheck := b.IsKingChecked(b.Turn())
+		unApply()
+		if b.GivesCheck(mv) != givesCheck {
+			t.Fatalf("unexpected check by %s of %s: got=%v want=%v", mv.UCI(), b.FEN(), !givesCheck, givesCheck)
+		}
+		if mv.IsCapture || mv.IsPromote != PieceUnknown {
+			wantCaptures = append(wantCaptures, mv)
+			continue
+		}
+		wantQuiets = append(wantQuiets, mv)
+		if givesCheck {
+			wantChecks = append(wantChecks, mv)
+		}
+	}
+	for _, stage := range []struct {
+		name string
+		got  []Move
+		want []Move
+	}{
+		{name: "captures", got: b.GenerateCaptures(nil), want: wantCaptures},
+		{name: "quiets", got: b.GenerateQuiets(nil), want: wantQuiets},
+		{name: "checks", got: b.GenerateChecks(nil), want: wantChecks},
+	} {
+		if gotUCI, wantUCI := sortedUCI(stage.got), sortedUCI(stage.want); gotUCI != wantUCI {
+			t.Fatalf("unexpected %s of %s: got=%s want=%s", stage.name, b.FEN(), gotUCI, wantUCI)
+		}
+	}
+	if got := b.HasLegalMoves(); got != (len(want) != 0) {
+		t.Fatalf("unexpected has legal moves of %s: got=%v want=%v", b.FEN(), got, len(want) != 0)
+	}
+
+	if depth > 1 {
+		for _, mv := range want {
+			unApply, _ := b.Apply(mv)
+			checkLegalMoves(t, b, depth-1)
+			unApply()
+		}
+	}
+	return got
+}
+
+func sortedUCI(mvs []Move) string {
+	notations := make([]string, 0, len(mvs))
+	for _, mv := range mvs {
+		notations = append(notations, fmt.Sprintf("%s(%v,%v,%d)", mv.UCI(), mv.IsCapture, mv.IsEnPassant, mv.IsCastle))
+	}
+	sort.Strings(notations)
+	return fmt.Sprint(notations)
+}
+
+func TestIsLegalMove(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		fen  string
+		mv   Move
+		want bool
+	}{
+		{name: "legal", fen: DefaultStartingPositionFEN, mv: Move{From: position.E2, To: position.E4, Piece: PiecePawn, IsTurn: SideWhite}, want: true},
+		{name: "blocked", fen: DefaultStartingPositionFEN, mv: Move{From: position.A1, To: position.A3, Piece: PieceRook, IsTurn: SideWhite}, want: false},
+		{name: "opponent piece", fen: DefaultStartingPositionFEN, mv: Move{From: position.E7, To: position.E5, Piece: PiecePawn, IsTurn: SideBlack}, want: false},
+		{name: "pinned", fen: "4k3/4r3/8/8/8/8/4B3/4K3 w - - 0 1", mv: Move{From: position.E2, To: position.D3, Piece: PieceBishop, IsTurn: SideWhite}, want: false},
+		{name: "wrong piece", fen: DefaultStartingPositionFEN, mv: Move{From: position.E2, To: position.E4, Piece: PieceQueen, IsTurn: SideWhite}, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := NewBoard(WithFEN(tt.fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := b.IsLegalMove(tt.mv); got != tt.want {
+				t.Errorf("unexpected legality of %s: got=%v want=%v", tt.mv.UCI(), got, tt.want)
+			}
+		})
+	}
+}
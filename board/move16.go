@@ -0,0 +1,148 @@
+package board
+
+import "github.com/daystram/gambit/position"
+
+// Move16 is a move packed into 16 bits: the from cell (bits 0-5), the to cell (bits 6-11), the promotion piece
+// (bits 12-13), and the move kind (bits 14-15). The piece and captured piece are left to the board, so it is
+// only meaningful for the position it was made on. The zero value is the null move.
+type Move16 uint16
+
+const (
+	move16KindNormal uint16 = iota
+	move16KindPromote
+	move16KindEnPassant
+	move16KindCastle
+)
+
+// move16Promotions are the promotion pieces by their packed index.
+var move16Promotions = [4]Piece{PieceKnight, PieceBishop, PieceRook, PieceQueen}
+
+// Move16 packs the move.
+func (mv Move) Move16() Move16 {
+	if mv.IsNull() {
+		return 0
+	}
+	kind, promote := move16KindNormal, uint16(0)
+	switch {
+	case mv.IsPromote != PieceUnknown:
+		kind = move16KindPromote
+		for i, p := range move16Promotions {
+			if p == mv.IsPromote {
+				promote = uint16(i)
+			}
+		}
+	case mv.IsEnPassant:
+		kind = move16KindEnPassant
+	case mv.IsCastle != CastleDirectionUnknown:
+		kind = move16KindCastle
+	}
+	return Move16(uint16(mv.From) | uint16(mv.To)<<6 | promote<<12 | kind<<14)
+}
+
+func (m Move16) From() position.Pos {
+	return position.Pos(m & 0x3F)
+}
+
+func (m Move16) To() position.Pos {
+	return position.Pos(m >> 6 & 0x3F)
+}
+
+// Promote returns the promotion piece, or PieceUnknown if the move is not a promotion.
+func (m Move16) Promote() Piece {
+	if m.kind() != move16KindPromote {
+		return PieceUnknown
+	}
+	return move16Promotions[m>>12&0x3]
+}
+
+func (m Move16) IsNull() bool {
+	return m == 0
+}
+
+func (m Move16) UCI() string {
+	return m.From().Notation() + m.To().Notation() + m.Promote().SymbolAlgebra(SideBlack)
+}
+
+func (m Move16) kind() uint16 {
+	return uint16(m >> 14)
+}
+
+// NewMoveFromMove16 unpacks the move using the pieces of the board. The move is not checked, see IsPseudoLegal.
+func (b *Board) NewMoveFromMove16(m Move16) Move {
+	if m.IsNull() {
+		return Move{}
+	}
+	fromPos, toPos := m.From(), m.To()
+	s, p := b.GetSideAndPieces(fromPos)
+	mv := Move{
+		From:        fromPos,
+		To:          toPos,
+		Piece:       p,
+		IsTurn:      s,
+		IsCapture:   b.occupied&maskCell[toPos] != 0,
+		IsEnPassant: m.kind() == move16KindEnPassant,
+		IsPromote:   m.Promote(),
+	}
+	if mv.IsEnPassant {
+		mv.IsCapture = true
+	}
+	if m.kind() == move16KindCastle {
+		for _, d := range []CastleDirection{
+			CastleDirectionWhiteRight, CastleDirectionWhiteLeft, CastleDirectionBlackRight, CastleDirectionBlackLeft,
+		} {
+			if posCastling[d][PieceKing] == [2]position.Pos{fromPos, toPos} {
+				mv.IsCastle = d
+			}
+		}
+	}
+	return mv
+}
+
+// IsPseudoLegal returns true if the piece on the from cell can make the move, ignoring whether its King is left
+// in check, which Apply reports. Castling must also be legal. Moves from the transposition table may be of
+// another position sharing the hash, and must be checked before they are applied.
+func (b *Board) IsPseudoLegal(m Move16) bool {
+	if m.IsNull() || (m.kind() != move16KindPromote && m>>12&0x3 != 0) {
+		return false // only the packed form of a move is accepted
+	}
+	fromPos, toPos := m.From(), m.To()
+	fromCell, toCell := maskCell[fromPos], maskCell[toPos]
+	s, p := b.GetSideAndPieces(fromPos)
+	if s != b.turn || b.sides[b.turn]&toCell != 0 {
+		return false
+	}
+
+	switch m.kind() {
+	case move16KindCastle:
+		if p != PieceKing || b.IsKingChecked(b.turn) {
+			return false
+		}
+		var mvs []Move
+		b.generateCastling(&mvs)
+		for _, mv := range mvs {
+			if mv.From == fromPos && mv.To == toPos {
+				return true
+			}
+		}
+		return false
+	case move16KindEnPassant:
+		return p == PiecePawn && toCell == b.enPassant && attacksPawn(b.turn, fromCell)&toCell != 0
+	}
+
+	if p != PiecePawn {
+		return m.kind() == move16KindNormal && attacksPiece(p, fromPos, b.occupied)&toCell != 0
+	}
+	promoteRow, pushRow := maskRow[7], maskRow[2]
+	pushMask := ShiftN(fromCell) &^ b.occupied
+	pushMask |= ShiftN(pushMask&pushRow) &^ b.occupied
+	if b.turn == SideBlack {
+		promoteRow, pushRow = maskRow[0], maskRow[5]
+		pushMask = ShiftS(fromCell) &^ b.occupied
+		pushMask |= ShiftS(pushMask&pushRow) &^ b.occupied
+	}
+	if (toCell&promoteRow != 0) != (m.kind() == move16KindPromote) {
+		return false
+	}
+	captureMask := attacksPawn(b.turn, fromCell) & b.sides[b.turn.Opposite()]
+	return (pushMask|captureMask)&toCell != 0
+}
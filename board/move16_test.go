@@ -0,0 +1,68 @@
+package board
+
+import (
+	"testing"
+)
+
+func TestMove16(t *testing.T) {
+	t.Parallel()
+	fens := append([]string{
+		"8/8/8/KPp4r/8/8/8/7k w - c6 0 2",
+		"8/8/3k4/3Pp3/8/8/8/4K3 w - e6 0 1",
+		"4k3/8/8/8/8/5b2/8/R3K2R w KQ - 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+		"3rk3/2P5/8/8/8/8/8/4K3 w - - 0 1",
+		"4k3/8/8/8/8/8/2p5/1R2K3 b - - 0 1",
+		"4k3/8/8/8/1b6/5n2/8/4K3 w - - 0 1",
+	}, fuzzFENs...)
+	for _, fen := range fens {
+		fen := fen
+		t.Run(fen, func(t *testing.T) {
+			t.Parallel()
+			b, err := NewBoard(WithFEN(fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			legal := make(map[Move16]bool)
+			for _, mv := range b.GenerateLegalMoves(nil) {
+				m := mv.Move16()
+				legal[m] = true
+				if got := b.NewMoveFromMove16(m); !got.Equals(mv) {
+					t.Errorf("unexpected move: got=%+v want=%+v", got, mv)
+				}
+				if got, want := m.UCI(), mv.UCI(); got != want {
+					t.Errorf("unexpected UCI: got=%s want=%s", got, want)
+				}
+			}
+
+			// every packed value is a legal move exactly if it is pseudo-legal, and Apply allows it
+			for m := Move16(0); ; m++ {
+				got := b.IsPseudoLegal(m) && b.IsLegal(b.NewMoveFromMove16(m))
+				if got != legal[m] {
+					t.Errorf("unexpected legality of %s (%#04x): got=%v want=%v", m.UCI(), uint16(m), got, legal[m])
+				}
+				if m == 0xFFFF {
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestMove16Null(t *testing.T) {
+	t.Parallel()
+	b, err := NewBoard()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m := (Move{}).Move16(); !m.IsNull() {
+		t.Errorf("unexpected null move: got=%#04x want=0", uint16(m))
+	}
+	if mv := b.NewMoveFromMove16(0); !mv.IsNull() {
+		t.Errorf("unexpected null move: got=%+v", mv)
+	}
+	if b.IsPseudoLegal(0) {
+		t.Errorf("unexpected pseudo-legal null move")
+	}
+}
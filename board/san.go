@@ -0,0 +1,161 @@
+package board
+
+import (
+	"strings"
+
+	"github.com/daystram/gambit/position"
+)
+
+// NewMoveFromSAN resolves the move in Standard Algebraic Notation to a legal move. Check, mate, and
+// annotation suffixes are ignored, and castling may be written with either O or 0.
+func (b *Board) NewMoveFromSAN(san string) (Move, error) {
+	san = strings.TrimRight(san, "+#!?")
+	if len(san) < 2 {
+		return Move{}, ErrInvalidMove
+	}
+
+	switch strings.ReplaceAll(san, "0", "O") {
+	case "O-O":
+		return b.findLegalMove(func(mv Move) bool { return mv.IsCastle != CastleDirectionUnknown && mv.IsCastle.IsRight() })
+	case "O-O-O":
+		return b.findLegalMove(func(mv Move) bool { return mv.IsCastle != CastleDirectionUnknown && !mv.IsCastle.IsRight() })
+	}
+
+	piece := PiecePawn
+	if p := pieceFromSymbol(san[0]); p != PieceUnknown {
+		piece = p
+		san = san[1:]
+	}
+
+	promote := PieceUnknown
+	if i := strings.IndexByte(san, '='); i != -1 {
+		if i+2 != len(san) {
+			return Move{}, ErrInvalidMove
+		}
+		promote = pieceFromSymbol(san[i+1])
+		san = san[:i]
+	} else if len(san) > 2 && piece == PiecePawn {
+		if p := pieceFromSymbol(san[len(san)-1]); p != PieceUnknown {
+			promote = p
+			san = san[:len(san)-1]
+		}
+	}
+	if len(san) < 2 {
+		return Move{}, ErrInvalidMove
+	}
+
+	to, err := position.NewPosFromNotation(san[len(san)-2:])
+	if err != nil {
+		return Move{}, ErrInvalidMove
+	}
+
+	// remaining characters are the disambiguation
+	fromX, fromY := position.Pos(-1), position.Pos(-1)
+	for _, c := range strings.ReplaceAll(san[:len(san)-2], "x", "") {
+		switch {
+		case c >= 'a' && c <= 'h':
+			fromX = position.Pos(c - 'a')
+		case c >= '1' && c <= '8':
+			fromY = position.Pos(c - '1')
+		default:
+			return Move{}, ErrInvalidMove
+		}
+	}
+
+	return b.findLegalMove(func(mv Move) bool {
+		return mv.Piece == piece && mv.To == to && mv.IsPromote == promote && mv.IsCastle == CastleDirectionUnknown &&
+			(fromX == -1 || mv.From.X() == fromX) &&
+			(fromY == -1 || mv.From.Y() == fromY)
+	})
+}
+
+// SAN returns the move in Standard Algebraic Notation, disambiguated against the other legal moves of the
+// board, with check and mate suffixes.
+func (b *Board) SAN(mv Move) string {
+	builder := strings.Builder{}
+	switch {
+	case mv.IsCastle != CastleDirectionUnknown && mv.IsCastle.IsRight():
+		_, _ = builder.WriteString("O-O")
+	case mv.IsCastle != CastleDirectionUnknown:
+		_, _ = builder.WriteString("O-O-O")
+	case mv.Piece == PiecePawn:
+		if mv.IsCapture {
+			_, _ = builder.WriteString(mv.From.X().NotationComponentX())
+			_, _ = builder.WriteRune('x')
+		}
+		_, _ = builder.WriteString(mv.To.Notation())
+		if mv.IsPromote != PieceUnknown {
+			_, _ = builder.WriteRune('=')
+			_, _ = builder.WriteString(mv.IsPromote.SymbolAlgebra(SideWhite))
+		}
+	default:
+		_, _ = builder.WriteString(mv.Piece.SymbolAlgebra(SideWhite))
+		var ambiguous, sameX, sameY bool
+		for _, other := range b.GeneratePseudoLegalMoves() {
+			if other.Piece != mv.Piece || other.To != mv.To || other.From == mv.From || !b.IsLegal(other) {
+				continue
+			}
+			ambiguous = true
+			sameX = sameX || other.From.X() == mv.From.X()
+			sameY = sameY || other.From.Y() == mv.From.Y()
+		}
+		switch {
+		case ambiguous && !sameX:
+			_, _ = builder.WriteString(mv.From.X().NotationComponentX())
+		case ambiguous && !sameY:
+			_, _ = builder.WriteString(mv.From.Y().NotationComponentY())
+		case ambiguous:
+			_, _ = builder.WriteString(mv.From.Notation())
+		}
+		if mv.IsCapture {
+			_, _ = builder.WriteRune('x')
+		}
+		_, _ = builder.WriteString(mv.To.Notation())
+	}
+
+	unApply, ok := b.Apply(mv)
+	if ok {
+		switch state := b.State(); {
+		case state.IsCheckmate():
+			_, _ = builder.WriteRune('#')
+		case b.IsKingChecked(b.Turn()):
+			_, _ = builder.WriteRune('+')
+		}
+	}
+	unApply()
+	return builder.String()
+}
+
+// findLegalMove returns the only legal move matching the filter.
+func (b *Board) findLegalMove(match func(Move) bool) (Move, error) {
+	var found Move
+	var count int
+	for _, mv := range b.GeneratePseudoLegalMoves() {
+		if !match(mv) || !b.IsLegal(mv) {
+			continue
+		}
+		found = mv
+		count++
+	}
+	if count != 1 {
+		return Move{}, ErrInvalidMove
+	}
+	return found, nil
+}
+
+func pieceFromSymbol(sym byte) Piece {
+	switch sym {
+	case 'N':
+		return PieceKnight
+	case 'B':
+		return PieceBishop
+	case 'R':
+		return PieceRook
+	case 'Q':
+		return PieceQueen
+	case 'K':
+		return PieceKing
+	default:
+		return PieceUnknown
+	}
+}
@@ -0,0 +1,66 @@
+package board
+
+import (
+	"testing"
+)
+
+func TestSAN(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen     string
+		san     string
+		wantUCI string
+		wantSAN string
+		wantErr bool
+	}{
+		{fen: DefaultStartingPositionFEN, san: "e4", wantUCI: "e2e4", wantSAN: "e4"},
+		{fen: DefaultStartingPositionFEN, san: "Nf3", wantUCI: "g1f3", wantSAN: "Nf3"},
+		{fen: DefaultStartingPositionFEN, san: "Nf3!?", wantUCI: "g1f3", wantSAN: "Nf3"},
+		{fen: DefaultStartingPositionFEN, san: "e5", wantErr: true},
+		{fen: DefaultStartingPositionFEN, san: "Ke2", wantErr: true},
+		{fen: DefaultStartingPositionFEN, san: "x", wantErr: true},
+		{fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", san: "O-O", wantUCI: "e1g1", wantSAN: "O-O"},
+		{fen: "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", san: "0-0-0", wantUCI: "e8c8", wantSAN: "O-O-O"},
+		{fen: "4k3/8/8/8/8/8/8/R3K2R w - - 0 1", san: "Rd1", wantUCI: "a1d1", wantSAN: "Rd1"},
+		{fen: "4k3/8/8/8/8/8/7K/R6R w - - 0 1", san: "Rad1", wantUCI: "a1d1", wantSAN: "Rad1"},
+		{fen: "4k3/8/8/8/8/8/7K/R6R w - - 0 1", san: "Rd1", wantErr: true},
+		{fen: "4k3/8/8/8/R7/8/8/R3K3 w - - 0 1", san: "R1a2", wantUCI: "a1a2", wantSAN: "R1a2"},
+		{fen: "2k5/8/8/8/Q7/8/8/Q2QK3 w - - 0 1", san: "Qa1d4", wantUCI: "a1d4", wantSAN: "Qa1d4"},
+		{fen: "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1", san: "exd5", wantUCI: "e4d5", wantSAN: "exd5"},
+		{fen: "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", san: "exd6", wantUCI: "e5d6", wantSAN: "exd6"},
+		{fen: "1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1", san: "axb8=Q+", wantUCI: "a7b8q", wantSAN: "axb8=Q+"},
+		{fen: "1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1", san: "a8N", wantUCI: "a7a8n", wantSAN: "a8=N"},
+		{fen: "6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1", san: "Ra8", wantUCI: "a1a8", wantSAN: "Ra8#"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.fen+" "+tt.san, func(t *testing.T) {
+			t.Parallel()
+			b, err := NewBoard(WithFEN(tt.fen))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			mv, err := b.NewMoveFromSAN(tt.san)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("error expected: got=%s", mv.UCI())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if gotUCI := mv.UCI(); gotUCI != tt.wantUCI {
+				t.Errorf("unexpected move: got=%s want=%s", gotUCI, tt.wantUCI)
+			}
+			if gotSAN := b.SAN(mv); gotSAN != tt.wantSAN {
+				t.Errorf("unexpected SAN: got=%s want=%s", gotSAN, tt.wantSAN)
+			}
+			if gotFEN := b.FEN(); gotFEN != tt.fen {
+				t.Errorf("unexpected FEN: got=%s want=%s", gotFEN, tt.fen)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package board
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daystram/gambit/position"
+)
+
+// Validate recomputes the incrementally updated fields of the board from its cells, reporting every field that
+// does not match. Validation is slow, and only meant for tests and debugging.
+func (b *Board) Validate() error {
+	var mismatches []string
+	mismatch := func(format string, args ...any) {
+		mismatches = append(mismatches, fmt.Sprintf(format, args...))
+	}
+
+	want := &Board{
+		cells:        b.cells,
+		enPassant:    b.enPassant,
+		castleRights: b.castleRights,
+		turn:         b.turn,
+	}
+	for pos := position.Pos(0); pos < TotalCells; pos++ {
+		s, p := b.GetSideAndPieces(pos)
+		switch {
+		case s == SideUnknown && p == PieceUnknown:
+			continue
+		case s != SideWhite && s != SideBlack, p == PieceUnknown || p > PieceKing:
+			mismatch("cells: invalid cell %s: %#02x", pos.Notation(), b.cells[pos])
+			continue
+		}
+		want.occupied.Set(pos)
+		want.sides[s].Set(pos)
+		want.pieces[p].Set(pos)
+		want.materialValue[s] += scoreMaterial[p]
+		want.positionValueMG[s] += scorePositionMG[p][scorePositionMap[s][pos]]
+		want.positionValueEG[s] += scorePositionEG[p][scorePositionMap[s][pos]]
+		want.phase += phaseConstant[p]
+		want.hash ^= zobristConstantPiece[s][p][pos]
+	}
+	if b.turn == SideWhite {
+		want.hash ^= zobristConstantSideWhite
+	}
+	want.hash ^= zobristConstantCastleRights[b.castleRights]
+	want.hash ^= zobristConstantEnPassant[b.enPassant.LS1B()]
+
+	if b.occupied != want.occupied {
+		mismatch("occupied: got=%#016x want=%#016x", uint64(b.occupied), uint64(want.occupied))
+	}
+	for _, s := range []Side{SideWhite, SideBlack} {
+		if b.sides[s] != want.sides[s] {
+			mismatch("sides[%s]: got=%#016x want=%#016x", s, uint64(b.sides[s]), uint64(want.sides[s]))
+		}
+		if b.materialValue[s] != want.materialValue[s] {
+			mismatch("materialValue[%s]: got=%d want=%d", s, b.materialValue[s], want.materialValue[s])
+		}
+		if b.positionValueMG[s] != want.positionValueMG[s] {
+			mismatch("positionValueMG[%s]: got=%d want=%d", s, b.positionValueMG[s], want.positionValueMG[s])
+		}
+		if b.positionValueEG[s] != want.positionValueEG[s] {
+			mismatch("positionValueEG[%s]: got=%d want=%d", s, b.positionValueEG[s], want.positionValueEG[s])
+		}
+	}
+	if b.sides[SideUnknown] != 0 {
+		mismatch("sides[unknown]: got=%#016x want=0", uint64(b.sides[SideUnknown]))
+	}
+	for p := PieceUnknown; p <= PieceKing; p++ {
+		if b.pieces[p] != want.pieces[p] {
+			mismatch("pieces[%d]: got=%#016x want=%#016x", p, uint64(b.pieces[p]), uint64(want.pieces[p]))
+		}
+	}
+	if b.phase != want.phase {
+		mismatch("phase: got=%d want=%d", b.phase, want.phase)
+	}
+	if b.enPassant != 0 && (b.enPassant.BitCount() != 1 || b.enPassant&(maskRow[2]|maskRow[5]) == 0) {
+		mismatch("enPassant: invalid cells %#016x", uint64(b.enPassant))
+	}
+	if b.turn != SideWhite && b.turn != SideBlack {
+		mismatch("turn: invalid side %d", b.turn)
+	}
+	if b.hash != want.hash {
+		mismatch("hash: got=%#016x want=%#016x", b.hash, want.hash)
+	}
+
+	if len(mismatches) != 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidBoard, strings.Join(mismatches, ", "))
+	}
+	return nil
+}
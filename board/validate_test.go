@@ -0,0 +1,159 @@
+package board
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/daystram/gambit/position"
+)
+
+var fuzzFENs = []string{
+	DefaultStartingPositionFEN,
+	"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+	"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+	"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		corrupt func(b *Board)
+		wantErr bool
+	}{
+		{name: "valid", corrupt: func(b *Board) {}, wantErr: false},
+		{name: "occupied", corrupt: func(b *Board) { b.occupied.Unset(position.E1) }, wantErr: true},
+		{name: "sides", corrupt: func(b *Board) { b.sides[SideBlack].Set(position.E4) }, wantErr: true},
+		{name: "pieces", corrupt: func(b *Board) { b.pieces[PieceQueen].Set(position.D2) }, wantErr: true},
+		{name: "cells", corrupt: func(b *Board) { b.setSideAndPieces(position.A1, SideWhite, PieceQueen) }, wantErr: true},
+		{name: "invalid cell", corrupt: func(b *Board) { b.cells[position.A1] = 0xFF }, wantErr: true},
+		{name: "material", corrupt: func(b *Board) { b.materialValue[SideWhite]++ }, wantErr: true},
+		{name: "position mg", corrupt: func(b *Board) { b.positionValueMG[SideBlack]-- }, wantErr: true},
+		{name: "position eg", corrupt: func(b *Board) { b.positionValueEG[SideWhite]++ }, wantErr: true},
+		{name: "phase", corrupt: func(b *Board) { b.phase++ }, wantErr: true},
+		{name: "enpassant", corrupt: func(b *Board) { b.enPassant = maskCell[position.E4] }, wantErr: true},
+		{name: "hash", corrupt: func(b *Board) { b.hash ^= 1 }, wantErr: true},
+		{name: "turn", corrupt: func(b *Board) { b.turn = b.turn.Opposite() }, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := NewBoard()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.corrupt(b)
+			err = b.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: got=%v wantErr=%v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidBoard) {
+				t.Errorf("unexpected error: got=%v want=%v", err, ErrInvalidBoard)
+			}
+		})
+	}
+}
+
+func TestApplyPromotionPhase(t *testing.T) {
+	t.Parallel()
+	const fen = "r3k3/1P6/8/8/8/8/8/4K3 w - - 0 1"
+	for _, uci := range []string{"b7b8q", "b7b8n", "b7a8q", "b7a8r"} {
+		uci := uci
+		t.Run(uci, func(t *testing.T) {
+			t.Parallel()
+			b, err := NewBoard(WithFEN(fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			wantBefore := b.Phase()
+			mv, err := b.NewMoveFromUCI(uci)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			unApply, ok := b.Apply(mv)
+			if !ok {
+				t.Fatalf("unexpected illegal move: %s", uci)
+			}
+			fromFEN, err := NewBoard(WithFEN(b.FEN()))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.Phase() != fromFEN.Phase() {
+				t.Errorf("unexpected phase after %s: got=%d want=%d", uci, b.Phase(), fromFEN.Phase())
+			}
+			unApply()
+			if b.Phase() != wantBefore {
+				t.Errorf("unexpected phase after undoing %s: got=%d want=%d", uci, b.Phase(), wantBefore)
+			}
+		})
+	}
+}
+
+// FuzzApply plays the legal move, or the null move when out of range, selected by each byte, checking the board
+// after every move, and that undoing the moves restores it exactly.
+func FuzzApply(f *testing.F) {
+	for i := range fuzzFENs {
+		f.Add(uint8(i), []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+		f.Add(uint8(i), []byte{0xFF, 0x10, 0xFF, 0x20, 0x30, 0x40, 0xFF})
+	}
+	f.Fuzz(func(t *testing.T, fenIndex uint8, selectors []byte) {
+		b, err := NewBoard(WithFEN(fuzzFENs[int(fenIndex)%len(fuzzFENs)]))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		type undo struct {
+			before   Board
+			unApply  UnApplyFunc
+			notation string
+		}
+		var history []undo
+		for _, selector := range selectors {
+			var legal []Move
+			for _, mv := range b.GeneratePseudoLegalMoves() {
+				if b.IsLegal(mv) {
+					legal = append(legal, mv)
+				}
+			}
+			if len(legal) == 0 {
+				break
+			}
+
+			before := *b
+			var u undo
+			if i := int(selector) % (len(legal) + 1); i < len(legal) {
+				unApply, ok := b.Apply(legal[i])
+				if !ok {
+					t.Fatalf("unexpected illegal move: %s", legal[i].UCI())
+				}
+				u = undo{before: before, unApply: unApply, notation: legal[i].UCI()}
+			} else {
+				if b.IsKingChecked(b.Turn()) {
+					continue
+				}
+				u = undo{before: before, unApply: b.ApplyNull(), notation: "0000"}
+			}
+			history = append(history, u)
+
+			if err := b.Validate(); err != nil {
+				t.Fatalf("unexpected error after %s from %s: %v", u.notation, before.FEN(), err)
+			}
+			fromFEN, err := NewBoard(WithFEN(b.FEN()))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.Hash() != fromFEN.Hash() {
+				t.Fatalf("unexpected hash after %s from %s: got=%d want=%d", u.notation, before.FEN(), b.Hash(), fromFEN.Hash())
+			}
+		}
+
+		for i := len(history) - 1; i >= 0; i-- {
+			history[i].unApply()
+			if *b != history[i].before {
+				t.Fatalf("unexpected board after undoing %s: got=%s want=%s", history[i].notation, b.FEN(), history[i].before.FEN())
+			}
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package book
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/position"
+)
+
+const (
+	entrySize = 16
+)
+
+var (
+	ErrInvalidBook = errors.New("invalid book")
+
+	polyglotPromotions = [...]board.Piece{
+		board.PieceUnknown,
+		board.PieceKnight,
+		board.PieceBishop,
+		board.PieceRook,
+		board.PieceQueen,
+	}
+)
+
+// Selection is the strategy to pick a move among the book entries of a position.
+type Selection uint8
+
+const (
+	// SelectionWeighted picks a random move, proportional to the entry weights.
+	SelectionWeighted Selection = iota
+
+	// SelectionBest picks the move with the highest weight.
+	SelectionBest
+)
+
+// Entry is a Polyglot book entry.
+type Entry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// Book is a Polyglot opening book, with entries sorted by key.
+type Book struct {
+	entries []Entry
+}
+
+// Open reads the Polyglot book file.
+func Open(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read reads a Polyglot book.
+func Read(r io.Reader) (*Book, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%entrySize != 0 {
+		return nil, fmt.Errorf("%w: size %d is not a multiple of %d", ErrInvalidBook, len(data), entrySize)
+	}
+
+	bk := &Book{entries: make([]Entry, 0, len(data)/entrySize)}
+	for off := 0; off < len(data); off += entrySize {
+		bk.entries = append(bk.entries, Entry{
+			Key:    binary.BigEndian.Uint64(data[off:]),
+			Move:   binary.BigEndian.Uint16(data[off+8:]),
+			Weight: binary.BigEndian.Uint16(data[off+10:]),
+			Learn:  binary.BigEndian.Uint32(data[off+12:]),
+		})
+	}
+	// books should already be sorted, but do not rely on it for the binary search
+	sort.SliceStable(bk.entries, func(i, j int) bool {
+		return bk.entries[i].Key < bk.entries[j].Key
+	})
+	return bk, nil
+}
+
+// Len returns the number of entries in the book.
+func (bk *Book) Len() int {
+	return len(bk.entries)
+}
+
+// Entries returns the entries of the board.
+func (bk *Book) Entries(b *board.Board) []Entry {
+	key := Key(b)
+	i := sort.Search(len(bk.entries), func(i int) bool {
+		return bk.entries[i].Key >= key
+	})
+	j := i
+	for j < len(bk.entries) && bk.entries[j].Key == key {
+		j++
+	}
+	return bk.entries[i:j]
+}
+
+// Probe picks a legal book move of the board using the selection strategy. Entries with zero weight are
+// never picked.
+func (bk *Book) Probe(b *board.Board, sel Selection, rnd *rand.Rand) (board.Move, bool) {
+	var mvs []board.Move
+	var weights []int
+	var totalWeight int
+	for _, entry := range bk.Entries(b) {
+		if entry.Weight == 0 {
+			continue
+		}
+		mv, ok := DecodeMove(b, entry.Move)
+		if !ok {
+			continue
+		}
+		mvs = append(mvs, mv)
+		weights = append(weights, int(entry.Weight))
+		totalWeight += int(entry.Weight)
+	}
+	if len(mvs) == 0 {
+		return board.Move{}, false
+	}
+
+	switch sel {
+	case SelectionBest:
+		best := 0
+		for i := range mvs {
+			if weights[i] > weights[best] {
+				best = i
+			}
+		}
+		return mvs[best], true
+	default:
+		var n int
+		if rnd != nil {
+			n = rnd.Intn(totalWeight)
+		} else {
+			n = rand.Intn(totalWeight)
+		}
+		for i := range mvs {
+			n -= weights[i]
+			if n < 0 {
+				return mvs[i], true
+			}
+		}
+		return mvs[len(mvs)-1], true
+	}
+}
+
+// DecodeMove resolves the Polyglot move of the board into a legal move. Polyglot encodes castling as the
+// King capturing its own Rook.
+func DecodeMove(b *board.Board, code uint16) (board.Move, bool) {
+	to := position.Pos(code & 0x3F)
+	from := position.Pos((code >> 6) & 0x3F)
+	promote := int((code >> 12) & 0x7)
+	if promote >= len(polyglotPromotions) {
+		return board.Move{}, false
+	}
+
+	if _, p := b.GetSideAndPieces(from); p == board.PieceKing {
+		switch {
+		case from == position.E1 && to == position.H1:
+			to = position.G1
+		case from == position.E1 && to == position.A1:
+			to = position.C1
+		case from == position.E8 && to == position.H8:
+			to = position.G8
+		case from == position.E8 && to == position.A8:
+			to = position.C8
+		}
+	}
+
+	for _, mv := range b.GenerateLegalMoves(nil) {
+		if mv.From == from && mv.To == to && mv.IsPromote == polyglotPromotions[promote] {
+			return mv, true
+		}
+	}
+	return board.Move{}, false
+}
+
+// EncodeMove encodes the move in Polyglot format.
+func EncodeMove(mv board.Move) uint16 {
+	to := mv.To
+	switch mv.IsCastle {
+	case board.CastleDirectionWhiteRight:
+		to = position.H1
+	case board.CastleDirectionWhiteLeft:
+		to = position.A1
+	case board.CastleDirectionBlackRight:
+		to = position.H8
+	case board.CastleDirectionBlackLeft:
+		to = position.A8
+	}
+
+	var promote uint16
+	for i, p := range polyglotPromotions {
+		if i != 0 && p == mv.IsPromote {
+			promote = uint16(i)
+		}
+	}
+	return promote<<12 | uint16(mv.From)<<6 | uint16(to)
+}
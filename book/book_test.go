@@ -0,0 +1,145 @@
+package book
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/daystram/gambit/board"
+)
+
+func encodeEntries(entries []Entry) []byte {
+	buf := bytes.Buffer{}
+	for _, entry := range entries {
+		_ = binary.Write(&buf, binary.BigEndian, entry)
+	}
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		data    []byte
+		wantLen int
+		wantErr error
+	}{
+		{name: "empty", data: nil, wantLen: 0},
+		{name: "entries", data: encodeEntries([]Entry{{Key: 2}, {Key: 1}}), wantLen: 2},
+		{name: "truncated", data: make([]byte, entrySize+1), wantErr: ErrInvalidBook},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			bk, err := Read(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got=%v want=%v", err, tt.wantErr)
+			}
+			if err == nil && bk.Len() != tt.wantLen {
+				t.Errorf("unexpected length: got=%d want=%d", bk.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+	start, err := board.NewBoard()
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	castle, err := board.NewBoard(board.WithFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	startKey, castleKey := Key(start), Key(castle)
+
+	bk, err := Read(bytes.NewReader(encodeEntries([]Entry{
+		{Key: startKey, Move: 0x031C, Weight: 10}, // e2e4
+		{Key: startKey, Move: 0x02DB, Weight: 5},  // d2d4
+		{Key: startKey, Move: 0x0195, Weight: 0},  // g1f3
+		{Key: startKey, Move: 0x0124, Weight: 50}, // e1e5, illegal
+		{Key: castleKey, Move: 0x0107, Weight: 1}, // e1h1, castling
+	})))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	tests := []struct {
+		name     string
+		b        *board.Board
+		sel      Selection
+		wantMove []string
+		wantOK   bool
+	}{
+		{name: "best", b: start, sel: SelectionBest, wantMove: []string{"e2e4"}, wantOK: true},
+		{name: "weighted", b: start, sel: SelectionWeighted, wantMove: []string{"e2e4", "d2d4"}, wantOK: true},
+		{name: "castling", b: castle, sel: SelectionBest, wantMove: []string{"e1g1"}, wantOK: true},
+		{name: "not found", b: func() *board.Board {
+			b, _ := board.NewBoard(board.WithFEN("4k3/8/8/8/8/8/8/4K3 w - - 0 1"))
+			return b
+		}(), sel: SelectionBest, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := tt.b.Clone()
+			mv, ok := bk.Probe(b, tt.sel, rand.New(rand.NewSource(1)))
+			if ok != tt.wantOK {
+				t.Fatalf("unexpected ok: got=%v want=%v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			for _, want := range tt.wantMove {
+				if mv.UCI() == want {
+					return
+				}
+			}
+			t.Errorf("unexpected move: got=%s want=%v", mv.UCI(), tt.wantMove)
+		})
+	}
+}
+
+func TestEncodeMove(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen      string
+		move     string
+		wantCode uint16
+	}{
+		{fen: board.DefaultStartingPositionFEN, move: "e2e4", wantCode: 0x031C},
+		{fen: "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", move: "e1g1", wantCode: 0x0107},
+		{fen: "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", move: "e8c8", wantCode: 0x0F38},
+		{fen: "8/P3k3/8/8/8/8/8/4K3 w - - 0 1", move: "a7a8q", wantCode: 0x4C38},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.move, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			mv, err := b.NewMoveFromUCI(tt.move)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			code := EncodeMove(mv)
+			if code != tt.wantCode {
+				t.Errorf("unexpected code: got=%04x want=%04x", code, tt.wantCode)
+			}
+			decoded, ok := DecodeMove(b, code)
+			if !ok || decoded.UCI() != tt.move {
+				t.Errorf("unexpected decoded move: got=%s want=%s", decoded.UCI(), tt.move)
+			}
+		})
+	}
+}
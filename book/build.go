@@ -0,0 +1,139 @@
+package book
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/daystram/gambit/board"
+)
+
+// BuildConfig filters the moves of the built book.
+type BuildConfig struct {
+	MaxPly   int     // moves beyond the ply are not recorded, 0 for no limit
+	MinGames int     // minimum number of games the move is played in
+	MinScore float64 // minimum score of the move for the side playing it, between 0 and 1
+}
+
+type moveStats struct {
+	games, wins, draws int
+}
+
+// score returns the score of the move for the side playing it.
+func (s *moveStats) score() float64 {
+	return (float64(s.wins) + float64(s.draws)/2) / float64(s.games)
+}
+
+// Builder aggregates the moves played in games into a Polyglot book.
+type Builder struct {
+	cfg   BuildConfig
+	stats map[uint64]map[uint16]*moveStats
+}
+
+func NewBuilder(cfg *BuildConfig) *Builder {
+	return &Builder{
+		cfg:   *cfg,
+		stats: make(map[uint64]map[uint16]*moveStats),
+	}
+}
+
+// AddGame records the moves of the game played from the board, with the final score of White, where a draw
+// is 0.5. The board is not modified.
+func (bd *Builder) AddGame(b *board.Board, mvs []board.Move, whiteScore float64) {
+	b = b.Clone()
+	for ply, mv := range mvs {
+		if bd.cfg.MaxPly > 0 && ply >= bd.cfg.MaxPly {
+			break
+		}
+
+		key := Key(b)
+		if bd.stats[key] == nil {
+			bd.stats[key] = make(map[uint16]*moveStats)
+		}
+		code := EncodeMove(mv)
+		stats := bd.stats[key][code]
+		if stats == nil {
+			stats = &moveStats{}
+			bd.stats[key][code] = stats
+		}
+
+		score := whiteScore
+		if b.Turn() == board.SideBlack {
+			score = 1 - whiteScore
+		}
+		stats.games++
+		switch {
+		case score > 0.5:
+			stats.wins++
+		case score == 0.5:
+			stats.draws++
+		}
+
+		if _, ok := b.Apply(mv); !ok {
+			break
+		}
+	}
+}
+
+// Entries returns the filtered entries, sorted by key and decreasing weight. The weight of a move is twice its
+// wins plus its draws, scaled down if needed to fit.
+func (bd *Builder) Entries() []Entry {
+	type candidate struct {
+		key    uint64
+		move   uint16
+		weight int
+	}
+	var candidates []candidate
+	var maxWeight int
+	for key, moves := range bd.stats {
+		for code, stats := range moves {
+			if stats.games < bd.cfg.MinGames || stats.score() < bd.cfg.MinScore {
+				continue
+			}
+			weight := 2*stats.wins + stats.draws
+			if weight > maxWeight {
+				maxWeight = weight
+			}
+			candidates = append(candidates, candidate{key: key, move: code, weight: weight})
+		}
+	}
+
+	scale := 1.0
+	if maxWeight > math.MaxUint16 {
+		scale = float64(math.MaxUint16) / float64(maxWeight)
+	}
+	entries := make([]Entry, 0, len(candidates))
+	for _, c := range candidates {
+		entries = append(entries, Entry{
+			Key:    c.key,
+			Move:   c.move,
+			Weight: uint16(float64(c.weight) * scale),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		if entries[i].Weight != entries[j].Weight {
+			return entries[i].Weight > entries[j].Weight
+		}
+		return entries[i].Move < entries[j].Move
+	})
+	return entries
+}
+
+// Write writes the entries in Polyglot format. The entries must already be sorted by key.
+func Write(w io.Writer, entries []Entry) error {
+	buf := make([]byte, entrySize)
+	for _, entry := range entries {
+		binary.BigEndian.PutUint64(buf[0:], entry.Key)
+		binary.BigEndian.PutUint16(buf[8:], entry.Move)
+		binary.BigEndian.PutUint16(buf[10:], entry.Weight)
+		binary.BigEndian.PutUint32(buf[12:], entry.Learn)
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package book
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/daystram/gambit/board"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+	type game struct {
+		moves      []string
+		whiteScore float64
+	}
+	games := []game{
+		{moves: []string{"e2e4", "e7e5", "g1f3"}, whiteScore: 1},
+		{moves: []string{"e2e4", "c7c5"}, whiteScore: 0.5},
+		{moves: []string{"e2e4", "e7e5"}, whiteScore: 0},
+		{moves: []string{"d2d4", "d7d5"}, whiteScore: 0},
+	}
+	tests := []struct {
+		name        string
+		cfg         BuildConfig
+		wantEntries int
+		wantBest    string
+	}{
+		{name: "all", cfg: BuildConfig{}, wantEntries: 6, wantBest: "e2e4"},
+		{name: "max ply", cfg: BuildConfig{MaxPly: 1}, wantEntries: 2, wantBest: "e2e4"},
+		{name: "min games", cfg: BuildConfig{MinGames: 2}, wantEntries: 2, wantBest: "e2e4"},
+		{name: "min score", cfg: BuildConfig{MaxPly: 1, MinScore: 0.6}, wantEntries: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			start, err := board.NewBoard()
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			bd := NewBuilder(&tt.cfg)
+			for _, g := range games {
+				b := start.Clone()
+				var mvs []board.Move
+				for _, notation := range g.moves {
+					mv, err := b.NewMoveFromUCI(notation)
+					if err != nil {
+						t.Fatal("unexpected error:", err)
+					}
+					b.Apply(mv)
+					mvs = append(mvs, mv)
+				}
+				bd.AddGame(start, mvs, g.whiteScore)
+			}
+
+			entries := bd.Entries()
+			if len(entries) != tt.wantEntries {
+				t.Fatalf("unexpected entries: got=%d want=%d", len(entries), tt.wantEntries)
+			}
+
+			buf := bytes.Buffer{}
+			if err := Write(&buf, entries); err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			bk, err := Read(&buf)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			mv, ok := bk.Probe(start, SelectionBest, nil)
+			if tt.wantBest == "" {
+				if ok {
+					t.Errorf("unexpected move: got=%s want=none", mv.UCI())
+				}
+				return
+			}
+			if !ok || mv.UCI() != tt.wantBest {
+				t.Errorf("unexpected move: got=%s want=%s", mv.UCI(), tt.wantBest)
+			}
+		})
+	}
+}
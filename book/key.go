@@ -0,0 +1,78 @@
+package book
+
+import (
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/position"
+)
+
+const (
+	randomPieceOffset     = 0
+	randomCastleOffset    = 768
+	randomEnPassantOffset = 772
+	randomTurnOffset      = 780
+)
+
+// polyglotPieceKinds maps the pieces to the Polyglot piece kinds, of the Black side. The White side is offset
+// by one.
+var polyglotPieceKinds = [board.PieceKing + 1]int{
+	board.PiecePawn:   0,
+	board.PieceKnight: 2,
+	board.PieceBishop: 4,
+	board.PieceRook:   6,
+	board.PieceQueen:  8,
+	board.PieceKing:   10,
+}
+
+// Key returns the Polyglot Zobrist key of the board. The key is independent of the board's own hash.
+func Key(b *board.Board) uint64 {
+	var key uint64
+
+	for _, s := range []board.Side{board.SideWhite, board.SideBlack} {
+		for p := board.PiecePawn; p <= board.PieceKing; p++ {
+			kind := polyglotPieceKinds[p]
+			if s == board.SideWhite {
+				kind++
+			}
+			bm := b.GetBitmap(s, p)
+			for bm != 0 {
+				pos := bm.LS1B()
+				bm &= bm - 1
+				key ^= random64[randomPieceOffset+64*kind+int(pos)]
+			}
+		}
+	}
+
+	castleRights := b.CastleRights()
+	for i, d := range []board.CastleDirection{
+		board.CastleDirectionWhiteRight,
+		board.CastleDirectionWhiteLeft,
+		board.CastleDirectionBlackRight,
+		board.CastleDirectionBlackLeft,
+	} {
+		if castleRights.IsAllowed(d) {
+			key ^= random64[randomCastleOffset+i]
+		}
+	}
+
+	// en passant is only hashed if a Pawn of the side to move can capture onto the target cell
+	if enPassant, ok := b.EnPassant(); ok {
+		rank, pawnSide := enPassant.Y()-1, board.SideWhite
+		if b.Turn() == board.SideBlack {
+			rank, pawnSide = enPassant.Y()+1, board.SideBlack
+		}
+		for _, x := range []position.Pos{enPassant.X() - 1, enPassant.X() + 1} {
+			if x < 0 || x >= board.Width {
+				continue
+			}
+			if s, p := b.GetSideAndPieces(rank*board.Width + x); s == pawnSide && p == board.PiecePawn {
+				key ^= random64[randomEnPassantOffset+int(enPassant.X())]
+				break
+			}
+		}
+	}
+
+	if b.Turn() == board.SideWhite {
+		key ^= random64[randomTurnOffset]
+	}
+	return key
+}
@@ -0,0 +1,41 @@
+package book
+
+import (
+	"testing"
+
+	"github.com/daystram/gambit/board"
+)
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+	// test vectors from the Polyglot book format specification
+	tests := []struct {
+		name    string
+		fen     string
+		wantKey uint64
+	}{
+		{name: "starting position", fen: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", wantKey: 0x463b96181691fc9c},
+		{name: "e2e4", fen: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", wantKey: 0x823c9b50fd114196},
+		{name: "e2e4 d7d5", fen: "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", wantKey: 0x0756b94461c50fb0},
+		{name: "e2e4 d7d5 e4e5", fen: "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", wantKey: 0x662fafb965db29d4},
+		{name: "e2e4 d7d5 e4e5 f7f5", fen: "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", wantKey: 0x22a48b5a8e47ff78},
+		{name: "e2e4 d7d5 e4e5 f7f5 e1e2", fen: "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3", wantKey: 0x652a607ca3f242c1},
+		{name: "e2e4 d7d5 e4e5 f7f5 e1e2 e8f7", fen: "rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4", wantKey: 0x00fdd303c946bdd9},
+		{name: "a2a4 b7b5 h2h4 b5b4 c2c4", fen: "rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", wantKey: 0x3c8123ea7b067637},
+		{name: "a2a4 b7b5 h2h4 b5b4 c2c4 b4c3 a1a3", fen: "rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", wantKey: 0x5c3f9b829b279560},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if gotKey := Key(b); gotKey != tt.wantKey {
+				t.Errorf("unexpected key: got=%016x want=%016x", gotKey, tt.wantKey)
+			}
+		})
+	}
+}
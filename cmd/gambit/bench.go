@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/daystram/gambit/bench"
+)
+
+func runBench(depth int) error {
+	if depth <= 0 || depth > 255 {
+		return errors.New("invalid depth")
+	}
+	r, err := bench.Search(context.Background(), uint8(depth), bench.DefaultSearchHashTableMB,
+		func(index int, fen string, nodes uint32) {
+			fmt.Printf("Position %d: %s, nodes %d\n", index+1, fen, nodes)
+		})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Nodes searched: %d\n", r.Nodes)
+	fmt.Printf("Nodes/second: %d\n", r.NPS())
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/daystram/gambit/book"
+	"github.com/daystram/gambit/pgn"
+)
+
+func runBook(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing book command: build")
+	}
+	switch args[0] {
+	case "build":
+		return bookBuild(args[1:])
+	default:
+		return fmt.Errorf("unknown book command: %s", args[0])
+	}
+}
+
+func bookBuild(args []string) error {
+	fs := flag.NewFlagSet("book build", flag.ContinueOnError)
+	output := fs.String("o", "book.bin", "output Polyglot book file")
+	maxPly := fs.Int("ply", 20, "maximum ply recorded per game, 0 for no limit")
+	minGames := fs.Int("min-games", 1, "minimum number of games a move is played in")
+	minScore := fs.Float64("min-score", 0, "minimum score of a move for the side playing it, between 0 and 1")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("missing PGN files")
+	}
+
+	bd := book.NewBuilder(&book.BuildConfig{
+		MaxPly:   *maxPly,
+		MinGames: *minGames,
+		MinScore: *minScore,
+	})
+	var games, skipped int
+	for _, path := range fs.Args() {
+		n, s, err := addPGN(bd, path)
+		if err != nil {
+			return err
+		}
+		games += n
+		skipped += s
+	}
+
+	entries := bd.Entries()
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := book.Write(f, entries); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("wrote %d entries from %d games (%d skipped) to %s\n", len(entries), games, skipped, *output)
+	return nil
+}
+
+// addPGN adds the games of the PGN file, skipping invalid games and games without a result.
+func addPGN(bd *book.Builder, path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	var games, skipped int
+	r := pgn.NewReader(f)
+	for {
+		g, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return games, skipped, nil
+		}
+		if errors.Is(err, pgn.ErrInvalidGame) {
+			log.Printf("skipping game in %s: %v\n", path, err)
+			skipped++
+			continue
+		}
+		if err != nil {
+			return games, skipped, err
+		}
+
+		score, ok := g.Result.Score()
+		if !ok {
+			skipped++
+			continue
+		}
+		b, err := g.Board()
+		if err != nil {
+			skipped++
+			continue
+		}
+		bd.AddGame(b, g.Moves, score)
+		games++
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/daystram/gambit/bench"
 	"github.com/daystram/gambit/board"
 )
 
@@ -22,6 +23,11 @@ var (
 	searchRun      = flag.Bool("search", false, "run search mode")
 	searchDepth    = flag.Int("search.depth", 0, "search depth in search mode")
 	searchMovetime = flag.Int("search.movetime", 0, "search movetime in milliseconds in search mode")
+
+	xboardRun = flag.Bool("xboard", false, "run XBoard (CECP v2) mode")
+
+	benchRun   = flag.Bool("bench", false, "run bench mode, printing the node count signature")
+	benchDepth = flag.Int("bench.depth", int(bench.DefaultSearchDepth), "search depth in bench mode")
 )
 
 func main() {
@@ -48,6 +54,21 @@ func runProfiler() {
 }
 
 func realMain(args []string) error {
+	switch flag.Arg(0) {
+	case "book":
+		return runBook(flag.Args()[1:])
+	case "match":
+		return runMatch(flag.Args()[1:])
+	case "sprt":
+		return runSPRT(flag.Args()[1:])
+	case "serve":
+		return runServe(flag.Args()[1:])
+	case "testsuite":
+		return runTestSuite(flag.Args()[1:])
+	case "perft":
+		return runPerft(flag.Args()[1:])
+	}
+
 	fen := board.DefaultStartingPositionFEN
 	if len(args) > 1 {
 		fen = strings.Join(args[1:], " ")
@@ -61,6 +82,12 @@ func realMain(args []string) error {
 	if *searchRun {
 		return search(fen, 50, *searchDepth, *searchMovetime)
 	}
+	if *benchRun {
+		return runBench(*benchDepth)
+	}
+	if *xboardRun {
+		return runXBoard()
+	}
 
 	return runUCI()
 }
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/match"
+	"github.com/daystram/gambit/tablebase"
+)
+
+// engineFlags are the options of an engine player, either the built-in engine or a UCI executable.
+type engineFlags struct {
+	name          *string
+	hashTableSize *uint
+	syzygyPath    *string
+	command       *string
+	options       optionFlags
+}
+
+func registerEngineFlags(fs *flag.FlagSet, prefix, name string) *engineFlags {
+	f := &engineFlags{
+		name:          fs.String(prefix+".name", name, "player name"),
+		hashTableSize: fs.Uint(prefix+".hash", uint(engine.DefaultHashTableSizeMB), "hash table size in MB"),
+		syzygyPath:    fs.String(prefix+".syzygy", "", "Syzygy tablebase paths"),
+		command:       fs.String(prefix+".cmd", "", "UCI engine executable, instead of the built-in engine"),
+		options:       make(optionFlags),
+	}
+	fs.Var(f.options, prefix+".option", "UCI option of the executable as name=value, repeatable")
+	return f
+}
+
+func (f *engineFlags) factory() (match.PlayerFactory, error) {
+	if *f.command != "" {
+		fields := strings.Fields(*f.command)
+		return match.NewUCIPlayerFactory(*f.name, fields[0], fields[1:], f.options), nil
+	}
+	tb, err := tablebase.NewSyzygy(*f.syzygyPath)
+	if err != nil {
+		return nil, err
+	}
+	return match.NewEnginePlayerFactory(*f.name, &engine.EngineConfig{
+		HashTableSize: uint32(*f.hashTableSize),
+		Tablebase:     tb,
+	}), nil
+}
+
+// optionFlags collects repeated name=value flags.
+type optionFlags map[string]string
+
+func (o optionFlags) String() string {
+	var options []string
+	for name, value := range o {
+		options = append(options, name+"="+value)
+	}
+	return strings.Join(options, ",")
+}
+
+func (o optionFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid option: %s", s)
+	}
+	o[name] = value
+	return nil
+}
+
+func runMatch(args []string) error {
+	fs := flag.NewFlagSet("match", flag.ContinueOnError)
+	cfg, pgnPath, err := parseMatchFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	pgnFile, err := os.Create(pgnPath)
+	if err != nil {
+		return err
+	}
+	defer pgnFile.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var games int
+	summary, err := match.Run(ctx, cfg, func(g *match.Game, current *match.Summary) {
+		if err := g.Record.Write(pgnFile); err != nil {
+			log.Println("cannot write game:", err)
+		}
+		games++
+		log.Printf("game %d (%s vs %s): %s by %s; %s\n",
+			games, g.Record.Tags["White"], g.Record.Tags["Black"], g.Result, g.Termination, current)
+		if g.Err != nil {
+			log.Println("game error:", g.Err)
+		}
+	})
+	if summary != nil {
+		fmt.Println(summary)
+	}
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// parseMatchFlags parses the match flags shared by the match and SPRT modes, returning the match config and
+// the output PGN path.
+func parseMatchFlags(fs *flag.FlagSet, args []string) (*match.Config, string, error) {
+	engineA := registerEngineFlags(fs, "a", "Gambit A")
+	engineB := registerEngineFlags(fs, "b", "Gambit B")
+	openingsPath := fs.String("openings", "", "opening suite file of FEN or EPD positions, one per line")
+	rounds := fs.Int("rounds", 1, "number of times the opening suite is played, each opening twice with colors swapped")
+	tc := fs.String("tc", "10+0.1", "game clock as base+increment in seconds, or empty to use movetime or depth")
+	movetime := fs.Int("movetime", 0, "movetime in milliseconds, without a game clock")
+	depth := fs.Uint("depth", 0, "search depth, without a game clock")
+	timeMargin := fs.Int("timemargin", 100, "tolerated clock overrun in milliseconds")
+	maxPlies := fs.Int("maxplies", 0, "plies before adjudicating a draw, 0 for no limit")
+	concurrency := fs.Int("concurrency", 1, "number of games played at the same time")
+	pgnPath := fs.String("pgn", "match.pgn", "output PGN file")
+	if err := fs.Parse(args); err != nil {
+		return nil, "", err
+	}
+
+	timeControl, err := parseTimeControl(*tc)
+	if err != nil {
+		return nil, "", err
+	}
+	timeControl.Movetime = time.Duration(*movetime) * time.Millisecond
+	timeControl.Depth = uint8(*depth)
+	if timeControl.Time == 0 && timeControl.Movetime == 0 && timeControl.Depth == 0 {
+		return nil, "", fmt.Errorf("missing time control")
+	}
+
+	var openings []string
+	if *openingsPath != "" {
+		openings, err = match.LoadOpenings(*openingsPath)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	playerA, err := engineA.factory()
+	if err != nil {
+		return nil, "", err
+	}
+	playerB, err := engineB.factory()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &match.Config{
+		PlayerA:     playerA,
+		PlayerB:     playerB,
+		Openings:    openings,
+		Rounds:      *rounds,
+		TimeControl: timeControl,
+		TimeMargin:  time.Duration(*timeMargin) * time.Millisecond,
+		MaxPlies:    *maxPlies,
+		Concurrency: *concurrency,
+		Event:       fmt.Sprintf("%s vs %s", *engineA.name, *engineB.name),
+	}, *pgnPath, nil
+}
+
+// parseTimeControl parses the game clock in seconds, e.g. "60+0.5" or "60".
+func parseTimeControl(tc string) (match.TimeControl, error) {
+	if tc == "" {
+		return match.TimeControl{}, nil
+	}
+	base, increment, _ := strings.Cut(tc, "+")
+	baseSeconds, err := strconv.ParseFloat(base, 64)
+	if err != nil {
+		return match.TimeControl{}, fmt.Errorf("invalid time control: %s", tc)
+	}
+	var incrementSeconds float64
+	if increment != "" {
+		incrementSeconds, err = strconv.ParseFloat(increment, 64)
+		if err != nil {
+			return match.TimeControl{}, fmt.Errorf("invalid time control: %s", tc)
+		}
+	}
+	return match.TimeControl{
+		Time:      time.Duration(baseSeconds * float64(time.Second)),
+		Increment: time.Duration(incrementSeconds * float64(time.Second)),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/daystram/gambit/bench"
+	"github.com/daystram/gambit/board"
+)
+
+func runPerft(args []string) error {
+	fs := flag.NewFlagSet("perft", flag.ContinueOnError)
+	depth := fs.Int("depth", 5, "perft depth, or the max depth of the suite cases")
+	divide := fs.Bool("divide", false, "print the node count below each root move")
+	suitePath := fs.String("suite", "", "perft suite file of \"<fen> ;D1 <nodes> ;D2 <nodes>\" lines, instead of a position")
+	hashTableSize := fs.Uint("hash", 0, "perft hash table size in MB, 0 to disable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *depth < 0 {
+		return errors.New("invalid depth")
+	}
+	tt := bench.NewPerftTable(uint32(*hashTableSize))
+
+	if *suitePath != "" {
+		cases, err := bench.LoadPerftSuite(*suitePath)
+		if err != nil {
+			return err
+		}
+		failed, err := bench.RunPerftSuite(cases, *depth, tt, func(r *bench.PerftResult) {
+			status := "ok"
+			if !r.Passed() {
+				status = "FAILED"
+			}
+			fmt.Printf("%s D%d %d/%d %s (%s)\n", r.Case.FEN, r.Depth, r.Got, r.Want, status, r.Elapsed.Round(time.Millisecond))
+		})
+		if err != nil {
+			return err
+		}
+		if failed != 0 {
+			return fmt.Errorf("%d perft counts failed", failed)
+		}
+		return nil
+	}
+
+	fen := board.DefaultStartingPositionFEN
+	if fs.NArg() > 0 {
+		fen = strings.Join(fs.Args(), " ")
+	}
+	b, err := board.NewBoard(board.WithFEN(fen))
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	var nodes uint64
+	if *divide {
+		for _, r := range bench.Divide(b, *depth, tt) {
+			fmt.Printf("%s: %d\n", r.Move.UCI(), r.Nodes)
+			nodes += r.Nodes
+		}
+		fmt.Println()
+	} else {
+		nodes = bench.Count(b, *depth, tt)
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("Nodes searched: %d (%.3fs elapsed, %.0fn/s)\n", nodes, elapsed.Seconds(), float64(nodes)/elapsed.Seconds())
+	return nil
+}
@@ -9,19 +9,23 @@ import (
 
 	"github.com/daystram/gambit/board"
 	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/game"
 )
 
 func search(fen string, steps, maxDepth, timeout int) error {
 	rand.Seed(time.Now().Unix())
-	b, _ := board.NewBoard(board.WithFEN(fen))
+	g, err := game.NewGame(game.WithFEN(fen))
+	if err != nil {
+		return err
+	}
 	e := engine.NewEngine(&engine.EngineConfig{
 		HashTableSize: engine.DefaultHashTableSizeMB,
 	})
+	b := g.Board()
 	fmt.Println(b.Draw())
 	fmt.Println(b.FEN())
 	fmt.Println(b.DebugString())
-	initialBoard := b.Clone()
-	playingSide := b.Turn()
+	playingSide := g.Turn()
 
 	searchCfg := &engine.SearchConfig{
 		ClockConfig: engine.ClockConfig{
@@ -31,59 +35,43 @@ func search(fen string, steps, maxDepth, timeout int) error {
 		Debug: true,
 	}
 
-	getMove := func(ctx context.Context, b *board.Board) board.Move {
-		if b.Turn() == playingSide {
-			mv, err := e.Search(ctx, b, searchCfg)
-			if err != nil {
-				panic(err)
-			}
-			return mv
-		} else {
-			mvs := b.GeneratePseudoLegalMoves()
-			return mvs[rand.Intn(len(mvs))]
+	getMove := func(ctx context.Context) (board.Move, error) {
+		if g.Turn() == playingSide {
+			return e.Search(ctx, g.Board(), searchCfg)
 		}
+		mvs := g.LegalMoves()
+		return mvs[rand.Intn(len(mvs))], nil
 	}
 
 	ctx := context.Background()
-	var history []board.Move
-	for step := 1; step <= steps; step++ {
-		fmt.Printf("\n=============== Move %d\n", b.FullMoveClock())
-
-		// White's move
-		if b.Turn() == board.SideWhite {
-			fmt.Printf("\n>>> %s\n", board.SideWhite)
-			mv := getMove(ctx, b)
-			b.Apply(mv)
-			history = append(history, mv)
-			fmt.Printf("--- %s\n", mv)
-
-			fmt.Println(b.FEN())
-			fmt.Println(b.Draw())
-			if !b.State().IsRunning() {
-				break
-			}
-			<-time.Tick(2 * time.Millisecond)
+	for step := 1; step <= steps*2 && !g.IsOver(); step++ {
+		turn := g.Turn()
+		if turn == board.SideWhite || step == 1 {
+			fmt.Printf("\n=============== Move %d\n", g.Board().FullMoveClock())
 		}
 
-		// Black's move
-		if b.Turn() == board.SideBlack {
-			fmt.Printf("\n>>> %s\n", board.SideBlack)
-			mv := getMove(ctx, b)
-			b.Apply(mv)
-			history = append(history, mv)
-			fmt.Printf("--- %s\n", mv)
+		fmt.Printf("\n>>> %s\n", turn)
+		mv, err := getMove(ctx)
+		if err != nil {
+			return err
+		}
+		if err := g.Move(mv); err != nil {
+			return err
+		}
+		fmt.Printf("--- %s\n", mv)
 
-			fmt.Println(b.FEN())
-			fmt.Println(b.Draw())
-			if !b.State().IsRunning() {
-				break
-			}
+		b := g.Board()
+		fmt.Println(b.FEN())
+		fmt.Println(b.Draw())
+		if turn == board.SideWhite {
+			<-time.Tick(2 * time.Millisecond)
+		} else {
 			<-time.Tick(2 * time.Second)
 		}
 	}
-	log.Println("=============== game ended:", b.State())
-	fmt.Println(b.FEN())
-	fmt.Println(engine.DumpHistory(initialBoard, history))
+	log.Println("=============== game ended:", g.Result(), g.Termination())
+	fmt.Println(g.FEN())
+	fmt.Println(engine.DumpHistory(g.StartBoard(), g.Moves()))
 
 	return nil
 }
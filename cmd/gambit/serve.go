@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/server"
+	"github.com/daystram/gambit/tablebase"
+)
+
+const (
+	shutdownTimeout = 5 * time.Second
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "listen address")
+	engines := fs.Int("engines", 1, "number of engines, i.e. concurrent searches")
+	hashTableSize := fs.Uint("hash", uint(engine.DefaultHashTableSizeMB), "hash table size of each engine in MB")
+	syzygyPath := fs.String("syzygy", "", "Syzygy tablebase paths")
+	maxMovetime := fs.Int("maxmovetime", int(server.DefaultMaxMovetime.Milliseconds()), "movetime limit of every search in milliseconds")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *engines < 1 {
+		return errors.New("expected at least one engine")
+	}
+
+	tb, err := tablebase.NewSyzygy(*syzygyPath)
+	if err != nil {
+		return err
+	}
+	pool := server.NewPool(*engines, &engine.EngineConfig{
+		HashTableSize: uint32(*hashTableSize),
+		Tablebase:     tb,
+	})
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: server.NewServer(pool, server.WithMaxMovetime(time.Duration(*maxMovetime)*time.Millisecond)),
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("serving analysis on http://%s/analyze\n", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
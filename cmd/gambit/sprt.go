@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/signal"
+
+	"github.com/daystram/gambit/match"
+)
+
+func runSPRT(args []string) error {
+	fs := flag.NewFlagSet("sprt", flag.ContinueOnError)
+	elo0 := fs.Float64("elo0", 0, "Elo difference of the null hypothesis")
+	elo1 := fs.Float64("elo1", 5, "Elo difference of the alternative hypothesis")
+	alpha := fs.Float64("alpha", 0.05, "false positive rate")
+	beta := fs.Float64("beta", 0.05, "false negative rate")
+	cfg, pgnPath, err := parseMatchFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if !isFlagSet(fs, "rounds") {
+		cfg.Rounds = math.MaxInt32 // play until a decision
+	}
+	test := &match.SPRT{Elo0: *elo0, Elo1: *elo1, Alpha: *alpha, Beta: *beta}
+	lower, upper := test.Bounds()
+
+	pgnFile, err := os.Create(pgnPath)
+	if err != nil {
+		return err
+	}
+	defer pgnFile.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var penta match.Pentanomial
+	var decision match.Decision
+	pending := make(map[int]float64) // score of the first finished game of each pair
+	summary, err := match.Run(ctx, cfg, func(g *match.Game, _ *match.Summary) {
+		if err := g.Record.Write(pgnFile); err != nil {
+			log.Println("cannot write game:", err)
+		}
+		if g.Err != nil {
+			log.Println("game error:", g.Err)
+		}
+		first, ok := pending[g.Round]
+		if !ok {
+			pending[g.Round] = g.ScoreA()
+			return
+		}
+		delete(pending, g.Round)
+		penta.AddPair(first, g.ScoreA())
+
+		decision = test.Decide(&penta)
+		log.Printf("pairs %d %s: LLR %.2f (%.2f, %.2f)\n", penta.Pairs(), &penta, test.LLR(&penta), lower, upper)
+		if decision != match.DecisionNone {
+			cancel()
+		}
+	})
+	if summary != nil {
+		fmt.Println(summary)
+	}
+	fmt.Printf("SPRT elo0=%g elo1=%g alpha=%g beta=%g: LLR %.2f (%.2f, %.2f) %s, pentanomial %s\n",
+		*elo0, *elo1, *alpha, *beta, test.LLR(&penta), lower, upper, decision, &penta)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	var set bool
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
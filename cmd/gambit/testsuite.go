@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/epd"
+	"github.com/daystram/gambit/tablebase"
+	"github.com/daystram/gambit/testsuite"
+)
+
+func runTestSuite(args []string) error {
+	fs := flag.NewFlagSet("testsuite", flag.ContinueOnError)
+	movetime := fs.Int("movetime", 1000, "movetime of each position in milliseconds")
+	depth := fs.Uint("depth", 0, "search depth of each position, instead of movetime")
+	nodes := fs.Uint("nodes", 0, "searched nodes of each position, instead of movetime")
+	hashTableSize := fs.Uint("hash", uint(engine.DefaultHashTableSizeMB), "hash table size of each engine in MB")
+	syzygyPath := fs.String("syzygy", "", "Syzygy tablebase paths")
+	concurrency := fs.Int("concurrency", 1, "number of positions searched at the same time")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected EPD file")
+	}
+
+	positions, err := epd.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	tb, err := tablebase.NewSyzygy(*syzygyPath)
+	if err != nil {
+		return err
+	}
+	limit := engine.ClockConfig{Movetime: time.Duration(*movetime) * time.Millisecond}
+	if *depth != 0 || *nodes != 0 {
+		limit = engine.ClockConfig{Depth: uint8(*depth), Nodes: uint32(*nodes)}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	summary, err := testsuite.Run(ctx, &testsuite.Config{
+		Positions: positions,
+		EngineConfig: &engine.EngineConfig{
+			HashTableSize: uint32(*hashTableSize),
+			Logger:        func(...any) {},
+			Tablebase:     tb,
+		},
+		Limit:       limit,
+		Concurrency: *concurrency,
+	}, func(r *testsuite.Result) {
+		name := r.ID
+		if name == "" {
+			name = fmt.Sprintf("#%d", r.Index+1)
+		}
+		switch {
+		case r.Err != nil:
+			log.Printf("%s: error: %v\n", name, r.Err)
+		case r.Solved:
+			log.Printf("%s: solved %s at depth %d in %s\n",
+				name, r.Position.Board.SAN(r.Move), r.DepthToSolution, r.TimeToSolution.Round(time.Millisecond))
+		default:
+			log.Printf("%s: failed %s, expected %s\n", name, r.Position.Board.SAN(r.Move), expectedMoves(r.Position))
+		}
+	})
+	if summary != nil {
+		fmt.Println(summary)
+	}
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// expectedMoves describes the bm and am operations of the position.
+func expectedMoves(p *epd.Position) string {
+	var expected []string
+	for _, op := range []struct {
+		opcode, prefix string
+	}{{epd.OpcodeBestMove, ""}, {epd.OpcodeAvoidMove, "not "}} {
+		mvs := p.Moves(op.opcode)
+		if len(mvs) == 0 {
+			continue
+		}
+		expected = append(expected, op.prefix+sanList(p.Board, mvs))
+	}
+	return strings.Join(expected, " and ")
+}
+
+func sanList(b *board.Board, mvs []board.Move) string {
+	sans := make([]string, 0, len(mvs))
+	for _, mv := range mvs {
+		sans = append(sans, b.SAN(mv))
+	}
+	return strings.Join(sans, " or ")
+}
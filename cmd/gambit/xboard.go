@@ -0,0 +1,8 @@
+package main
+
+import "github.com/daystram/gambit/xboard"
+
+func runXBoard() error {
+	i := xboard.NewInterface()
+	return i.Run()
+}
@@ -3,6 +3,7 @@ package engine
 import (
 	"context"
 	"math"
+	"sync/atomic"
 	"time"
 
 	"github.com/daystram/gambit/board"
@@ -38,14 +39,16 @@ type Clock struct {
 	allocatedDepth    uint8
 	allocatedNodes    uint32
 
-	done   bool
+	// done and stopCh are replaced on every Start, so that a late timer of a previous search cannot affect
+	// the current one.
+	done   *int32
 	stopCh chan struct{}
 }
 
 func NewClock() *Clock {
+	done := int32(1)
 	return &Clock{
-		done:   true,
-		stopCh: make(chan struct{}),
+		done: &done,
 	}
 }
 
@@ -67,7 +70,8 @@ func (c *Clock) Start(ctx context.Context, turn board.Side, fullMoveClock uint8,
 	c.allocatedMovetime = MaxMovetime
 	c.allocatedDepth = MaxDepth - 1
 	c.allocatedNodes = MaxNodes
-	c.done = false
+	done, stopCh := new(int32), make(chan struct{})
+	c.done, c.stopCh = done, stopCh
 
 	if cfg.Movetime != 0 || cfg.WhiteTime != 0 || cfg.BlackTime != 0 {
 		if cfg.Movetime != 0 {
@@ -90,10 +94,6 @@ func (c *Clock) Start(ctx context.Context, turn board.Side, fullMoveClock uint8,
 		}
 	} else if cfg.Depth != 0 {
 		c.mode = ClockModeDepth
-		c.allocatedDepth = cfg.Depth
-		if c.allocatedDepth >= MaxDepth {
-			c.allocatedDepth = MaxDepth - 1
-		}
 	} else if cfg.Nodes != 0 {
 		c.mode = ClockModeNodes
 		c.allocatedNodes = cfg.Nodes
@@ -104,28 +104,39 @@ func (c *Clock) Start(ctx context.Context, turn board.Side, fullMoveClock uint8,
 		c.mode = ClockModeInfinite
 	}
 
+	// the depth limit also applies together with a time limit
+	if cfg.Depth != 0 {
+		c.allocatedDepth = cfg.Depth
+		if c.allocatedDepth >= MaxDepth {
+			c.allocatedDepth = MaxDepth - 1
+		}
+	}
+
+	movetime := c.allocatedMovetime
 	go func() {
 		var cancel context.CancelFunc
-		if c.allocatedMovetime != 0 {
-			ctx, cancel = context.WithTimeout(ctx, c.allocatedMovetime-movetimeMargin)
+		if movetime != 0 {
+			ctx, cancel = context.WithTimeout(ctx, movetime-movetimeMargin)
 			defer cancel()
 		}
 		select {
 		case <-ctx.Done():
-		case <-c.stopCh:
+		case <-stopCh:
 		}
-		c.done = true
+		atomic.StoreInt32(done, 1)
 	}()
 }
 
 func (c *Clock) Stop() {
-	if !c.done {
-		c.stopCh <- struct{}{}
+	atomic.StoreInt32(c.done, 1)
+	if c.stopCh != nil {
+		close(c.stopCh)
+		c.stopCh = nil
 	}
 }
 
 func (c *Clock) DoneByMovetime() bool {
-	return c.done
+	return atomic.LoadInt32(c.done) == 1
 }
 
 func (c *Clock) DoneByDepth(depth uint8) bool {
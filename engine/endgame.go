@@ -0,0 +1,321 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/position"
+)
+
+const (
+	scoreKnownWin int16 = 10000
+
+	scaleFactorDraw            int32 = 0
+	scaleFactorOppositeBishops int32 = 16
+	scaleFactorNormal          int32 = 64
+
+	endgamePhaseLimit int8 = 6 // KQKR
+)
+
+var (
+	scoreEndgameMaterial = [6 + 1]int16{
+		board.PiecePawn:   100,
+		board.PieceKnight: 320,
+		board.PieceBishop: 350,
+		board.PieceRook:   500,
+		board.PieceQueen:  900,
+	}
+
+	// endgameEvaluators maps a material key to the specialized evaluation function of the endgame.
+	endgameEvaluators = map[uint64]endgame{}
+)
+
+// endgameFunc returns the score of the endgame relative to the strong side.
+type endgameFunc func(b *board.Board, strong board.Side) int16
+
+type endgame struct {
+	strong board.Side
+	eval   endgameFunc
+}
+
+func init() {
+	registerEndgame("KPK", evaluateKPK)
+	registerEndgame("KBNK", evaluateKBNK)
+	registerEndgame("KRKP", evaluateKRKP)
+	registerEndgame("KQKR", evaluateKQKR)
+}
+
+// registerEndgame registers the evaluation function for the material signature, e.g. "KBNK", for both sides.
+func registerEndgame(signature string, eval endgameFunc) {
+	split := strings.LastIndex(signature, "K")
+	strongCode, weakCode := signature[:split], signature[split:]
+	for _, strong := range []board.Side{board.SideWhite, board.SideBlack} {
+		var key uint64
+		for _, sym := range strongCode[1:] {
+			key += 1 << materialKeyShift(strong, pieceFromSymbol(sym))
+		}
+		for _, sym := range weakCode[1:] {
+			key += 1 << materialKeyShift(strong.Opposite(), pieceFromSymbol(sym))
+		}
+		endgameEvaluators[key] = endgame{strong: strong, eval: eval}
+	}
+}
+
+// materialKey packs the piece counts of both sides, excluding Kings, into a key.
+func materialKey(b *board.Board) uint64 {
+	var key uint64
+	for _, s := range []board.Side{board.SideWhite, board.SideBlack} {
+		for p := board.PiecePawn; p <= board.PieceQueen; p++ {
+			key |= uint64(b.GetBitmap(s, p).BitCount()) << materialKeyShift(s, p)
+		}
+	}
+	return key
+}
+
+func materialKeyShift(s board.Side, p board.Piece) uint64 {
+	return (uint64(s-board.SideWhite)*6 + uint64(p-board.PiecePawn)) * 4
+}
+
+func pieceFromSymbol(sym rune) board.Piece {
+	switch sym {
+	case 'P':
+		return board.PiecePawn
+	case 'B':
+		return board.PieceBishop
+	case 'N':
+		return board.PieceKnight
+	case 'R':
+		return board.PieceRook
+	case 'Q':
+		return board.PieceQueen
+	default:
+		return board.PieceUnknown
+	}
+}
+
+// evaluateEndgame returns the score of the specialized endgame evaluation relative to the side to move,
+// if the board matches any known endgame.
+func evaluateEndgame(b *board.Board) (int16, bool) {
+	if b.Phase() > endgamePhaseLimit {
+		return 0, false
+	}
+
+	var score int16
+	var strong board.Side
+	if eg, ok := endgameEvaluators[materialKey(b)]; ok {
+		strong = eg.strong
+		score = eg.eval(b, strong)
+	} else if strong = loneKingOpponent(b); strong != board.SideUnknown && hasMatingMaterial(b, strong) {
+		score = evaluateKXK(b, strong)
+	} else {
+		return 0, false
+	}
+
+	if b.Turn() != strong {
+		score = -score
+	}
+	return score, true
+}
+
+// scaleFactor returns the factor, out of scaleFactorNormal, to scale the score of the strong side with
+// due to drawish material configurations.
+func scaleFactor(b *board.Board, strong board.Side) int32 {
+	weak := strong.Opposite()
+	strongPawns := b.GetBitmap(strong, board.PiecePawn)
+	strongBishops := b.GetBitmap(strong, board.PieceBishop)
+	strongPieces := nonPawnCount(b, strong)
+	weakPieces := nonPawnCount(b, weak)
+
+	// opposite colored Bishops
+	weakBishops := b.GetBitmap(weak, board.PieceBishop)
+	if strongPieces == 1 && weakPieces == 1 && strongBishops.BitCount() == 1 && weakBishops.BitCount() == 1 &&
+		isLightCell(strongBishops.LS1B()) != isLightCell(weakBishops.LS1B()) {
+		return scaleFactorOppositeBishops
+	}
+
+	// wrong Rook Pawn, with or without a Bishop
+	if strongPawns != 0 && weakPieces == 0 && (strongPieces == 0 || (strongPieces == 1 && strongBishops != 0)) {
+		file := strongPawns.LS1B().X()
+		if file != position.FileA && file != position.FileH {
+			return scaleFactorNormal
+		}
+		for pawns := strongPawns; pawns != 0; pawns &= pawns - 1 {
+			if pawns.LS1B().X() != file {
+				return scaleFactorNormal
+			}
+		}
+		queeningPos := file + position.Rank8*8
+		if strong == board.SideBlack {
+			queeningPos = file + position.Rank1*8
+		}
+		if strongBishops != 0 && isLightCell(strongBishops.LS1B()) == isLightCell(queeningPos) {
+			return scaleFactorNormal
+		}
+		if distance(b.GetBitmap(weak, board.PieceKing).LS1B(), queeningPos) <= 1 {
+			return scaleFactorDraw
+		}
+	}
+
+	return scaleFactorNormal
+}
+
+// evaluateKXK drives the lone King to the edge of the board.
+func evaluateKXK(b *board.Board, strong board.Side) int16 {
+	weak := strong.Opposite()
+	strongKing := b.GetBitmap(strong, board.PieceKing).LS1B()
+	weakKing := b.GetBitmap(weak, board.PieceKing).LS1B()
+
+	return scoreKnownWin + materialValue(b, strong) +
+		pushToEdge(weakKing) + pushClose(strongKing, weakKing)
+}
+
+// evaluateKBNK drives the lone King to the corner with the same color as the Bishop.
+func evaluateKBNK(b *board.Board, strong board.Side) int16 {
+	weak := strong.Opposite()
+	strongKing := b.GetBitmap(strong, board.PieceKing).LS1B()
+	weakKing := b.GetBitmap(weak, board.PieceKing).LS1B()
+
+	corners := [2]position.Pos{position.A1, position.H8}
+	if isLightCell(b.GetBitmap(strong, board.PieceBishop).LS1B()) {
+		corners = [2]position.Pos{position.A8, position.H1}
+	}
+	cornerDistance := min(manhattanDistance(weakKing, corners[0]), manhattanDistance(weakKing, corners[1]))
+
+	return scoreKnownWin + materialValue(b, strong) +
+		20*int16(14-cornerDistance) + pushClose(strongKing, weakKing)
+}
+
+// evaluateKPK probes the KPK bitbase to determine if the position is won.
+func evaluateKPK(b *board.Board, strong board.Side) int16 {
+	weak := strong.Opposite()
+	strongKing := normalizeCell(b.GetBitmap(strong, board.PieceKing).LS1B(), strong)
+	strongPawn := normalizeCell(b.GetBitmap(strong, board.PiecePawn).LS1B(), strong)
+	weakKing := normalizeCell(b.GetBitmap(weak, board.PieceKing).LS1B(), strong)
+
+	if strongPawn.X() > position.FileD {
+		strongKing, strongPawn, weakKing = strongKing^7, strongPawn^7, weakKing^7
+	}
+	if !probeKPK(b.Turn() == strong, strongKing, strongPawn, weakKing) {
+		return 0
+	}
+	return scoreKnownWin + scoreEndgameMaterial[board.PiecePawn] + 10*int16(strongPawn.Y())
+}
+
+// evaluateKRKP is mostly a win for the Rook, unless the Pawn is far advanced and supported by its King.
+func evaluateKRKP(b *board.Board, strong board.Side) int16 {
+	weak := strong.Opposite()
+	strongKing := normalizeCell(b.GetBitmap(strong, board.PieceKing).LS1B(), strong)
+	strongRook := normalizeCell(b.GetBitmap(strong, board.PieceRook).LS1B(), strong)
+	weakKing := normalizeCell(b.GetBitmap(weak, board.PieceKing).LS1B(), strong)
+	weakPawn := normalizeCell(b.GetBitmap(weak, board.PiecePawn).LS1B(), strong)
+	queeningPos := weakPawn.X() + position.Rank1*8
+	isWeakTurn := int16(0)
+	if b.Turn() == weak {
+		isWeakTurn = 1
+	}
+	isStrongTurn := 1 - isWeakTurn
+
+	// strong King is in front of the Pawn, or weak King is too far from its Pawn and the Rook
+	if (strongKing.X() == weakPawn.X() && strongKing.Y() < weakPawn.Y()) ||
+		(int16(distance(weakKing, weakPawn)) >= 3+isWeakTurn && distance(weakKing, strongRook) >= 3) {
+		return scoreEndgameMaterial[board.PieceRook] - int16(distance(strongKing, weakPawn))
+	}
+
+	// Pawn is far advanced and supported by its King
+	if weakKing.Y() <= position.Rank3 && distance(weakKing, weakPawn) == 1 &&
+		strongKing.Y() >= position.Rank4 && int16(distance(strongKing, weakPawn)) > 2+isStrongTurn {
+		return 80 - 8*int16(distance(strongKing, weakPawn))
+	}
+
+	return 200 - 8*(int16(distance(strongKing, weakPawn-8))-
+		int16(distance(weakKing, weakPawn-8))-
+		int16(distance(weakPawn, queeningPos)))
+}
+
+// evaluateKQKR drives the defending King to the edge, where the Queen can win the Rook or mate.
+func evaluateKQKR(b *board.Board, strong board.Side) int16 {
+	weak := strong.Opposite()
+	strongKing := b.GetBitmap(strong, board.PieceKing).LS1B()
+	weakKing := b.GetBitmap(weak, board.PieceKing).LS1B()
+
+	return scoreEndgameMaterial[board.PieceQueen] - scoreEndgameMaterial[board.PieceRook] +
+		pushToEdge(weakKing) + pushClose(strongKing, weakKing)
+}
+
+// loneKingOpponent returns the side playing against a lone King, if any.
+func loneKingOpponent(b *board.Board) board.Side {
+	for _, s := range []board.Side{board.SideWhite, board.SideBlack} {
+		if nonPawnCount(b, s) == 0 && b.GetBitmap(s, board.PiecePawn) == 0 {
+			return s.Opposite()
+		}
+	}
+	return board.SideUnknown
+}
+
+// hasMatingMaterial returns true if the side can force a mate without promoting a Pawn.
+func hasMatingMaterial(b *board.Board, s board.Side) bool {
+	bishops := b.GetBitmap(s, board.PieceBishop)
+	knights := b.GetBitmap(s, board.PieceKnight)
+	if b.GetBitmap(s, board.PieceQueen) != 0 || b.GetBitmap(s, board.PieceRook) != 0 {
+		return true
+	}
+	if bishops != 0 && knights != 0 {
+		return true
+	}
+	if bishops.BitCount() >= 2 {
+		lights := 0
+		for bm := bishops; bm != 0; bm &= bm - 1 {
+			if isLightCell(bm.LS1B()) {
+				lights++
+			}
+		}
+		return lights != 0 && lights != int(bishops.BitCount())
+	}
+	return false
+}
+
+func materialValue(b *board.Board, s board.Side) int16 {
+	white, black := b.GetMaterialValue()
+	if s == board.SideWhite {
+		return white
+	}
+	return black
+}
+
+func nonPawnCount(b *board.Board, s board.Side) uint8 {
+	return b.GetBitmap(s, board.PieceKnight).BitCount() +
+		b.GetBitmap(s, board.PieceBishop).BitCount() +
+		b.GetBitmap(s, board.PieceRook).BitCount() +
+		b.GetBitmap(s, board.PieceQueen).BitCount()
+}
+
+// normalizeCell flips the cell vertically such that the side is playing from Rank1.
+func normalizeCell(pos position.Pos, s board.Side) position.Pos {
+	if s == board.SideBlack {
+		return pos ^ 56
+	}
+	return pos
+}
+
+func isLightCell(pos position.Pos) bool {
+	return (pos.X()+pos.Y())%2 == 1
+}
+
+// pushToEdge rewards the lone King being far from the center.
+func pushToEdge(pos position.Pos) int16 {
+	return 20 * int16(max(3-pos.X(), pos.X()-4)+max(3-pos.Y(), pos.Y()-4))
+}
+
+// pushClose rewards the Kings being close to each other.
+func pushClose(pos1, pos2 position.Pos) int16 {
+	return 20 * int16(7-distance(pos1, pos2))
+}
+
+// distance returns the Chebyshev distance between the cells.
+func distance(pos1, pos2 position.Pos) position.Pos {
+	return max(abs(pos1.X()-pos2.X()), abs(pos1.Y()-pos2.Y()))
+}
+
+func manhattanDistance(pos1, pos2 position.Pos) position.Pos {
+	return abs(pos1.X()-pos2.X()) + abs(pos1.Y()-pos2.Y())
+}
@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/daystram/gambit/board"
+)
+
+func TestEvaluateEndgame(t *testing.T) {
+	t.Parallel()
+	// scores are relative to the side to move
+	tests := []struct {
+		name    string
+		fen     string
+		wantMin int16
+		wantMax int16
+	}{
+		{name: "KPK king on sixth", fen: "4k3/8/4K3/4P3/8/8/8/8 w - - 0 1", wantMin: scoreKnownWin, wantMax: ScoreInfinite},
+		{name: "KPK king on sixth, defender to move", fen: "4k3/8/4K3/4P3/8/8/8/8 b - - 0 1", wantMin: -ScoreInfinite, wantMax: -scoreKnownWin},
+		{name: "KPK king blocking", fen: "8/8/8/8/8/4k3/4P3/4K3 w - - 0 1", wantMin: 0, wantMax: 0},
+		{name: "KPK rook pawn", fen: "k7/8/8/8/8/8/P7/K7 w - - 0 1", wantMin: 0, wantMax: 0},
+		{name: "KPK black", fen: "8/8/8/8/4p3/4k3/8/4K3 b - - 0 1", wantMin: scoreKnownWin, wantMax: ScoreInfinite},
+		{name: "KPK mirrored", fen: "4k3/8/3K4/3P4/8/8/8/8 w - - 0 1", wantMin: scoreKnownWin, wantMax: ScoreInfinite},
+		{name: "KBNK", fen: "8/8/3k4/8/8/8/8/2BNK3 w - - 0 1", wantMin: scoreKnownWin, wantMax: ScoreInfinite},
+		{name: "KRK", fen: "8/8/3k4/8/8/8/8/3RK3 b - - 0 1", wantMin: -ScoreInfinite, wantMax: -scoreKnownWin},
+		{name: "KQKR", fen: "8/8/3kr3/8/8/8/8/3QK3 w - - 0 1", wantMin: 1, wantMax: scoreKnownWin},
+		{name: "KRKP", fen: "8/8/8/8/8/8/2kp4/3RK3 w - - 0 1", wantMin: 1, wantMax: scoreKnownWin},
+		{name: "wrong rook pawn", fen: "k7/8/8/8/8/8/P7/K1B5 w - - 0 1", wantMin: 0, wantMax: 0},
+	}
+
+	e := NewEngine(&EngineConfig{})
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			if score := e.Evaluate(b); score < tt.wantMin || score > tt.wantMax {
+				t.Errorf("unexpected score: got=%d want=[%d, %d]", score, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
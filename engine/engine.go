@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/tablebase"
 	"golang.org/x/exp/constraints"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -24,29 +25,50 @@ const (
 	lateMoveReductionFullMoves        = 4
 	lateMoveReductionDepthLimit       = 3
 
-	scoreCheckmate = ScoreInfinite - 1
+	scoreCheckmate    = ScoreInfinite - 1
+	scoreTablebaseWin = scoreCheckmate - int16(MaxDepth) - 1
+	scoreCursedWin    = 1
+
+	fiftyMoveHalfMoves = 100
 )
 
 func DefaultLogger(a ...any) {
 	fmt.Println(a...)
 }
 
+// PVLine is the principal variation in packed moves, keeping the copy made at every node small. The full
+// moves are unpacked by replaying the line on the board it was searched from.
 type PVLine struct {
-	mvs []board.Move
+	mvs []board.Move16
 }
 
-func (pvl *PVLine) GetPV() board.Move {
+func (pvl *PVLine) GetPV(b *board.Board) board.Move {
 	if len(pvl.mvs) == 0 {
 		return board.Move{}
 	}
-	return pvl.mvs[0]
+	return b.NewMoveFromMove16(pvl.mvs[0])
 }
 
 func (pvl *PVLine) Set(mv board.Move, nextPVL PVLine) {
 	if pvl == nil {
 		return
 	}
-	pvl.mvs = append([]board.Move{mv}, nextPVL.mvs...)
+	pvl.mvs = append([]board.Move16{mv.Move16()}, nextPVL.mvs...)
+}
+
+// Moves unpacks the line, replaying it on a clone of the board it was searched from.
+func (pvl *PVLine) Moves(b *board.Board) []board.Move {
+	if len(pvl.mvs) == 0 {
+		return nil
+	}
+	bb := b.Clone()
+	mvs := make([]board.Move, 0, len(pvl.mvs))
+	for _, m := range pvl.mvs {
+		mv := bb.NewMoveFromMove16(m)
+		bb.Apply(mv)
+		mvs = append(mvs, mv)
+	}
+	return mvs
 }
 
 func (pvl *PVLine) Clear() {
@@ -72,7 +94,7 @@ func (pvl *PVLine) StringUCI() string {
 }
 
 func (pvl *PVLine) String(b *board.Board) string {
-	return DumpHistory(b, pvl.mvs)
+	return DumpHistory(b, pvl.Moves(b))
 }
 
 func DumpHistory(b *board.Board, mvs []board.Move) string {
@@ -112,24 +134,41 @@ func DumpHistory(b *board.Board, mvs []board.Move) string {
 type EngineConfig struct {
 	HashTableSize uint32
 	Logger        func(...any)
+	Tablebase     *tablebase.Syzygy
 }
 
 type SearchConfig struct {
 	ClockConfig ClockConfig
 	Debug       bool
+
+	// OnInfo is called after each completed iteration, from the goroutine running the search.
+	OnInfo func(*SearchInfo)
 }
 
 type Engine struct {
 	tt           *TranspositionTable
-	killers      [MaxDepth][2]board.Move
+	killers      [MaxDepth][2]board.Move16
+	moveBuffers  [MaxDepth][]board.Move // quiescence moves, by ply from the root
 	boardHistory [1024]uint64
 	clock        *Clock
+	tablebase    *tablebase.Syzygy
+	rootMoves    []board.Move
 
 	currentPly  uint16
 	currentTurn board.Side
 	nodes       uint32
 	elapsedTime time.Duration
 	logger      func(...any)
+
+	// progress of the current iteration, for SearchConfig.OnInfo
+	onInfo        func(*SearchInfo)
+	startTime     time.Time
+	lastProgress  time.Time
+	currentDepth  uint8
+	selDepth      uint16
+	rootSearched  int
+	rootBestScore int16
+	rootBestPVL   PVLine
 }
 
 func NewEngine(cfg *EngineConfig) *Engine {
@@ -138,9 +177,10 @@ func NewEngine(cfg *EngineConfig) *Engine {
 	}
 
 	return &Engine{
-		tt:     NewTranspositionTable(cfg.HashTableSize),
-		clock:  NewClock(),
-		logger: cfg.Logger,
+		tt:        NewTranspositionTable(cfg.HashTableSize),
+		clock:     NewClock(),
+		tablebase: cfg.Tablebase,
+		logger:    cfg.Logger,
 	}
 }
 
@@ -165,29 +205,37 @@ func (e *Engine) search(ctx context.Context, b *board.Board, cfg *SearchConfig)
 	e.currentTurn = b.Turn()
 	e.nodes = 0
 	e.elapsedTime = 0
+	e.rootMoves = e.probeRoot(b)
+	e.onInfo = cfg.OnInfo
+	e.startTime = time.Now()
+	e.lastProgress = e.startTime
 	timeDecay := float64(1)
 
 	e.clock.Start(ctx, b.Turn(), b.FullMoveClock(), &cfg.ClockConfig)
 
 	for d := uint8(1); !e.clock.DoneByDepth(d); d++ {
-		startTime := time.Now()
+		e.currentDepth, e.selDepth, e.rootSearched, e.rootBestScore = d, 0, 0, -ScoreInfinite
+		e.rootBestPVL.Clear()
 		candidateScore := e.negamax(b, board.Move{}, &pvl, d, 0, -ScoreInfinite, ScoreInfinite)
-		e.elapsedTime += time.Since(startTime)
+		e.elapsedTime = time.Since(e.startTime) // same clock as the progress infos
 
 		if e.clock.DoneByMovetime() {
+			e.reportIncomplete(b)
 			break
 		}
 
-		bestMove = pvl.GetPV()
+		bestMove = pvl.GetPV(b)
 		bestScore = candidateScore
 
 		if cfg.Debug {
 			e.logger(message.NewPrinter(language.English).
 				Sprintf("depth:%d [%s] nodes:%d (%.0fn/s) t:%s\n    %s",
 					d, formatScoreDebug(bestScore, pvl), e.nodes, float64(e.nodes)/((e.elapsedTime + 1).Seconds()), e.elapsedTime, pvl.String(b)))
-		} else {
-			e.logger(fmt.Sprintf("info depth %d score %s time %d nodes %d nps %.0f pv %s",
-				d, formatScoreUCI(bestScore, pvl), e.elapsedTime.Milliseconds(), e.nodes, float64(e.nodes)/((e.elapsedTime + 1).Seconds()), pvl.StringUCI()))
+		}
+		if cfg.OnInfo != nil {
+			info := newSearchInfo(b, d, bestScore, pvl, e.nodes, e.elapsedTime, e.tt.HashFull(e.currentPly))
+			info.SelDepth = e.selDepthInfo()
+			cfg.OnInfo(info)
 		}
 
 		if bestScore == scoreCheckmate || bestScore == -scoreCheckmate {
@@ -226,7 +274,7 @@ func (e *Engine) negamax(
 	depth, dist uint8,
 	alpha, beta int16,
 ) int16 {
-	e.nodes++
+	e.visit(b)
 
 	// check if movetime exceeded
 	if e.clock.DoneByMovetime() {
@@ -245,6 +293,18 @@ func (e *Engine) negamax(
 
 	isRoot := dist == 0
 
+	// check fifty move rule, checkmate takes precedence
+	if !isRoot && b.HalfMoveClock() >= fiftyMoveHalfMoves && !b.State().IsCheckmate() {
+		return 0
+	}
+
+	// check from Syzygy tablebase
+	if !isRoot {
+		if score, ok := e.probeWDL(b, dist); ok {
+			return score
+		}
+	}
+
 	// check from TranspositionTable
 	ttType, ttMove, ttScore, ttDepth, ok := e.tt.Get(b, e.currentPly)
 	if !isRoot && ok && ttDepth >= depth {
@@ -280,20 +340,38 @@ func (e *Engine) negamax(
 		}
 	}
 
-	// generate next moves
-	mvs := b.GeneratePseudoLegalMoves()
-
-	// assign score to moves
-	e.scoreMoves(b, ttMove, &mvs)
+	// try the hash move first, generating the other moves only if it does not cause a cutoff
+	var mvs []board.Move
+	generated := !ok || !b.IsPseudoLegal(ttMove)
+	if generated {
+		mvs = e.generateMoves(b, ttMove)
+	} else {
+		mvs = []board.Move{b.NewMoveFromMove16(ttMove)}
+	}
 
 	var moveCount int8
 	var bestMove board.Move
 	var childPVL PVLine
 	bestScore := -ScoreInfinite
 	ttType = EntryTypeLowerBound
-	for i := 0; i < len(mvs); i++ {
+	for i := 0; ; i++ {
+		if i == len(mvs) {
+			if generated {
+				break
+			}
+			mvs, generated = e.generateMoves(b, ttMove), true
+			if len(mvs) == 0 || mvs[0].Move16() != ttMove {
+				i = 0 // the hash move was not generated, search every move
+			}
+			if i == len(mvs) {
+				break
+			}
+		}
 		e.sortMoves(&mvs, i)
 		mv := mvs[i]
+		if isRoot && !e.isRootMove(mv) {
+			continue
+		}
 
 		unApply, ok := b.Apply(mv)
 		if !ok {
@@ -301,6 +379,9 @@ func (e *Engine) negamax(
 			continue
 		}
 		moveCount++
+		if isRoot {
+			e.reportCurrMove(mv, int(moveCount))
+		}
 		e.boardHistory[dist] = b.Hash()
 		var score int16
 		if moveCount == 1 {
@@ -324,6 +405,13 @@ func (e *Engine) negamax(
 		}
 		unApply()
 
+		if isRoot && !e.clock.DoneByMovetime() {
+			e.rootSearched++
+			if score > e.rootBestScore {
+				e.rootBestScore = score
+				e.rootBestPVL.Set(mv, childPVL)
+			}
+		}
 		if score > bestScore {
 			bestMove = mv
 			bestScore = score
@@ -332,9 +420,9 @@ func (e *Engine) negamax(
 			// set Killer move
 			if depth > 0 && !bestMove.IsCapture {
 				ply := b.Ply()
-				if !bestMove.Equals(e.killers[ply][0]) {
+				if killer := bestMove.Move16(); killer != e.killers[ply][0] {
 					e.killers[ply][1] = e.killers[ply][0]
-					e.killers[ply][0] = bestMove
+					e.killers[ply][0] = killer
 				}
 			}
 			ttType = EntryTypeUpperBound
@@ -363,13 +451,13 @@ func (e *Engine) negamax(
 	}
 
 	// set TranspositionTable
-	e.tt.Set(b, e.currentPly, ttType, bestMove, bestScore, depth)
+	e.tt.Set(b, e.currentPly, ttType, bestMove.Move16(), bestScore, depth)
 
 	return bestScore
 }
 
 func (e *Engine) quiescence(b *board.Board, pvl *PVLine, alpha, beta int16) int16 {
-	e.nodes++
+	e.visit(b)
 
 	if e.clock.DoneByMovetime() {
 		return 0
@@ -387,24 +475,32 @@ func (e *Engine) quiescence(b *board.Board, pvl *PVLine, alpha, beta int16) int1
 		alpha = eval
 	}
 
-	mvs := b.GeneratePseudoLegalMoves()
+	// all evasions if in check, as standing pat is not an option
+	ply := b.Ply() - e.currentPly
+	mvs := e.moveBuffers[ply][:0]
+	if isCheck {
+		mvs = b.GenerateLegalMoves(mvs)
+	} else {
+		// only captures, quiet promotions are left to the main search
+		captures := b.GenerateCaptures(mvs)
+		mvs = captures[:0]
+		for _, mv := range captures {
+			if mv.IsCapture {
+				mvs = append(mvs, mv)
+			}
+		}
+	}
+	e.moveBuffers[ply] = mvs
 
-	e.scoreMoves(b, board.Move{}, &mvs)
+	e.scoreMoves(b, 0, &mvs)
 
 	var childPVL PVLine
 	bestScore := eval
 	for i := 0; i < len(mvs); i++ {
 		e.sortMoves(&mvs, i)
 		mv := mvs[i]
-		if !isCheck && !mv.IsCapture {
-			continue
-		}
 
-		unApply, ok := b.Apply(mv)
-		if !ok {
-			unApply()
-			continue
-		}
+		unApply, _ := b.Apply(mv)
 		score := -e.quiescence(b, &childPVL, -beta, -alpha)
 		unApply()
 
@@ -428,6 +524,64 @@ func (e *Engine) quiescence(b *board.Board, pvl *PVLine, alpha, beta int16) int1
 	return bestScore
 }
 
+// probeWDL probes the WDL tables once the half move clock has been reset and the material is within the
+// tables. WDL results assume the fifty move rule starts counting from the position.
+func (e *Engine) probeWDL(b *board.Board, dist uint8) (int16, bool) {
+	if e.tablebase == nil || b.HalfMoveClock() != 0 || b.CastleRights() != 0 || pieceCount(b) > e.tablebase.MaxPieces() {
+		return 0, false
+	}
+	wdl, err := e.tablebase.ProbeWDL(b)
+	if err != nil {
+		return 0, false
+	}
+	switch wdl {
+	case tablebase.WDLWin:
+		return scoreTablebaseWin - int16(dist), true
+	case tablebase.WDLLoss:
+		return -scoreTablebaseWin + int16(dist), true
+	case tablebase.WDLCursedWin:
+		return scoreCursedWin, true
+	case tablebase.WDLBlessedLoss:
+		return -scoreCursedWin, true
+	default:
+		return 0, true
+	}
+}
+
+// probeRoot returns the root moves preserving the best DTZ result, taking the fifty move rule into account.
+func (e *Engine) probeRoot(b *board.Board) []board.Move {
+	if e.tablebase == nil || b.CastleRights() != 0 || pieceCount(b) > e.tablebase.MaxPieces() {
+		return nil
+	}
+	rms, err := e.tablebase.ProbeRoot(b, true)
+	if err != nil {
+		return nil
+	}
+	return tablebase.BestRootMoves(rms)
+}
+
+func (e *Engine) isRootMove(mv board.Move) bool {
+	if len(e.rootMoves) == 0 {
+		return true
+	}
+	for _, rootMove := range e.rootMoves {
+		if mv.Equals(rootMove) {
+			return true
+		}
+	}
+	return false
+}
+
+func pieceCount(b *board.Board) int {
+	var count int
+	for _, s := range []board.Side{board.SideWhite, board.SideBlack} {
+		for p := board.PiecePawn; p <= board.PieceKing; p++ {
+			count += int(b.GetBitmap(s, p).BitCount())
+		}
+	}
+	return count
+}
+
 func (e *Engine) isBoardRepeated(b *board.Board, dist uint8) bool {
 	count := 0
 	for ply := uint8(0); ply < dist; ply++ {
@@ -482,13 +636,3 @@ func formatScoreDebug(s int16, pvl PVLine) string {
 	}
 	return "0"
 }
-
-func formatScoreUCI(s int16, pvl PVLine) string {
-	if s == scoreCheckmate {
-		return fmt.Sprintf("mate %d", pvl.Len()/2+1)
-	}
-	if s == -scoreCheckmate {
-		return fmt.Sprintf("mate -%d", pvl.Len()/2)
-	}
-	return fmt.Sprintf("cp %d", s)
-}
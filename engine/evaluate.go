@@ -22,17 +22,28 @@ var (
 	scoreKiller uint8 = 10
 )
 
-func (e *Engine) scoreMoves(b *board.Board, pvMove board.Move, mvs *[]board.Move) {
+// generateMoves generates and scores the pseudo-legal moves, with the best one, the hash move if present, sorted
+// first.
+func (e *Engine) generateMoves(b *board.Board, hashMove board.Move16) []board.Move {
+	mvs := b.GeneratePseudoLegalMoves()
+	e.scoreMoves(b, hashMove, &mvs)
+	if len(mvs) != 0 {
+		e.sortMoves(&mvs, 0)
+	}
+	return mvs
+}
+
+func (e *Engine) scoreMoves(b *board.Board, pvMove board.Move16, mvs *[]board.Move) {
 	for i, mv := range *mvs {
 		var score uint8
-		if mv.Equals(pvMove) {
+		if m := mv.Move16(); m == pvMove {
 			score = offsetPV
 		} else if mv.IsCapture {
 			capturedPiece, _ := b.GetSideAndPieces(mv.To)
 			score = offsetMVVLVA + scoreMVVLVA[mv.Piece][capturedPiece]
 		} else {
 			for i, killer := range e.killers[b.Ply()] {
-				if mv.Equals(killer) {
+				if m == killer {
 					score = offsetMVVLVA - uint8(i+1)*scoreKiller
 					break
 				}
@@ -59,6 +70,10 @@ func (e *Engine) sortMoves(mvs *[]board.Move, index int) {
 // Evaluate returns the score evaluated from the given board.
 // The score is positive relative to the currently playing side.
 func (e *Engine) Evaluate(b *board.Board) int16 {
+	if score, ok := evaluateEndgame(b); ok {
+		return score
+	}
+
 	ourTurn := b.Turn()
 	theirTurn := ourTurn.Opposite()
 
@@ -92,7 +107,17 @@ func (e *Engine) Evaluate(b *board.Board) int16 {
 	}
 
 	scoreMG, scoreEG := positionMG+tempoMG, positionEG+tempoEG
-	phaseMG := int16(max(b.Phase(), 0))
+	phaseMG := int16(min(max(b.Phase(), 0), board.PhaseTotal)) // promotions may exceed the starting material
 	phaseEG := int16(board.PhaseTotal) - phaseMG
-	return ((scoreMG*phaseMG + scoreEG*phaseEG) / int16(board.PhaseTotal)) + material + bishopPair
+	score := ((scoreMG*phaseMG + scoreEG*phaseEG) / int16(board.PhaseTotal)) + material + bishopPair
+
+	// scale down drawish endgames
+	strong := ourTurn
+	if score < 0 {
+		strong = theirTurn
+	}
+	if sf := scaleFactor(b, strong); sf != scaleFactorNormal {
+		score = int16(int32(score) * sf / scaleFactorNormal)
+	}
+	return score
 }
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/daystram/gambit/board"
+)
+
+const (
+	infoCurrMoveDelay     = time.Second // root moves are reported once the search has run this long
+	infoProgressInterval  = time.Second
+	infoProgressNodesMask = 1<<14 - 1 // the clock is checked for progress reports every 16384 nodes
+)
+
+type SearchInfoType uint8
+
+const (
+	// SearchInfoTypeIteration is a completed iteration, with all fields except CurrMove set.
+	SearchInfoTypeIteration SearchInfoType = iota
+	// SearchInfoTypeCurrMove is the root move about to be searched, with Depth, CurrMove, and CurrMoveNumber.
+	SearchInfoTypeCurrMove
+	// SearchInfoTypeProgress is a periodic report during an iteration, with Nodes, NPS, Time, and HashFull.
+	SearchInfoTypeProgress
+	// SearchInfoTypeIncomplete is an iteration stopped before searching all root moves. The score and the PV
+	// are of the best of the CurrMoveNumber root moves fully searched.
+	SearchInfoTypeIncomplete
+)
+
+type ScoreType uint8
+
+const (
+	ScoreTypeCentipawns ScoreType = iota
+	ScoreTypeMate
+)
+
+// SearchInfo is the progress of a search, of the fields according to its type.
+type SearchInfo struct {
+	Type SearchInfoType
+
+	Depth    uint8
+	SelDepth uint8 // deepest ply reached in the iteration, including quiescence
+
+	// Score is from the side to move, either in centipawns or in moves to mate, negative if getting mated.
+	ScoreType ScoreType
+	Score     int
+
+	Nodes    uint32
+	NPS      uint64
+	Time     time.Duration
+	HashFull int // permille of the transposition table in use
+	PV       []board.Move
+
+	// CurrMove is the root move being searched, and CurrMoveNumber its 1-based index.
+	CurrMove       board.Move
+	CurrMoveNumber int
+}
+
+func newSearchInfo(b *board.Board, depth uint8, score int16, pvl PVLine, nodes uint32, elapsed time.Duration, hashFull int) *SearchInfo {
+	info := &SearchInfo{
+		Type:      SearchInfoTypeIteration,
+		Depth:     depth,
+		ScoreType: ScoreTypeCentipawns,
+		Score:     int(score),
+		Nodes:     nodes,
+		NPS:       nps(nodes, elapsed),
+		Time:      elapsed,
+		HashFull:  hashFull,
+		PV:        pvl.Moves(b),
+	}
+	switch score {
+	case scoreCheckmate:
+		info.ScoreType, info.Score = ScoreTypeMate, pvl.Len()/2+1
+	case -scoreCheckmate:
+		info.ScoreType, info.Score = ScoreTypeMate, -pvl.Len()/2
+	}
+	return info
+}
+
+// reportCurrMove reports the root move once the search has run long enough for the GUI to benefit from it.
+func (e *Engine) reportCurrMove(mv board.Move, number int) {
+	if e.onInfo == nil || time.Since(e.startTime) < infoCurrMoveDelay {
+		return
+	}
+	e.onInfo(&SearchInfo{
+		Type:           SearchInfoTypeCurrMove,
+		Depth:          e.currentDepth,
+		CurrMove:       mv,
+		CurrMoveNumber: number,
+	})
+}
+
+// visit counts the node, sending a progress report at most every infoProgressInterval.
+func (e *Engine) visit(b *board.Board) {
+	e.nodes++
+	if ply := b.Ply() - e.currentPly; ply > e.selDepth {
+		e.selDepth = ply
+	}
+	if e.onInfo == nil || e.nodes&infoProgressNodesMask != 0 || time.Since(e.lastProgress) < infoProgressInterval {
+		return
+	}
+	e.lastProgress = time.Now()
+	elapsed := time.Since(e.startTime)
+	e.onInfo(&SearchInfo{
+		Type:     SearchInfoTypeProgress,
+		Nodes:    e.nodes,
+		NPS:      nps(e.nodes, elapsed),
+		Time:     elapsed,
+		HashFull: e.tt.HashFull(e.currentPly),
+	})
+}
+
+// reportIncomplete reports the best root move of an iteration stopped early from the board, if any root move
+// was searched.
+func (e *Engine) reportIncomplete(b *board.Board) {
+	if e.onInfo == nil || e.rootSearched == 0 {
+		return
+	}
+	info := newSearchInfo(b, e.currentDepth, e.rootBestScore, e.rootBestPVL, e.nodes, e.elapsedTime, e.tt.HashFull(e.currentPly))
+	info.Type = SearchInfoTypeIncomplete
+	info.SelDepth = e.selDepthInfo()
+	info.CurrMoveNumber = e.rootSearched
+	e.onInfo(info)
+}
+
+func (e *Engine) selDepthInfo() uint8 {
+	return uint8(min(e.selDepth, uint16(MaxDepth)))
+}
+
+func nps(nodes uint32, elapsed time.Duration) uint64 {
+	return uint64(float64(nodes) / (elapsed + 1).Seconds())
+}
@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/daystram/gambit/board"
+)
+
+func TestSearchOnInfo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		fen           string
+		depth         uint8
+		wantInfos     int
+		wantScoreType ScoreType
+		wantScore     int
+	}{
+		{name: "startpos", fen: board.DefaultStartingPositionFEN, depth: 3, wantInfos: 3, wantScoreType: ScoreTypeCentipawns},
+		{name: "mate in one", fen: "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", depth: 5, wantInfos: 2, wantScoreType: ScoreTypeMate, wantScore: 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var infos []*SearchInfo
+			e := NewEngine(&EngineConfig{HashTableSize: 1})
+			mv, err := e.Search(context.Background(), b, &SearchConfig{
+				ClockConfig: ClockConfig{Depth: tt.depth},
+				OnInfo: func(info *SearchInfo) {
+					infos = append(infos, info)
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(infos) != tt.wantInfos {
+				t.Fatalf("unexpected info count: got=%d want=%d", len(infos), tt.wantInfos)
+			}
+			for d, info := range infos {
+				if info.Type != SearchInfoTypeIteration {
+					t.Fatalf("unexpected info type: got=%d want=%d", info.Type, SearchInfoTypeIteration)
+				}
+				if info.Depth != uint8(d+1) {
+					t.Errorf("unexpected depth: got=%d want=%d", info.Depth, d+1)
+				}
+				if info.SelDepth < info.Depth {
+					t.Errorf("unexpected seldepth: got=%d want>=%d", info.SelDepth, info.Depth)
+				}
+				if len(info.PV) == 0 || info.Nodes == 0 {
+					t.Errorf("unexpected info: got=%+v", info)
+				}
+			}
+			last := infos[len(infos)-1]
+			if !last.PV[0].Equals(mv) {
+				t.Errorf("unexpected pv move: got=%s want=%s", last.PV[0].UCI(), mv.UCI())
+			}
+			if last.ScoreType != tt.wantScoreType {
+				t.Errorf("unexpected score type: got=%d want=%d", last.ScoreType, tt.wantScoreType)
+			}
+			if tt.wantScoreType == ScoreTypeMate && last.Score != tt.wantScore {
+				t.Errorf("unexpected score: got=%d want=%d", last.Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestSearchDepthWithClock(t *testing.T) {
+	t.Parallel()
+	b, err := board.NewBoard()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var maxDepth uint8
+	e := NewEngine(&EngineConfig{HashTableSize: 1})
+	_, err = e.Search(context.Background(), b, &SearchConfig{
+		ClockConfig: ClockConfig{WhiteTime: time.Hour, BlackTime: time.Hour, Depth: 2},
+		OnInfo: func(info *SearchInfo) {
+			if info.Type == SearchInfoTypeIteration && info.Depth > maxDepth {
+				maxDepth = info.Depth
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if maxDepth != 2 {
+		t.Errorf("unexpected depth: got=%d want=%d", maxDepth, 2)
+	}
+}
+
+func TestSearchOnInfoProgress(t *testing.T) {
+	t.Parallel()
+	b, err := board.NewBoard()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counts := make(map[SearchInfoType]int)
+	var lastTime time.Duration
+	e := NewEngine(&EngineConfig{HashTableSize: 1})
+	_, err = e.Search(context.Background(), b, &SearchConfig{
+		ClockConfig: ClockConfig{Movetime: 2500 * time.Millisecond},
+		OnInfo: func(info *SearchInfo) {
+			counts[info.Type]++
+			if info.Type != SearchInfoTypeCurrMove {
+				// every info type reports the time since the same start
+				if info.Time < lastTime {
+					t.Errorf("unexpected time of info type %d: got=%s want>=%s", info.Type, info.Time, lastTime)
+				}
+				lastTime = info.Time
+			}
+			switch info.Type {
+			case SearchInfoTypeCurrMove:
+				if info.CurrMove.IsNull() || info.CurrMoveNumber < 1 || info.Depth == 0 {
+					t.Errorf("unexpected currmove info: got=%+v", info)
+				}
+			case SearchInfoTypeProgress:
+				if info.Nodes == 0 || info.Time < infoProgressInterval {
+					t.Errorf("unexpected progress info: got=%+v", info)
+				}
+			case SearchInfoTypeIncomplete:
+				if len(info.PV) == 0 || info.CurrMoveNumber < 1 {
+					t.Errorf("unexpected incomplete info: got=%+v", info)
+				}
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// root moves are only reported if an iteration starts after the delay, which depends on the speed
+	for _, typ := range []SearchInfoType{SearchInfoTypeIteration, SearchInfoTypeProgress} {
+		if counts[typ] == 0 {
+			t.Errorf("info type not reported: %d", typ)
+		}
+	}
+}
+
+func TestPVLineMoves(t *testing.T) {
+	t.Parallel()
+	b, err := board.NewBoard(board.WithFEN("r3k3/1P6/8/8/4p3/8/3P4/R3K2R w KQq - 0 1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	bb := b.Clone()
+	var want []board.Move
+	for _, uci := range []string{"d2d4", "e4d3", "e1g1", "a8a1", "f1a1", "e8e7", "b7b8n"} {
+		mv, err := bb.NewMoveFromUCI(uci)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		bb.Apply(mv)
+		want = append(want, mv)
+	}
+	var pvl PVLine
+	for i := len(want) - 1; i >= 0; i-- {
+		pvl.Set(want[i], pvl)
+	}
+
+	got := pvl.Moves(b)
+	if len(got) != len(want) {
+		t.Fatalf("unexpected move count: got=%d want=%d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Equals(want[i]) {
+			t.Errorf("unexpected move %d: got=%+v want=%+v", i, got[i], want[i])
+		}
+	}
+	if mv := pvl.GetPV(b); !mv.Equals(want[0]) {
+		t.Errorf("unexpected pv move: got=%s want=%s", mv.UCI(), want[0].UCI())
+	}
+}
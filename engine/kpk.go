@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"math/bits"
+	"sync"
+
+	"github.com/daystram/gambit/position"
+)
+
+// KPK bitbase generated by retrograde analysis, based on https://github.com/official-stockfish/Stockfish.
+// Positions are normalized so that the strong side is White and the Pawn is on files A to D.
+
+const (
+	kpkMaxIndex = 2 * 24 * 64 * 64 // stm * pawnSquares * bksq * wksq
+
+	kpkResultInvalid uint8 = 0
+	kpkResultUnknown uint8 = 1 << 0
+	kpkResultDraw    uint8 = 1 << 1
+	kpkResultWin     uint8 = 1 << 2
+
+	kpkWhite = 0
+	kpkBlack = 1
+)
+
+var (
+	kpkOnce     sync.Once
+	kpkBitbase  [kpkMaxIndex / 32]uint32
+	kpkPosMasks [64]uint64
+)
+
+type kpkPosition struct {
+	stm    int
+	ksq    [2]position.Pos
+	psq    position.Pos
+	result uint8
+}
+
+// kpkIndex packs the position into an index of the bitbase. The Pawn rank is stored from Rank7 downwards.
+func kpkIndex(stm int, bksq, wksq, psq position.Pos) int {
+	return int(wksq) | int(bksq)<<6 | stm<<12 | int(psq.X())<<13 | int(position.Rank7-psq.Y())<<15
+}
+
+// probeKPK returns true if the strong side wins the King and Pawn versus King endgame with perfect play.
+// The squares must be normalized so that the strong side is White and the Pawn is on files A to D.
+func probeKPK(stmIsStrong bool, wksq, wpsq, bksq position.Pos) bool {
+	kpkOnce.Do(initKPK)
+	stm := kpkBlack
+	if stmIsStrong {
+		stm = kpkWhite
+	}
+	idx := kpkIndex(stm, bksq, wksq, wpsq)
+	return kpkBitbase[idx/32]&(1<<(idx&0x1F)) != 0
+}
+
+func initKPK() {
+	for pos := position.Pos(0); pos < 64; pos++ {
+		kpkPosMasks[pos] = 1 << pos
+	}
+
+	db := make([]kpkPosition, kpkMaxIndex)
+	for idx := range db {
+		db[idx] = newKPKPosition(idx)
+	}
+
+	// iterate through the positions until none of the unknown positions can be resolved
+	for repeat := true; repeat; {
+		repeat = false
+		for idx := range db {
+			if db[idx].result == kpkResultUnknown {
+				db[idx].result = db[idx].classify(db)
+				repeat = repeat || db[idx].result != kpkResultUnknown
+			}
+		}
+	}
+
+	for idx := range db {
+		if db[idx].result == kpkResultWin {
+			kpkBitbase[idx/32] |= 1 << (idx & 0x1F)
+		}
+	}
+}
+
+func newKPKPosition(idx int) kpkPosition {
+	p := kpkPosition{
+		stm: (idx >> 12) & 0x01,
+		ksq: [2]position.Pos{
+			kpkWhite: position.Pos(idx & 0x3F),
+			kpkBlack: position.Pos((idx >> 6) & 0x3F),
+		},
+		psq: position.Pos((idx>>13)&0x03) + (position.Rank7-position.Pos((idx>>15)&0x07))*8,
+	}
+	wksq, bksq, psq := p.ksq[kpkWhite], p.ksq[kpkBlack], p.psq
+
+	switch {
+	case distance(wksq, bksq) <= 1 || wksq == psq || bksq == psq ||
+		(p.stm == kpkWhite && kpkPawnAttacks(psq)&kpkPosMasks[bksq] != 0):
+		// invalid if the two Kings are adjacent, a King overlaps the Pawn, or Black is in check with White to move
+		p.result = kpkResultInvalid
+	case p.stm == kpkWhite && psq.Y() == position.Rank7 && wksq != psq+8 &&
+		(distance(bksq, psq+8) > 1 || distance(wksq, psq+8) == 1):
+		// win if the Pawn can promote without being captured
+		p.result = kpkResultWin
+	case p.stm == kpkBlack &&
+		(kpkKingAttacks(bksq)&^(kpkKingAttacks(wksq)|kpkPawnAttacks(psq)) == 0 ||
+			kpkKingAttacks(bksq)&^kpkKingAttacks(wksq)&kpkPosMasks[psq] != 0):
+		// draw if Black is stalemated or can capture the undefended Pawn
+		p.result = kpkResultDraw
+	default:
+		p.result = kpkResultUnknown
+	}
+	return p
+}
+
+// classify resolves the position from the results of the positions reachable from it.
+func (p *kpkPosition) classify(db []kpkPosition) uint8 {
+	good, bad := kpkResultWin, kpkResultDraw
+	if p.stm == kpkBlack {
+		good, bad = kpkResultDraw, kpkResultWin
+	}
+
+	r := kpkResultInvalid
+	for bm := kpkKingAttacks(p.ksq[p.stm]); bm != 0; bm &= bm - 1 {
+		to := position.Pos(bits.TrailingZeros64(bm))
+		if p.stm == kpkWhite {
+			r |= db[kpkIndex(kpkBlack, p.ksq[kpkBlack], to, p.psq)].result
+		} else {
+			r |= db[kpkIndex(kpkWhite, to, p.ksq[kpkWhite], p.psq)].result
+		}
+	}
+
+	if p.stm == kpkWhite {
+		if p.psq.Y() < position.Rank7 {
+			// single push
+			to := p.psq + 8
+			r |= db[kpkIndex(kpkBlack, p.ksq[kpkBlack], p.ksq[kpkWhite], to)].result
+
+			// double push
+			if p.psq.Y() == position.Rank2 && to != p.ksq[kpkWhite] && to != p.ksq[kpkBlack] {
+				r |= db[kpkIndex(kpkBlack, p.ksq[kpkBlack], p.ksq[kpkWhite], to+8)].result
+			}
+		}
+	}
+
+	if r&good != 0 {
+		return good
+	}
+	if r&kpkResultUnknown != 0 {
+		return kpkResultUnknown
+	}
+	return bad
+}
+
+func kpkKingAttacks(pos position.Pos) uint64 {
+	var bm uint64
+	for dy := position.Pos(-1); dy <= 1; dy++ {
+		for dx := position.Pos(-1); dx <= 1; dx++ {
+			x, y := pos.X()+dx, pos.Y()+dy
+			if (dx != 0 || dy != 0) && x >= 0 && x < 8 && y >= 0 && y < 8 {
+				bm |= kpkPosMasks[y*8+x]
+			}
+		}
+	}
+	return bm
+}
+
+// kpkPawnAttacks returns the cells attacked by a White Pawn.
+func kpkPawnAttacks(pos position.Pos) uint64 {
+	var bm uint64
+	if pos.Y() == position.Rank8 {
+		return 0
+	}
+	if pos.X() > position.FileA {
+		bm |= kpkPosMasks[pos+7]
+	}
+	if pos.X() < position.FileH {
+		bm |= kpkPosMasks[pos+9]
+	}
+	return bm
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/tablebase"
+)
+
+// syzygyPath holds the test tables of the tablebase package, including KRvK and KPvK.
+const syzygyPath = "../tablebase/testdata"
+
+func openTestSyzygy(t *testing.T) *tablebase.Syzygy {
+	t.Helper()
+	tb, err := tablebase.NewSyzygy(syzygyPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = tb.Close() })
+	return tb
+}
+
+func TestProbeWDL(t *testing.T) {
+	t.Parallel()
+	tb := openTestSyzygy(t)
+	tests := []struct {
+		name      string
+		fen       string
+		wantScore int16
+		wantOK    bool
+	}{
+		{name: "win", fen: "8/8/8/4k3/8/8/8/R3K3 w - - 0 1", wantScore: scoreTablebaseWin - 2, wantOK: true},
+		{name: "loss", fen: "8/8/8/4k3/8/8/8/R3K3 b - - 0 1", wantScore: -scoreTablebaseWin + 2, wantOK: true},
+		{name: "draw", fen: "k7/8/K7/P7/8/8/8/8 w - - 0 1", wantScore: 0, wantOK: true},
+		{name: "half move clock", fen: "8/8/8/4k3/8/8/8/R3K3 w - - 1 1", wantOK: false},
+		{name: "too many pieces", fen: board.DefaultStartingPositionFEN, wantOK: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			e := NewEngine(&EngineConfig{HashTableSize: 1, Tablebase: tb})
+			score, ok := e.probeWDL(b, 2)
+			if ok != tt.wantOK {
+				t.Fatalf("unexpected ok: got=%v want=%v", ok, tt.wantOK)
+			}
+			if ok && score != tt.wantScore {
+				t.Errorf("unexpected score: got=%d want=%d", score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestSearchTablebaseRoot(t *testing.T) {
+	t.Parallel()
+	tb := openTestSyzygy(t)
+	tests := []struct {
+		name    string
+		fen     string
+		wantWDL tablebase.WDL // of the position after the best move, for the opponent
+	}{
+		{name: "only drawing move", fen: "8/8/8/8/8/8/3k4/3R3K b - - 0 1", wantWDL: tablebase.WDLDraw},
+		{name: "winning pawn ending", fen: "8/3k4/8/4K3/4P3/8/8/8 w - - 0 1", wantWDL: tablebase.WDLLoss},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			e := NewEngine(&EngineConfig{HashTableSize: 1, Tablebase: tb})
+			if len(e.probeRoot(b)) == 0 {
+				t.Fatalf("unexpected root moves: got=0")
+			}
+			mv, err := e.Search(context.Background(), b, &SearchConfig{ClockConfig: ClockConfig{Depth: 3}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !e.isRootMove(mv) {
+				t.Errorf("unexpected move outside the root moves: %s", mv)
+			}
+			b.Apply(mv)
+			wdl, err := tb.ProbeWDL(b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if wdl != tt.wantWDL {
+				t.Errorf("unexpected WDL after %s: got=%s want=%s", mv, wdl, tt.wantWDL)
+			}
+		})
+	}
+}
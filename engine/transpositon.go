@@ -1,7 +1,6 @@
 package engine
 
 import (
-	"fmt"
 	"unsafe"
 
 	"github.com/daystram/gambit/board"
@@ -30,13 +29,12 @@ type entry struct {
 	hash  uint64
 	score int16
 	age   uint16
-	mv    board.Move
+	mv    board.Move16
 	typ   EntryType
 	depth uint8
 }
 
 func NewTranspositionTable(sizeMB uint32) *TranspositionTable {
-	fmt.Print("Initializing transposition table... ")
 	entrySize := uint32(unsafe.Sizeof(entry{}))
 	count := sizeMB * 1e6 / entrySize
 	tt := TranspositionTable{
@@ -44,11 +42,10 @@ func NewTranspositionTable(sizeMB uint32) *TranspositionTable {
 		count:    uint64(count),
 		disabled: sizeMB == 0,
 	}
-	fmt.Printf("Done (%.3fMB)\n", float64(count*entrySize)/1e6)
 	return &tt
 }
 
-func (t *TranspositionTable) Set(b *board.Board, age uint16, typ EntryType, mv board.Move, score int16, depth uint8) {
+func (t *TranspositionTable) Set(b *board.Board, age uint16, typ EntryType, mv board.Move16, score int16, depth uint8) {
 	if t.IsDisabled() {
 		return
 	}
@@ -68,19 +65,36 @@ func (t *TranspositionTable) Set(b *board.Board, age uint16, typ EntryType, mv b
 	}
 }
 
-func (t *TranspositionTable) Get(b *board.Board, age uint16) (EntryType, board.Move, int16, uint8, bool) {
+// Get returns the entry of the board. The move may be of another position sharing the hash, and is checked with
+// board.IsPseudoLegal before the search tries it ahead of the generated moves.
+func (t *TranspositionTable) Get(b *board.Board, age uint16) (EntryType, board.Move16, int16, uint8, bool) {
 	if t.IsDisabled() {
-		return 0, board.Move{}, 0, 0, false
+		return 0, 0, 0, 0, false
 	}
 	hash := b.Hash()
 	index := hash % t.count
 	e := t.table[index]
 	if t.disabled || e.typ == EntryTypeUnknown || e.age != age || e.hash != hash {
-		return EntryTypeUnknown, board.Move{}, 0, 0, false
+		return EntryTypeUnknown, 0, 0, 0, false
 	}
 	return e.typ, e.mv, e.score, e.depth, true
 }
 
+// HashFull returns the permille of the table used by the current age, sampled from the first entries.
+func (t *TranspositionTable) HashFull(age uint16) int {
+	samples := min(t.count, 1000)
+	if samples == 0 {
+		return 0
+	}
+	var used uint64
+	for _, e := range t.table[:samples] {
+		if e.typ != EntryTypeUnknown && e.age == age {
+			used++
+		}
+	}
+	return int(used * 1000 / samples)
+}
+
 func (t *TranspositionTable) IsDisabled() bool {
 	return t.count == 0
 }
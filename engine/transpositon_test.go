@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"unsafe"
+
+	"github.com/daystram/gambit/board"
+)
+
+func TestTranspositionTable(t *testing.T) {
+	t.Parallel()
+	if got, want := unsafe.Sizeof(entry{}), uintptr(16); got != want {
+		t.Errorf("unexpected entry size: got=%d want=%d", got, want)
+	}
+
+	tt := NewTranspositionTable(1)
+	b, err := board.NewBoard()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mv, err := b.NewMoveFromUCI("e2e4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, _, _, ok := tt.Get(b, 0); ok {
+		t.Errorf("unexpected entry: got=%v want=%v", ok, false)
+	}
+
+	tt.Set(b, 0, EntryTypeExact, mv.Move16(), 42, 5)
+	typ, got, score, depth, ok := tt.Get(b, 0)
+	if !ok {
+		t.Fatalf("unexpected entry: got=%v want=%v", ok, true)
+	}
+	if typ != EntryTypeExact || score != 42 || depth != 5 {
+		t.Errorf("unexpected entry: got=(%d, %d, %d) want=(%d, %d, %d)", typ, score, depth, EntryTypeExact, 42, 5)
+	}
+	if !b.IsPseudoLegal(got) || !b.NewMoveFromMove16(got).Equals(mv) {
+		t.Errorf("unexpected move: got=%s want=%s", got.UCI(), mv.UCI())
+	}
+	if _, _, _, _, ok := tt.Get(b, 1); ok {
+		t.Errorf("unexpected entry of another age: got=%v want=%v", ok, false)
+	}
+}
+
+func TestSearchInvalidHashMove(t *testing.T) {
+	t.Parallel()
+	search := func(t *testing.T, hashMove board.Move16) (board.Move, uint32) {
+		t.Helper()
+		b, err := board.NewBoard()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		e := NewEngine(&EngineConfig{HashTableSize: 1})
+		if !hashMove.IsNull() {
+			e.tt.Set(b, b.Ply(), EntryTypeLowerBound, hashMove, 0, 0)
+		}
+		var nodes uint32
+		mv, err := e.Search(context.Background(), b, &SearchConfig{
+			ClockConfig: ClockConfig{Depth: 3},
+			OnInfo: func(info *SearchInfo) {
+				nodes = info.Nodes
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return mv, nodes
+	}
+	wantMove, wantNodes := search(t, 0)
+
+	// moves of another position sharing the hash are not tried, and leave the search unchanged
+	tests := []struct {
+		name string
+		from string
+		mv   string
+	}{
+		{name: "opponent piece", from: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1", mv: "e7e5"},
+		{name: "blocked", from: "rnbqkbnr/pppppppp/8/8/8/P7/1PPPPPPP/RNBQKBNR w KQkq - 0 1", mv: "a1a2"},
+		{name: "empty cell", from: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 1", mv: "e4e5"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			from, err := board.NewBoard(board.WithFEN(tt.from))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			hashMove, err := from.NewMoveFromUCI(tt.mv)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			mv, nodes := search(t, hashMove.Move16())
+			if !mv.Equals(wantMove) || nodes != wantNodes {
+				t.Errorf("unexpected search: got=(%s, %d) want=(%s, %d)", mv.UCI(), nodes, wantMove.UCI(), wantNodes)
+			}
+		})
+	}
+}
+
+// TestNewEngineSilent is not parallel, as it replaces os.Stdout.
+func TestNewEngineSilent(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	NewEngine(&EngineConfig{HashTableSize: 1})
+	os.Stdout = stdout
+	_ = w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 0 {
+		t.Errorf("unexpected output: got=%q want=%q", out, "")
+	}
+}
@@ -0,0 +1,368 @@
+package epd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/daystram/gambit/board"
+)
+
+var (
+	ErrInvalidEPD = errors.New("invalid epd")
+)
+
+// Opcodes of the standard operations with typed operands. Other opcodes, e.g. c0 to c9, are kept as symbols
+// or strings.
+const (
+	OpcodeAnalysisCountDepth   = "acd"
+	OpcodeAnalysisCountNodes   = "acn"
+	OpcodeAnalysisCountSeconds = "acs"
+	OpcodeAvoidMove            = "am"
+	OpcodeBestMove             = "bm"
+	OpcodeCentipawnEvaluation  = "ce"
+	OpcodeComment              = "c0"
+	OpcodeDirectMate           = "dm"
+	OpcodeFullMoveNumber       = "fmvn"
+	OpcodeHalfMoveClock        = "hmvn"
+	OpcodeID                   = "id"
+	OpcodePredictedMove        = "pm"
+	OpcodePredictedVariation   = "pv"
+	OpcodeRepetitionCount      = "rc"
+	OpcodeSuppliedMove         = "sm"
+)
+
+var (
+	// moveOpcodes have moves of the position as operands, and sequenceOpcodes consecutive moves from it.
+	moveOpcodes = map[string]bool{
+		OpcodeAvoidMove:     true,
+		OpcodeBestMove:      true,
+		OpcodePredictedMove: true,
+		OpcodeSuppliedMove:  true,
+	}
+	sequenceOpcodes = map[string]bool{
+		OpcodePredictedVariation: true,
+	}
+	integerOpcodes = map[string]bool{
+		OpcodeAnalysisCountDepth:   true,
+		OpcodeAnalysisCountNodes:   true,
+		OpcodeAnalysisCountSeconds: true,
+		OpcodeCentipawnEvaluation:  true,
+		OpcodeDirectMate:           true,
+		OpcodeFullMoveNumber:       true,
+		OpcodeHalfMoveClock:        true,
+		OpcodeRepetitionCount:      true,
+	}
+)
+
+type OperandType uint8
+
+const (
+	OperandTypeSymbol OperandType = iota
+	OperandTypeString
+	OperandTypeInteger
+	OperandTypeMove
+)
+
+// Operand is an operand of an operation. Text is set for symbols and strings, Int for integers, and Move for
+// moves.
+type Operand struct {
+	Type OperandType
+	Text string
+	Int  int64
+	Move board.Move
+}
+
+// Position is an EPD record of a position and its operations, keyed by opcode. The move clocks of the board
+// are from the hmvn and fmvn operations if present, or from the optional FEN move clock fields.
+type Position struct {
+	Board *board.Board
+	Ops   map[string][]Operand
+}
+
+// Parse parses an EPD record, i.e. the first four FEN fields followed by the operations, each an opcode and
+// its operands terminated by a semicolon. Move operands are in SAN, and resolved against the position.
+func Parse(line string) (*Position, error) {
+	fields, rest := splitFields(line, 4)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("%w: missing fields", ErrInvalidEPD)
+	}
+	clocks := []string{"0", "1"}
+	if more, after := splitFields(rest, 2); len(more) == 2 && isNumber(more[0]) && isNumber(more[1]) {
+		clocks, rest = more, after // a full FEN
+	}
+	ops, err := parseOperations(rest)
+	if err != nil {
+		return nil, err
+	}
+	for i, opcode := range []string{OpcodeHalfMoveClock, OpcodeFullMoveNumber} {
+		if operands := ops[opcode]; len(operands) == 1 && isNumber(operands[0].text) {
+			clocks[i] = operands[0].text
+		}
+	}
+	b, err := board.NewBoard(board.WithFEN(strings.Join(append(fields, clocks...), " ")))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEPD, err)
+	}
+
+	p := &Position{
+		Board: b,
+		Ops:   make(map[string][]Operand, len(ops)),
+	}
+	for opcode, operands := range ops {
+		typed, err := p.resolve(opcode, operands)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidEPD, opcode, err)
+		}
+		p.Ops[opcode] = typed
+	}
+	return p, nil
+}
+
+// resolve types the operands by the opcode.
+func (p *Position) resolve(opcode string, operands []rawOperand) ([]Operand, error) {
+	typed := make([]Operand, 0, len(operands))
+	b := p.Board.Clone()
+	for _, operand := range operands {
+		switch {
+		case !operand.quoted && (moveOpcodes[opcode] || sequenceOpcodes[opcode]):
+			mv, err := b.NewMoveFromSAN(operand.text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid move: %s", operand.text)
+			}
+			if sequenceOpcodes[opcode] {
+				b.Apply(mv)
+			}
+			typed = append(typed, Operand{Type: OperandTypeMove, Move: mv})
+		case !operand.quoted && integerOpcodes[opcode]:
+			n, err := strconv.ParseInt(operand.text, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer: %s", operand.text)
+			}
+			typed = append(typed, Operand{Type: OperandTypeInteger, Int: n})
+		case operand.quoted:
+			typed = append(typed, Operand{Type: OperandTypeString, Text: operand.text})
+		default:
+			typed = append(typed, Operand{Type: OperandTypeSymbol, Text: operand.text})
+		}
+	}
+	return typed, nil
+}
+
+// Moves returns the move operands of the operation.
+func (p *Position) Moves(opcode string) []board.Move {
+	var mvs []board.Move
+	for _, operand := range p.Ops[opcode] {
+		if operand.Type == OperandTypeMove {
+			mvs = append(mvs, operand.Move)
+		}
+	}
+	return mvs
+}
+
+// Int returns the first operand of the operation, if it is an integer.
+func (p *Position) Int(opcode string) (int64, bool) {
+	operands := p.Ops[opcode]
+	if len(operands) == 0 || operands[0].Type != OperandTypeInteger {
+		return 0, false
+	}
+	return operands[0].Int, true
+}
+
+// Text returns the first operand of the operation, if it is a string or a symbol.
+func (p *Position) Text(opcode string) (string, bool) {
+	operands := p.Ops[opcode]
+	if len(operands) == 0 || (operands[0].Type != OperandTypeString && operands[0].Type != OperandTypeSymbol) {
+		return "", false
+	}
+	return operands[0].Text, true
+}
+
+// SetMoves sets the operation to the moves, which are consecutive for sequence opcodes such as pv.
+func (p *Position) SetMoves(opcode string, mvs ...board.Move) {
+	operands := make([]Operand, 0, len(mvs))
+	for _, mv := range mvs {
+		operands = append(operands, Operand{Type: OperandTypeMove, Move: mv})
+	}
+	p.set(opcode, operands)
+}
+
+// SetInt sets the operation to the integer.
+func (p *Position) SetInt(opcode string, n int64) {
+	p.set(opcode, []Operand{{Type: OperandTypeInteger, Int: n}})
+}
+
+// SetText sets the operation to the string.
+func (p *Position) SetText(opcode, s string) {
+	p.set(opcode, []Operand{{Type: OperandTypeString, Text: s}})
+}
+
+func (p *Position) set(opcode string, operands []Operand) {
+	if p.Ops == nil {
+		p.Ops = make(map[string][]Operand)
+	}
+	p.Ops[opcode] = operands
+}
+
+// String returns the EPD record, with the operations in opcode order and the moves in SAN.
+func (p *Position) String() string {
+	builder := strings.Builder{}
+	fields := strings.Fields(p.Board.FEN())
+	_, _ = builder.WriteString(strings.Join(fields[:4], " "))
+
+	opcodes := make([]string, 0, len(p.Ops))
+	for opcode := range p.Ops {
+		opcodes = append(opcodes, opcode)
+	}
+	sort.Strings(opcodes)
+	for _, opcode := range opcodes {
+		_, _ = builder.WriteString(" " + opcode)
+		b := p.Board.Clone()
+		for _, operand := range p.Ops[opcode] {
+			_, _ = builder.WriteRune(' ')
+			switch operand.Type {
+			case OperandTypeMove:
+				_, _ = builder.WriteString(b.SAN(operand.Move))
+				if sequenceOpcodes[opcode] {
+					b.Apply(operand.Move)
+				}
+			case OperandTypeInteger:
+				_, _ = builder.WriteString(strconv.FormatInt(operand.Int, 10))
+			case OperandTypeString:
+				_, _ = builder.WriteString(`"` + strings.ReplaceAll(operand.Text, `"`, `\"`) + `"`)
+			default:
+				_, _ = builder.WriteString(operand.Text)
+			}
+		}
+		_, _ = builder.WriteRune(';')
+	}
+	return builder.String()
+}
+
+// Write writes the EPD record as a line.
+func (p *Position) Write(w io.Writer) error {
+	_, err := io.WriteString(w, p.String()+"\n")
+	return err
+}
+
+type rawOperand struct {
+	text   string
+	quoted bool
+}
+
+// parseOperations parses the operations, where string operands are double quoted. The semicolon of the last
+// operation may be omitted.
+func parseOperations(s string) (map[string][]rawOperand, error) {
+	ops := make(map[string][]rawOperand)
+	var opcode string
+	var operands []rawOperand
+	var inOp bool
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == ';':
+			if !inOp {
+				return nil, fmt.Errorf("%w: missing opcode", ErrInvalidEPD)
+			}
+			ops[opcode], operands, inOp = operands, nil, false
+			i++
+		case c == '"':
+			if !inOp {
+				return nil, fmt.Errorf("%w: missing opcode", ErrInvalidEPD)
+			}
+			text, n, err := readString(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			operands = append(operands, rawOperand{text: text, quoted: true})
+			i += n
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\r\n;\"", rune(s[j])) {
+				j++
+			}
+			token := s[i:j]
+			if !inOp {
+				if !isOpcode(token) {
+					return nil, fmt.Errorf("%w: invalid opcode: %s", ErrInvalidEPD, token)
+				}
+				opcode, inOp = token, true
+			} else {
+				operands = append(operands, rawOperand{text: token})
+			}
+			i = j
+		}
+	}
+	if inOp {
+		ops[opcode] = operands
+	}
+	return ops, nil
+}
+
+// readString reads the double quoted string at the start of s, where \" and \\ are escaped, returning the
+// string and the number of bytes read.
+func readString(s string) (string, int, error) {
+	builder := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+				i++
+			}
+			_ = builder.WriteByte(s[i])
+		case '"':
+			return builder.String(), i + 1, nil
+		default:
+			_ = builder.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("%w: unterminated string", ErrInvalidEPD)
+}
+
+// splitFields returns the first n whitespace separated fields of s, and the remainder after them.
+func splitFields(s string, n int) ([]string, string) {
+	var fields []string
+	for len(fields) < n {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+		end := strings.IndexAny(s, " \t")
+		if end == -1 {
+			end = len(s)
+		}
+		fields = append(fields, s[:end])
+		s = s[end:]
+	}
+	return fields, s
+}
+
+// isOpcode returns true if the token is a letter followed by letters, digits, or underscores.
+func isOpcode(token string) bool {
+	for i, c := range token {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '_'):
+		default:
+			return false
+		}
+	}
+	return token != ""
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package epd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/daystram/gambit/board"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		epd       string
+		wantFEN   string
+		wantMoves map[string][]string
+		wantInts  map[string]int64
+		wantTexts map[string]string
+		wantErr   error
+	}{
+		{
+			name:      "WAC",
+			epd:       `2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";`,
+			wantFEN:   "2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - 0 1",
+			wantMoves: map[string][]string{OpcodeBestMove: {"g3g6"}},
+			wantTexts: map[string]string{OpcodeID: "WAC.001"},
+		},
+		{
+			name:      "alternative moves and analysis",
+			epd:       `rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - bm e4 d4; am f3; acd 12; ce -15; pv Nf3 d5 g3; c0 "quoted \"text\"; with semicolon";`,
+			wantFEN:   board.DefaultStartingPositionFEN,
+			wantMoves: map[string][]string{OpcodeBestMove: {"e2e4", "d2d4"}, OpcodeAvoidMove: {"f2f3"}, OpcodePredictedVariation: {"g1f3", "d7d5", "g2g3"}},
+			wantInts:  map[string]int64{OpcodeAnalysisCountDepth: 12, OpcodeCentipawnEvaluation: -15},
+			wantTexts: map[string]string{OpcodeComment: `quoted "text"; with semicolon`},
+		},
+		{
+			name:     "move clocks from operations",
+			epd:      "4k3/8/8/8/8/8/8/4K2R w K - hmvn 12; fmvn 40; noop;",
+			wantFEN:  "4k3/8/8/8/8/8/8/4K2R w K - 12 40",
+			wantInts: map[string]int64{OpcodeHalfMoveClock: 12, OpcodeFullMoveNumber: 40},
+		},
+		{
+			name:      "full FEN and missing last semicolon",
+			epd:       "4k3/8/8/8/8/8/8/4K2R w K - 3 20 bm O-O+",
+			wantFEN:   "4k3/8/8/8/8/8/8/4K2R w K - 3 20",
+			wantMoves: map[string][]string{OpcodeBestMove: {"e1g1"}},
+		},
+		{
+			name:    "missing fields",
+			epd:     "4k3/8/8/8/8/8/8/4K2R w K",
+			wantErr: ErrInvalidEPD,
+		},
+		{
+			name:    "illegal move",
+			epd:     "4k3/8/8/8/8/8/8/4K2R w K - bm Ra8;",
+			wantErr: ErrInvalidEPD,
+		},
+		{
+			name:    "invalid integer",
+			epd:     "4k3/8/8/8/8/8/8/4K2R w K - acd deep;",
+			wantErr: ErrInvalidEPD,
+		},
+		{
+			name:    "unterminated string",
+			epd:     `4k3/8/8/8/8/8/8/4K2R w K - id "open;`,
+			wantErr: ErrInvalidEPD,
+		},
+		{
+			name:    "invalid opcode",
+			epd:     "4k3/8/8/8/8/8/8/4K2R w K - ; bm Rh8;",
+			wantErr: ErrInvalidEPD,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := Parse(tt.epd)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("unexpected error: got=%v want=%v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := p.Board.FEN(); got != tt.wantFEN {
+				t.Errorf("unexpected fen: got=%s want=%s", got, tt.wantFEN)
+			}
+			for opcode, want := range tt.wantMoves {
+				var got []string
+				for _, mv := range p.Moves(opcode) {
+					got = append(got, mv.UCI())
+				}
+				if strings.Join(got, " ") != strings.Join(want, " ") {
+					t.Errorf("unexpected %s moves: got=%v want=%v", opcode, got, want)
+				}
+			}
+			for opcode, want := range tt.wantInts {
+				if got, ok := p.Int(opcode); !ok || got != want {
+					t.Errorf("unexpected %s: got=%d want=%d", opcode, got, want)
+				}
+			}
+			for opcode, want := range tt.wantTexts {
+				if got, ok := p.Text(opcode); !ok || got != want {
+					t.Errorf("unexpected %s: got=%q want=%q", opcode, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPositionString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		epd  string
+		want string
+	}{
+		{
+			name: "round trip in opcode order",
+			epd:  `rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - pv Nf3 d5 g3; id "start"; bm e4 d4; c0 "say \"hi\""; noop;`,
+			want: `rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - bm e4 d4; c0 "say \"hi\""; id "start"; noop; pv Nf3 d5 g3;`,
+		},
+		{
+			name: "check suffix",
+			epd:  "6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8;",
+			want: "6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8#;",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := Parse(tt.epd)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := p.String(); got != tt.want {
+				t.Errorf("unexpected epd: got=%s want=%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositionSet(t *testing.T) {
+	t.Parallel()
+	b, err := board.NewBoard()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e4, _ := b.NewMoveFromUCI("e2e4")
+	p := &Position{Board: b}
+	p.SetMoves(OpcodeBestMove, e4)
+	p.SetInt(OpcodeAnalysisCountDepth, 8)
+	p.SetText(OpcodeID, "result 1")
+	want := `rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - acd 8; bm e4; id "result 1";`
+	if got := p.String(); got != want {
+		t.Errorf("unexpected epd: got=%s want=%s", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package epd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Reader reads consecutive EPD records, one per line. Empty lines and lines starting with # are skipped.
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		scanner: bufio.NewScanner(r),
+	}
+}
+
+// Next reads the next record, returning io.EOF when there are no more records. Errors are prefixed by the line
+// number.
+func (r *Reader) Next() (*Position, error) {
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimSpace(r.scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		p, err := Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return p, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// ReadAll reads all the records.
+func ReadAll(r io.Reader) ([]*Position, error) {
+	var positions []*Position
+	reader := NewReader(r)
+	for {
+		p, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return positions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+}
+
+// Load reads all the records of the file.
+func Load(path string) ([]*Position, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAll(f)
+}
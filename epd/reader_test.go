@@ -0,0 +1,33 @@
+package epd
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+	r := NewReader(strings.NewReader(`# tactical positions
+2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";
+
+8/7p/5k2/5p2/p1p2P2/Pr1pPK2/1P1R3P/8 b - - bm Rxb2; id "WAC.002";
+4k3/8/8/8/8/8/8/4K2R w K - bm Ra8;
+`))
+	for _, wantID := range []string{"WAC.001", "WAC.002"} {
+		p, err := r.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, _ := p.Text(OpcodeID); got != wantID {
+			t.Errorf("unexpected id: got=%s want=%s", got, wantID)
+		}
+	}
+	if _, err := r.Next(); !errors.Is(err, ErrInvalidEPD) || !strings.HasPrefix(err.Error(), "line 5:") {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrInvalidEPD)
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("unexpected error: got=%v want=%v", err, io.EOF)
+	}
+}
@@ -0,0 +1,444 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/pgn"
+)
+
+var (
+	ErrIllegalMove = errors.New("illegal move")
+	ErrGameOver    = errors.New("game over")
+	ErrNoDrawClaim = errors.New("no draw to claim")
+)
+
+// Termination is the reason a game ended.
+type Termination string
+
+const (
+	TerminationCheckmate            Termination = "checkmate"
+	TerminationStalemate            Termination = "stalemate"
+	TerminationFiftyMove            Termination = "fifty move rule"
+	TerminationSeventyFiveMove      Termination = "seventy-five move rule"
+	TerminationRepetition           Termination = "threefold repetition"
+	TerminationFivefoldRepetition   Termination = "fivefold repetition"
+	TerminationInsufficientMaterial Termination = "insufficient material"
+	TerminationTimeForfeit          Termination = "time forfeit"
+	TerminationResignation          Termination = "resignation"
+	TerminationAdjudication         Termination = "adjudication"
+)
+
+const (
+	// claimable draws, which end the game automatically once the automatic limits are reached
+	claimRepetitions, automaticRepetitions = 3, 5
+	claimHalfMoves, automaticHalfMoves     = 100, 150
+)
+
+// TimeControl is the game clock of each side, where the increment is added after each move. The game is
+// untimed if Time is zero.
+type TimeControl struct {
+	Time      time.Duration
+	Increment time.Duration
+
+	// Margin is the tolerated overrun of the clock before a time forfeit, e.g. for the latency of engines.
+	Margin time.Duration
+}
+
+// Game is a game from a starting position, with the moves played, the clocks, and the result once it has
+// ended. Checkmate, stalemate, insufficient material, fivefold repetition, and the seventy-five move rule end
+// the game on the move, while threefold repetition and the fifty move rule must be claimed with ClaimDraw.
+type Game struct {
+	start *board.Board
+	board *board.Board
+	plies []ply
+	redo  []ply // undone plies, the last undone at the end
+
+	timeControl TimeControl
+	now         func() time.Time
+	remaining   [3]time.Duration // by side
+	turnStart   time.Time
+
+	result      pgn.Result
+	termination Termination
+}
+
+type ply struct {
+	mv        board.Move
+	unApply   board.UnApplyFunc
+	hash      uint64
+	remaining [2][3]time.Duration // clocks before and after the move
+}
+
+type gameConfig struct {
+	fen         string
+	timeControl TimeControl
+	now         func() time.Time
+}
+
+type GameOption func(*gameConfig)
+
+// WithFEN sets the starting position, defaulting to board.DefaultStartingPositionFEN.
+func WithFEN(fen string) GameOption {
+	return func(cfg *gameConfig) {
+		cfg.fen = fen
+	}
+}
+
+func WithTimeControl(tc TimeControl) GameOption {
+	return func(cfg *gameConfig) {
+		cfg.timeControl = tc
+	}
+}
+
+// WithNow sets the wall clock the game clocks run by, defaulting to time.Now.
+func WithNow(now func() time.Time) GameOption {
+	return func(cfg *gameConfig) {
+		cfg.now = now
+	}
+}
+
+// NewGame starts a game, starting the clock of the side to move if timed.
+func NewGame(opts ...GameOption) (*Game, error) {
+	cfg := &gameConfig{
+		fen: board.DefaultStartingPositionFEN,
+		now: time.Now,
+	}
+	for _, f := range opts {
+		f(cfg)
+	}
+
+	start, err := board.NewBoard(board.WithFEN(cfg.fen))
+	if err != nil {
+		return nil, err
+	}
+	g := &Game{
+		start:       start,
+		board:       start.Clone(),
+		timeControl: cfg.timeControl,
+		now:         cfg.now,
+		turnStart:   cfg.now(),
+		result:      pgn.ResultUnknown,
+	}
+	g.remaining[board.SideWhite], g.remaining[board.SideBlack] = cfg.timeControl.Time, cfg.timeControl.Time
+	g.adjudicate()
+	return g, nil
+}
+
+// Board returns a copy of the current position.
+func (g *Game) Board() *board.Board {
+	return g.board.Clone()
+}
+
+// StartBoard returns a copy of the starting position.
+func (g *Game) StartBoard() *board.Board {
+	return g.start.Clone()
+}
+
+// Moves returns the moves played from the starting position.
+func (g *Game) Moves() []board.Move {
+	mvs := make([]board.Move, 0, len(g.plies))
+	for _, p := range g.plies {
+		mvs = append(mvs, p.mv)
+	}
+	return mvs
+}
+
+func (g *Game) FEN() string {
+	return g.board.FEN()
+}
+
+func (g *Game) Turn() board.Side {
+	return g.board.Turn()
+}
+
+// LegalMoves returns the legal moves of the current position, none once the game has ended.
+func (g *Game) LegalMoves() []board.Move {
+	if g.IsOver() {
+		return nil
+	}
+	return g.board.GenerateLegalMoves(nil)
+}
+
+// Move plays the move of the side to move, charging its clock with the time since the previous move. Moving
+// after the clock has run out ends the game by time forfeit instead. The undone moves can no longer be redone.
+func (g *Game) Move(mv board.Move) error {
+	if g.IsOver() {
+		return ErrGameOver
+	}
+	legal, ok := g.findLegal(mv)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrIllegalMove, mv.UCI())
+	}
+	if g.CheckTime() {
+		return ErrGameOver
+	}
+
+	p := ply{mv: legal}
+	p.remaining[0] = g.remaining
+	if g.isTimed() {
+		turn := g.board.Turn()
+		g.remaining[turn] += g.timeControl.Increment - g.now().Sub(g.turnStart)
+	}
+	p.remaining[1] = g.remaining
+	p.unApply, _ = g.board.Apply(legal)
+	p.hash = g.board.Hash()
+	g.plies = append(g.plies, p)
+	g.redo = g.redo[:0]
+	g.turnStart = g.now()
+	g.adjudicate()
+	return nil
+}
+
+// MoveUCI plays the move in UCI notation.
+func (g *Game) MoveUCI(notation string) error {
+	mv, err := g.board.NewMoveFromUCI(notation)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrIllegalMove, notation)
+	}
+	return g.Move(mv)
+}
+
+// MoveSAN plays the move in SAN.
+func (g *Game) MoveSAN(san string) error {
+	mv, err := g.board.NewMoveFromSAN(san)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrIllegalMove, san)
+	}
+	return g.Move(mv)
+}
+
+// Undo takes back the last move, restoring the clocks to before it, and resuming the game if it had ended.
+// Returns false if no move has been played.
+func (g *Game) Undo() bool {
+	if len(g.plies) == 0 {
+		return false
+	}
+	p := g.plies[len(g.plies)-1]
+	g.plies = g.plies[:len(g.plies)-1]
+	p.unApply()
+	g.remaining = p.remaining[0]
+	g.redo = append(g.redo, p)
+	g.turnStart = g.now()
+	g.result, g.termination = pgn.ResultUnknown, ""
+	return true
+}
+
+// Redo plays the last undone move again, restoring the clocks to after it. Returns false if no move has been
+// undone since the last move.
+func (g *Game) Redo() bool {
+	if len(g.redo) == 0 {
+		return false
+	}
+	p := g.redo[len(g.redo)-1]
+	g.redo = g.redo[:len(g.redo)-1]
+	p.unApply, _ = g.board.Apply(p.mv)
+	g.remaining = p.remaining[1]
+	g.plies = append(g.plies, p)
+	g.turnStart = g.now()
+	g.result, g.termination = pgn.ResultUnknown, ""
+	g.adjudicate()
+	return true
+}
+
+// Remaining returns the time left on the clock of the side, including the running time of the side to move,
+// negative if overrun within the margin, or zero if the game is untimed.
+func (g *Game) Remaining(s board.Side) time.Duration {
+	remaining := g.remaining[s]
+	if g.isTimed() && s == g.board.Turn() && !g.IsOver() {
+		remaining -= g.now().Sub(g.turnStart)
+	}
+	return remaining
+}
+
+// CheckTime ends the game by time forfeit if the clock of the side to move has run out past the margin,
+// returning true if so.
+// The game is drawn instead if the opponent cannot checkmate.
+func (g *Game) CheckTime() bool {
+	if !g.isTimed() || g.IsOver() || g.Remaining(g.board.Turn()) >= -g.timeControl.Margin {
+		return false
+	}
+	turn := g.board.Turn()
+	g.remaining[turn] -= g.now().Sub(g.turnStart)
+	if !canCheckmate(g.board, turn.Opposite()) {
+		g.end(pgn.ResultDraw, TerminationTimeForfeit)
+		return true
+	}
+	g.end(lossOf(turn), TerminationTimeForfeit)
+	return true
+}
+
+// Resign ends the game as a loss of the side.
+func (g *Game) Resign(s board.Side) error {
+	if g.IsOver() {
+		return ErrGameOver
+	}
+	g.end(lossOf(s), TerminationResignation)
+	return nil
+}
+
+// Adjudicate ends the game with the result, e.g. by a tournament director or a match runner.
+func (g *Game) Adjudicate(result pgn.Result) error {
+	if g.IsOver() {
+		return ErrGameOver
+	}
+	if _, ok := result.Score(); !ok {
+		return fmt.Errorf("invalid result: %s", result)
+	}
+	g.end(result, TerminationAdjudication)
+	return nil
+}
+
+// ClaimableDraw returns the draw the side to move may claim, by threefold repetition or the fifty move rule.
+func (g *Game) ClaimableDraw() (Termination, bool) {
+	switch {
+	case g.IsOver():
+		return "", false
+	case g.Repetitions() >= claimRepetitions:
+		return TerminationRepetition, true
+	case g.board.HalfMoveClock() >= claimHalfMoves:
+		return TerminationFiftyMove, true
+	default:
+		return "", false
+	}
+}
+
+// ClaimDraw ends the game as a draw if one may be claimed.
+func (g *Game) ClaimDraw() error {
+	termination, ok := g.ClaimableDraw()
+	if !ok {
+		return ErrNoDrawClaim
+	}
+	g.end(pgn.ResultDraw, termination)
+	return nil
+}
+
+// Repetitions returns the number of times the current position has occurred, including now.
+func (g *Game) Repetitions() int {
+	hash, count := g.board.Hash(), 1
+	// only positions since the last capture or Pawn move may repeat
+	for i := len(g.plies) - 1; i >= 0 && i >= len(g.plies)-int(g.board.HalfMoveClock()); i-- {
+		if g.hashAt(i) == hash {
+			count++
+		}
+	}
+	return count
+}
+
+func (g *Game) Result() pgn.Result {
+	return g.result
+}
+
+// Termination returns the reason the game ended, empty if it has not.
+func (g *Game) Termination() Termination {
+	return g.termination
+}
+
+func (g *Game) IsOver() bool {
+	return g.result != pgn.ResultUnknown
+}
+
+// PGN returns the game record, with the FEN and Termination tags set if needed.
+func (g *Game) PGN() *pgn.Game {
+	record := &pgn.Game{
+		Tags:   map[string]string{},
+		Moves:  g.Moves(),
+		Result: g.result,
+	}
+	if fen := g.start.FEN(); fen != board.DefaultStartingPositionFEN {
+		record.Tags["SetUp"], record.Tags["FEN"] = "1", fen
+	}
+	if g.IsOver() {
+		record.Tags["Termination"] = string(g.termination)
+	}
+	if g.isTimed() {
+		record.Tags["TimeControl"] = fmt.Sprintf("%g+%g", g.timeControl.Time.Seconds(), g.timeControl.Increment.Seconds())
+	}
+	return record
+}
+
+// adjudicate ends the game if the position ends it automatically.
+func (g *Game) adjudicate() {
+	b := g.board
+	switch {
+	case !b.HasLegalMoves():
+		if b.IsKingChecked(b.Turn()) {
+			g.end(lossOf(b.Turn()), TerminationCheckmate)
+		} else {
+			g.end(pgn.ResultDraw, TerminationStalemate)
+		}
+	case IsInsufficientMaterial(b):
+		g.end(pgn.ResultDraw, TerminationInsufficientMaterial)
+	case g.Repetitions() >= automaticRepetitions:
+		g.end(pgn.ResultDraw, TerminationFivefoldRepetition)
+	case b.HalfMoveClock() >= automaticHalfMoves:
+		g.end(pgn.ResultDraw, TerminationSeventyFiveMove)
+	}
+}
+
+func (g *Game) end(result pgn.Result, termination Termination) {
+	g.result, g.termination = result, termination
+}
+
+func (g *Game) findLegal(mv board.Move) (board.Move, bool) {
+	packed := mv.Move16()
+	for _, legal := range g.board.GenerateLegalMoves(nil) {
+		if legal.Move16() == packed {
+			return legal, true
+		}
+	}
+	return board.Move{}, false
+}
+
+// hashAt returns the hash of the position after the number of plies.
+func (g *Game) hashAt(plies int) uint64 {
+	if plies == 0 {
+		return g.start.Hash()
+	}
+	return g.plies[plies-1].hash
+}
+
+func (g *Game) isTimed() bool {
+	return g.timeControl.Time != 0
+}
+
+// IsInsufficientMaterial returns true if no checkmate is possible, i.e. only a single minor piece is left.
+func IsInsufficientMaterial(b *board.Board) bool {
+	var minors uint8
+	for _, s := range []board.Side{board.SideWhite, board.SideBlack} {
+		for _, p := range []board.Piece{board.PiecePawn, board.PieceRook, board.PieceQueen} {
+			if b.GetBitmap(s, p) != 0 {
+				return false
+			}
+		}
+		minors += b.GetBitmap(s, board.PieceBishop).BitCount() + b.GetBitmap(s, board.PieceKnight).BitCount()
+	}
+	return minors <= 1
+}
+
+// canCheckmate returns false if the side has only its King, or a single minor piece besides it while the
+// opponent has only its King. Any other opponent piece may block its King and allow a checkmate.
+func canCheckmate(b *board.Board, s board.Side) bool {
+	for _, p := range []board.Piece{board.PiecePawn, board.PieceRook, board.PieceQueen} {
+		if b.GetBitmap(s, p) != 0 {
+			return true
+		}
+	}
+	switch (b.GetBitmap(s, board.PieceBishop) | b.GetBitmap(s, board.PieceKnight)).BitCount() {
+	case 0:
+		return false
+	case 1:
+		return b.GetSideBitmap(s.Opposite())&^b.GetBitmap(s.Opposite(), board.PieceKing) != 0
+	default:
+		return true
+	}
+}
+
+func lossOf(s board.Side) pgn.Result {
+	if s == board.SideWhite {
+		return pgn.ResultBlackWins
+	}
+	return pgn.ResultWhiteWins
+}
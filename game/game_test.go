@@ -0,0 +1,376 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/pgn"
+)
+
+// fakeClock is a wall clock advanced by hand.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestGameResult(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		fen             string
+		moves           []string
+		wantResult      pgn.Result
+		wantTermination Termination
+		wantClaim       Termination
+	}{
+		{
+			name:            "checkmate",
+			moves:           []string{"f3", "e5", "g4", "Qh4#"},
+			wantResult:      pgn.ResultBlackWins,
+			wantTermination: TerminationCheckmate,
+		},
+		{
+			name:            "stalemate",
+			fen:             "7k/8/6Q1/8/8/8/8/K7 w - - 0 1",
+			moves:           []string{"Qf7"},
+			wantResult:      pgn.ResultDraw,
+			wantTermination: TerminationStalemate,
+		},
+		{
+			name:            "insufficient material",
+			fen:             "7k/8/8/8/8/8/5r2/K5B1 w - - 0 1",
+			moves:           []string{"Bxf2"},
+			wantResult:      pgn.ResultDraw,
+			wantTermination: TerminationInsufficientMaterial,
+		},
+		{
+			name:       "threefold repetition claimable",
+			moves:      []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8"},
+			wantResult: pgn.ResultUnknown,
+			wantClaim:  TerminationRepetition,
+		},
+		{
+			name: "fivefold repetition",
+			moves: []string{
+				"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8",
+				"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8",
+			},
+			wantResult:      pgn.ResultDraw,
+			wantTermination: TerminationFivefoldRepetition,
+		},
+		{
+			name:       "fifty move rule claimable",
+			fen:        "7k/8/8/8/8/8/8/KR6 w - - 99 80",
+			moves:      []string{"Rb2"},
+			wantResult: pgn.ResultUnknown,
+			wantClaim:  TerminationFiftyMove,
+		},
+		{
+			name:            "seventy five move rule",
+			fen:             "7k/8/8/8/8/8/8/KR6 w - - 149 105",
+			moves:           []string{"Rb2"},
+			wantResult:      pgn.ResultDraw,
+			wantTermination: TerminationSeventyFiveMove,
+		},
+		{
+			name:       "running",
+			moves:      []string{"e4", "e5"},
+			wantResult: pgn.ResultUnknown,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts := []GameOption{}
+			if tt.fen != "" {
+				opts = append(opts, WithFEN(tt.fen))
+			}
+			g, err := NewGame(opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, san := range tt.moves {
+				if err := g.MoveSAN(san); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if g.Result() != tt.wantResult {
+				t.Errorf("unexpected result: got=%s want=%s", g.Result(), tt.wantResult)
+			}
+			if g.Termination() != tt.wantTermination {
+				t.Errorf("unexpected termination: got=%s want=%s", g.Termination(), tt.wantTermination)
+			}
+			claim, ok := g.ClaimableDraw()
+			if claim != tt.wantClaim || ok != (tt.wantClaim != "") {
+				t.Errorf("unexpected claimable draw: got=%s want=%s", claim, tt.wantClaim)
+			}
+			if ok {
+				if err := g.ClaimDraw(); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if g.Result() != pgn.ResultDraw || g.Termination() != tt.wantClaim {
+					t.Errorf("unexpected claimed result: got=%s %s want=%s %s", g.Result(), g.Termination(), pgn.ResultDraw, tt.wantClaim)
+				}
+			}
+		})
+	}
+}
+
+func TestGameMove(t *testing.T) {
+	t.Parallel()
+	g, err := NewGame()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := g.MoveUCI("e2e5"); !errors.Is(err, ErrIllegalMove) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrIllegalMove)
+	}
+	if err := g.MoveSAN("Ke2"); !errors.Is(err, ErrIllegalMove) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrIllegalMove)
+	}
+	if err := g.ClaimDraw(); !errors.Is(err, ErrNoDrawClaim) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrNoDrawClaim)
+	}
+	if err := g.Resign(board.SideWhite); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Result() != pgn.ResultBlackWins || g.Termination() != TerminationResignation {
+		t.Errorf("unexpected result: got=%s %s want=%s %s", g.Result(), g.Termination(), pgn.ResultBlackWins, TerminationResignation)
+	}
+	if err := g.MoveUCI("e2e4"); !errors.Is(err, ErrGameOver) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrGameOver)
+	}
+	if err := g.Adjudicate(pgn.ResultDraw); !errors.Is(err, ErrGameOver) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrGameOver)
+	}
+	if len(g.LegalMoves()) != 0 {
+		t.Errorf("unexpected legal moves: got=%d want=0", len(g.LegalMoves()))
+	}
+
+	g, _ = NewGame()
+	if err := g.Adjudicate(pgn.ResultUnknown); err == nil {
+		t.Errorf("error expected: got=nil")
+	}
+	if err := g.Adjudicate(pgn.ResultWhiteWins); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Result() != pgn.ResultWhiteWins || g.Termination() != TerminationAdjudication {
+		t.Errorf("unexpected result: got=%s %s want=%s %s", g.Result(), g.Termination(), pgn.ResultWhiteWins, TerminationAdjudication)
+	}
+}
+
+func TestGameUndoRedo(t *testing.T) {
+	t.Parallel()
+	g, err := NewGame()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Undo() || g.Redo() {
+		t.Fatalf("unexpected undo or redo on a new game")
+	}
+	fens := []string{g.FEN()}
+	for _, san := range []string{"f3", "e5", "g4", "Qh4#"} {
+		if err := g.MoveSAN(san); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fens = append(fens, g.FEN())
+	}
+	if !g.IsOver() {
+		t.Fatalf("unexpected running game")
+	}
+
+	for i := len(fens) - 2; i >= 0; i-- {
+		if !g.Undo() {
+			t.Fatalf("unexpected failed undo")
+		}
+		if g.FEN() != fens[i] {
+			t.Errorf("unexpected fen: got=%s want=%s", g.FEN(), fens[i])
+		}
+		if g.IsOver() {
+			t.Errorf("unexpected result: got=%s want=%s", g.Result(), pgn.ResultUnknown)
+		}
+	}
+	for i := 1; i < len(fens); i++ {
+		if !g.Redo() {
+			t.Fatalf("unexpected failed redo")
+		}
+		if g.FEN() != fens[i] {
+			t.Errorf("unexpected fen: got=%s want=%s", g.FEN(), fens[i])
+		}
+	}
+	if g.Result() != pgn.ResultBlackWins || g.Termination() != TerminationCheckmate {
+		t.Errorf("unexpected result: got=%s %s want=%s %s", g.Result(), g.Termination(), pgn.ResultBlackWins, TerminationCheckmate)
+	}
+
+	g.Undo()
+	if err := g.MoveSAN("Nc6"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Redo() {
+		t.Errorf("unexpected redo after a new move")
+	}
+	if len(g.Moves()) != 4 {
+		t.Errorf("unexpected moves: got=%d want=4", len(g.Moves()))
+	}
+}
+
+func TestGameClock(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	g, err := NewGame(
+		WithTimeControl(TimeControl{Time: time.Minute, Increment: time.Second}),
+		WithNow(clock.now),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.advance(10 * time.Second)
+	if got, want := g.Remaining(board.SideWhite), 50*time.Second; got != want {
+		t.Errorf("unexpected white remaining: got=%v want=%v", got, want)
+	}
+	if err := g.MoveUCI("e2e4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := g.Remaining(board.SideWhite), 51*time.Second; got != want {
+		t.Errorf("unexpected white remaining: got=%v want=%v", got, want)
+	}
+	clock.advance(20 * time.Second)
+	if err := g.MoveUCI("e7e5"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := g.Remaining(board.SideBlack), 41*time.Second; got != want {
+		t.Errorf("unexpected black remaining: got=%v want=%v", got, want)
+	}
+
+	clock.advance(time.Hour)
+	g.Undo()
+	if got, want := g.Remaining(board.SideBlack), time.Minute; got != want {
+		t.Errorf("unexpected black remaining after undo: got=%v want=%v", got, want)
+	}
+	g.Redo()
+	if got, want := g.Remaining(board.SideBlack), 41*time.Second; got != want {
+		t.Errorf("unexpected black remaining after redo: got=%v want=%v", got, want)
+	}
+
+	clock.advance(time.Minute)
+	if err := g.MoveUCI("d2d4"); !errors.Is(err, ErrGameOver) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrGameOver)
+	}
+	if g.Result() != pgn.ResultBlackWins || g.Termination() != TerminationTimeForfeit {
+		t.Errorf("unexpected result: got=%s %s want=%s %s", g.Result(), g.Termination(), pgn.ResultBlackWins, TerminationTimeForfeit)
+	}
+}
+
+func TestGameTimeForfeit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		fen        string
+		wantResult pgn.Result
+	}{
+		{name: "opponent with rook", fen: "7k/8/8/8/8/8/8/Kr6 w - - 0 1", wantResult: pgn.ResultBlackWins},
+		{name: "opponent with lone king", fen: "7k/8/8/8/8/8/8/KR6 w - - 0 1", wantResult: pgn.ResultDraw},
+		{name: "opponent with knight against bishop", fen: "7k/8/8/8/8/8/B7/Kn6 w - - 0 1", wantResult: pgn.ResultBlackWins},
+		{name: "opponent with knight against pawn", fen: "7k/8/8/8/8/8/P7/Kn6 w - - 0 1", wantResult: pgn.ResultBlackWins},
+		{name: "opponent with two knights", fen: "7k/8/8/8/8/8/8/Knn5 w - - 0 1", wantResult: pgn.ResultBlackWins},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			clock := &fakeClock{t: time.Unix(0, 0)}
+			g, err := NewGame(
+				WithFEN(tt.fen),
+				WithTimeControl(TimeControl{Time: time.Second}),
+				WithNow(clock.now),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if g.CheckTime() {
+				t.Fatalf("unexpected clock check: got=true want=false")
+			}
+			clock.advance(2 * time.Second)
+			if !g.CheckTime() {
+				t.Fatalf("unexpected clock check: got=false want=true")
+			}
+			if g.Result() != tt.wantResult || g.Termination() != TerminationTimeForfeit {
+				t.Errorf("unexpected result: got=%s %s want=%s %s", g.Result(), g.Termination(), tt.wantResult, TerminationTimeForfeit)
+			}
+		})
+	}
+}
+
+func TestGameTimeMargin(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	g, err := NewGame(
+		WithTimeControl(TimeControl{Time: time.Second, Margin: 100 * time.Millisecond}),
+		WithNow(clock.now),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clock.advance(time.Second + 50*time.Millisecond)
+	if err := g.MoveUCI("e2e4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := g.Remaining(board.SideWhite), -50*time.Millisecond; got != want {
+		t.Errorf("unexpected white remaining: got=%v want=%v", got, want)
+	}
+	clock.advance(time.Second + 150*time.Millisecond)
+	if err := g.MoveUCI("e7e5"); !errors.Is(err, ErrGameOver) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrGameOver)
+	}
+	if g.Result() != pgn.ResultWhiteWins || g.Termination() != TerminationTimeForfeit {
+		t.Errorf("unexpected result: got=%s %s want=%s %s", g.Result(), g.Termination(), pgn.ResultWhiteWins, TerminationTimeForfeit)
+	}
+}
+
+func TestGamePGN(t *testing.T) {
+	t.Parallel()
+	fen := "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3"
+	g, err := NewGame(WithFEN(fen))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, san := range []string{"Bc4", "Nd4", "Nxe5", "Qg5", "Nxf7", "Qxg2", "Rf1", "Qxe4+", "Be2", "Nf3#"} {
+		if err := g.MoveSAN(san); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := g.PGN().Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := pgn.NewReader(&buf).Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Result != pgn.ResultBlackWins {
+		t.Errorf("unexpected result: got=%s want=%s", got.Result, pgn.ResultBlackWins)
+	}
+	if got.Tags["FEN"] != fen || got.Tags["SetUp"] != "1" {
+		t.Errorf("unexpected setup tags: got=%v", got.Tags)
+	}
+	if got.Tags["Termination"] != string(TerminationCheckmate) {
+		t.Errorf("unexpected termination tag: got=%s want=%s", got.Tags["Termination"], TerminationCheckmate)
+	}
+	if len(got.Moves) != len(g.Moves()) {
+		t.Fatalf("unexpected moves: got=%d want=%d", len(got.Moves), len(g.Moves()))
+	}
+	for i, mv := range g.Moves() {
+		if got.Moves[i].UCI() != mv.UCI() {
+			t.Errorf("unexpected move %d: got=%s want=%s", i, got.Moves[i].UCI(), mv.UCI())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package sessiontest
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const timeout = 30 * time.Second
+
+// Session drives a protocol interface over pipes, reading its output line by line.
+type Session struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	lines  chan string
+	doneCh chan error
+}
+
+// New starts run with the commands to read and the writer of its output. run must return on quit or once
+// the commands are closed.
+func New(t *testing.T, run func(r io.Reader, w io.Writer) error) *Session {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s := &Session{
+		t:      t,
+		in:     inW,
+		lines:  make(chan string, 1024),
+		doneCh: make(chan error, 1),
+	}
+	go func() {
+		defer close(s.lines)
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+	}()
+	go func() {
+		err := run(inR, outW)
+		_ = inR.Close() // unblocks writes after quit
+		_ = outW.Close()
+		s.doneCh <- err
+	}()
+	return s
+}
+
+// Send writes the command, failing the test if the interface has exited.
+func (s *Session) Send(command string) {
+	s.t.Helper()
+	if err := s.TrySend(command); err != nil {
+		s.t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TrySend writes the command, returning an error if the interface has exited.
+func (s *Session) TrySend(command string) error {
+	_, err := io.WriteString(s.in, command+"\n")
+	return err
+}
+
+// Expect reads the lines until one starting with each of the prefixes is found, in order.
+func (s *Session) Expect(prefixes ...string) []string {
+	s.t.Helper()
+	var got []string
+	timeout := time.After(timeout)
+	for _, prefix := range prefixes {
+		for found := false; !found; {
+			select {
+			case line, ok := <-s.lines:
+				if !ok {
+					s.t.Fatalf("unexpected end of output: want=%q", prefix)
+				}
+				got = append(got, line)
+				found = strings.HasPrefix(line, prefix)
+			case <-timeout:
+				s.t.Fatalf("timed out waiting for output: want=%q got=%q", prefix, got)
+			}
+		}
+	}
+	return got
+}
+
+// Close closes the commands and waits for the interface to exit, returning its error.
+func (s *Session) Close() error {
+	s.t.Helper()
+	_ = s.in.Close()
+	select {
+	case err := <-s.doneCh:
+		return err
+	case <-time.After(timeout):
+		s.t.Fatalf("timed out waiting for interface to exit")
+		return nil
+	}
+}
+
+// CountPrefix returns the number of lines starting with the prefix.
+func CountPrefix(lines []string, prefix string) int {
+	var n int
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,282 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/game"
+	"github.com/daystram/gambit/pgn"
+)
+
+// Terminations of match games besides those of game.Game, whose rules end the other games.
+const (
+	TerminationMaxPlies    game.Termination = "max plies"
+	TerminationIllegalMove game.Termination = "illegal move"
+	TerminationError       game.Termination = "error"
+)
+
+// TimeControl is the game clock of each side. Without a game clock, each move is limited by the movetime
+// or depth instead.
+type TimeControl struct {
+	Time      time.Duration
+	Increment time.Duration
+	Movetime  time.Duration
+	Depth     uint8
+}
+
+// Config configures a match between players A and B.
+type Config struct {
+	PlayerA, PlayerB PlayerFactory
+
+	// Openings are the starting positions in FEN. Each opening is played twice, with colors swapped. The
+	// default starting position is used if empty.
+	Openings []string
+
+	// Rounds is the number of times the opening suite is played.
+	Rounds int
+
+	TimeControl TimeControl
+
+	// TimeMargin is the tolerated overrun of the clock before a time forfeit.
+	TimeMargin time.Duration
+
+	// MaxPlies adjudicates a draw after the number of plies, 0 for no limit.
+	MaxPlies int
+
+	// Concurrency is the number of games played at the same time.
+	Concurrency int
+
+	Event string
+}
+
+// Game is the outcome of a single game.
+type Game struct {
+	Round       int // 1-indexed pair of games of the same opening, played with colors swapped
+	Opening     string
+	AIsWhite    bool
+	Result      pgn.Result
+	Termination game.Termination
+	Record      *pgn.Game
+	Err         error
+}
+
+// ScoreA returns the score of player A in the game, with a draw as 0.5.
+func (g *Game) ScoreA() float64 {
+	score, _ := g.Result.Score()
+	if !g.AIsWhite {
+		score = 1 - score
+	}
+	return score
+}
+
+// Run plays the match, calling onGame after each game finishes with the summary including it. Games are
+// played in order of their pairs, but may finish in any order. Cancelling the context stops scheduling new
+// games.
+func Run(ctx context.Context, cfg *Config, onGame func(*Game, *Summary)) (*Summary, error) {
+	if cfg.PlayerA == nil || cfg.PlayerB == nil {
+		return nil, errors.New("missing players")
+	}
+	openings := cfg.Openings
+	if len(openings) == 0 {
+		openings = []string{board.DefaultStartingPositionFEN}
+	}
+	rounds := cfg.Rounds
+	if rounds <= 0 {
+		rounds = 1
+	}
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	type job struct {
+		round    int
+		opening  string
+		aIsWhite bool
+	}
+	jobs := make(chan job)
+	done := make(chan struct{}) // closed once the workers exit, possibly without taking every job
+	go func() {
+		defer close(jobs)
+		var round int
+		for r := 0; r < rounds; r++ {
+			for _, opening := range openings {
+				round++
+				for _, aIsWhite := range []bool{true, false} {
+					select {
+					case jobs <- job{round: round, opening: opening, aIsWhite: aIsWhite}:
+					case <-ctx.Done():
+						return
+					case <-done:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	summary := &Summary{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errCh := make(chan error, concurrency)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a, err := cfg.PlayerA()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			defer a.Close()
+			b, err := cfg.PlayerB()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			defer b.Close()
+
+			for j := range jobs {
+				white, black := a, b
+				if !j.aIsWhite {
+					white, black = b, a
+				}
+				g := Play(ctx, cfg, j.opening, white, black)
+				g.Round = j.round
+				g.AIsWhite = j.aIsWhite
+				pairGame := 1
+				if !j.aIsWhite {
+					pairGame = 2
+				}
+				g.Record.Tags["Round"] = fmt.Sprintf("%d.%d", j.round, pairGame)
+				if ctx.Err() != nil && g.Err != nil {
+					return // incomplete game
+				}
+
+				mu.Lock()
+				summary.add(g)
+				if onGame != nil {
+					onGame(g, summary)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	close(errCh)
+	if err := <-errCh; err != nil {
+		return summary, err
+	}
+	return summary, ctx.Err()
+}
+
+// Play plays a single game from the opening, ended by the rules of game.Game. Draws by threefold repetition
+// and the fifty move rule are claimed on behalf of the players.
+func Play(ctx context.Context, cfg *Config, opening string, white, black Player) *Game {
+	g := &Game{
+		Opening: opening,
+		Result:  pgn.ResultUnknown,
+	}
+	var gm *game.Game
+	defer func() {
+		g.Record = &pgn.Game{Tags: map[string]string{}}
+		if gm != nil {
+			g.Record = gm.PGN()
+		}
+		g.Record.Tags["Event"] = cfg.Event
+		g.Record.Tags["Date"] = time.Now().Format("2006.01.02")
+		g.Record.Tags["White"] = white.Name()
+		g.Record.Tags["Black"] = black.Name()
+		g.Record.Tags["TimeControl"] = timeControlTag(cfg.TimeControl)
+		if opening != board.DefaultStartingPositionFEN {
+			g.Record.Tags["FEN"] = opening
+			g.Record.Tags["SetUp"] = "1"
+		}
+		g.Record.Tags["Termination"] = string(g.Termination)
+		g.Record.Result = g.Result
+	}()
+
+	var err error
+	gm, err = game.NewGame(game.WithFEN(opening), game.WithTimeControl(game.TimeControl{
+		Time:      cfg.TimeControl.Time,
+		Increment: cfg.TimeControl.Increment,
+		Margin:    cfg.TimeMargin,
+	}))
+	if err != nil {
+		g.Termination, g.Err = TerminationError, err
+		return g
+	}
+	for _, p := range []Player{white, black} {
+		if err := p.NewGame(); err != nil {
+			g.Termination, g.Err = TerminationError, err
+			return g
+		}
+	}
+
+	start := gm.StartBoard()
+	for plies := 0; ; plies++ {
+		if _, ok := gm.ClaimableDraw(); ok {
+			_ = gm.ClaimDraw()
+		}
+		if gm.IsOver() {
+			g.Result, g.Termination = gm.Result(), gm.Termination()
+			return g
+		}
+		if cfg.MaxPlies > 0 && plies >= cfg.MaxPlies {
+			_ = gm.Adjudicate(pgn.ResultDraw)
+			g.Result, g.Termination = gm.Result(), TerminationMaxPlies
+			return g
+		}
+
+		turn := gm.Turn()
+		player := white
+		if turn == board.SideBlack {
+			player = black
+		}
+		clockCfg := &engine.ClockConfig{
+			Movetime: cfg.TimeControl.Movetime,
+			Depth:    cfg.TimeControl.Depth,
+		}
+		if cfg.TimeControl.Time != 0 {
+			clockCfg = &engine.ClockConfig{
+				WhiteTime:      gm.Remaining(board.SideWhite),
+				BlackTime:      gm.Remaining(board.SideBlack),
+				WhiteIncrement: cfg.TimeControl.Increment,
+				BlackIncrement: cfg.TimeControl.Increment,
+			}
+		}
+
+		mv, err := player.Move(ctx, start, gm.Moves(), gm.Board(), clockCfg)
+		if err != nil {
+			_ = gm.Resign(turn)
+			g.Result, g.Termination, g.Err = gm.Result(), TerminationError, err
+			return g
+		}
+		if err := gm.Move(mv); errors.Is(err, game.ErrIllegalMove) {
+			_ = gm.Resign(turn)
+			g.Result, g.Termination = gm.Result(), TerminationIllegalMove
+			g.Err = fmt.Errorf("illegal move %s by %s", mv.UCI(), player.Name())
+			return g
+		}
+		// a move after the clock ran out ends the game by time forfeit instead
+	}
+}
+
+func timeControlTag(tc TimeControl) string {
+	if tc.Time == 0 && tc.Movetime != 0 {
+		return fmt.Sprintf("%g/move", tc.Movetime.Seconds())
+	}
+	if tc.Time == 0 {
+		return "-"
+	}
+	if tc.Increment == 0 {
+		return fmt.Sprintf("%g", tc.Time.Seconds())
+	}
+	return fmt.Sprintf("%g+%g", tc.Time.Seconds(), tc.Increment.Seconds())
+}
@@ -0,0 +1,268 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/game"
+	"github.com/daystram/gambit/pgn"
+)
+
+// scriptedPlayer plays the moves of its side in UCI notation in order, repeating from the first move when
+// exhausted.
+type scriptedPlayer struct {
+	name         string
+	white, black []string
+	ply          int
+}
+
+func (p *scriptedPlayer) Name() string { return p.name }
+
+func (p *scriptedPlayer) NewGame() error {
+	p.ply = 0
+	return nil
+}
+
+func (p *scriptedPlayer) Move(_ context.Context, _ *board.Board, _ []board.Move, b *board.Board, _ *engine.ClockConfig) (board.Move, error) {
+	moves := p.white
+	if b.Turn() == board.SideBlack {
+		moves = p.black
+	}
+	if len(moves) == 0 {
+		return board.Move{}, errors.New("no moves")
+	}
+	mv, err := b.NewMoveFromUCI(moves[p.ply%len(moves)])
+	p.ply++
+	return mv, err
+}
+
+func (p *scriptedPlayer) Close() error { return nil }
+
+func TestPlay(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		fen             string
+		white, black    []string
+		maxPlies        int
+		wantResult      pgn.Result
+		wantTermination game.Termination
+	}{
+		{
+			name:            "checkmate",
+			fen:             board.DefaultStartingPositionFEN,
+			white:           []string{"f2f3", "g2g4"},
+			black:           []string{"e7e5", "d8h4"},
+			wantResult:      pgn.ResultBlackWins,
+			wantTermination: game.TerminationCheckmate,
+		},
+		{
+			name:            "repetition",
+			fen:             board.DefaultStartingPositionFEN,
+			white:           []string{"g1f3", "f3g1"},
+			black:           []string{"g8f6", "f6g8"},
+			wantResult:      pgn.ResultDraw,
+			wantTermination: game.TerminationRepetition,
+		},
+		{
+			name:            "stalemate",
+			fen:             "k7/8/1Q6/8/8/8/8/7K w - - 0 1",
+			white:           []string{"b6c7"},
+			wantResult:      pgn.ResultDraw,
+			wantTermination: game.TerminationStalemate,
+		},
+		{
+			name:            "insufficient material",
+			fen:             "k7/8/8/8/8/8/1r6/B6K w - - 0 1",
+			white:           []string{"a1b2"},
+			wantResult:      pgn.ResultDraw,
+			wantTermination: game.TerminationInsufficientMaterial,
+		},
+		{
+			name:            "max plies",
+			fen:             board.DefaultStartingPositionFEN,
+			white:           []string{"e2e4"},
+			black:           []string{"e7e5"},
+			maxPlies:        2,
+			wantResult:      pgn.ResultDraw,
+			wantTermination: TerminationMaxPlies,
+		},
+		{
+			name:            "illegal move",
+			fen:             board.DefaultStartingPositionFEN,
+			white:           []string{"e2e5"},
+			wantResult:      pgn.ResultBlackWins,
+			wantTermination: TerminationIllegalMove,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			white := &scriptedPlayer{name: "White", white: tt.white}
+			black := &scriptedPlayer{name: "Black", black: tt.black}
+			g := Play(context.Background(), &Config{MaxPlies: tt.maxPlies}, tt.fen, white, black)
+			if g.Result != tt.wantResult {
+				t.Errorf("unexpected result: got=%s want=%s", g.Result, tt.wantResult)
+			}
+			if g.Termination != tt.wantTermination {
+				t.Errorf("unexpected termination: got=%s want=%s", g.Termination, tt.wantTermination)
+			}
+
+			builder := strings.Builder{}
+			if err := g.Record.Write(&builder); err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if !strings.Contains(builder.String(), `[Result "`+string(tt.wantResult)+`"]`) {
+				t.Errorf("unexpected PGN: got=%s", builder.String())
+			}
+		})
+	}
+}
+
+// slowPlayer delays the moves of its player.
+type slowPlayer struct {
+	Player
+	delay time.Duration
+}
+
+func (p *slowPlayer) Move(ctx context.Context, start *board.Board, mvs []board.Move, b *board.Board, clockCfg *engine.ClockConfig) (board.Move, error) {
+	time.Sleep(p.delay)
+	return p.Player.Move(ctx, start, mvs, b, clockCfg)
+}
+
+func TestPlayTimeForfeit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		margin          time.Duration
+		wantResult      pgn.Result
+		wantTermination game.Termination
+	}{
+		{name: "overrun", margin: 0, wantResult: pgn.ResultBlackWins, wantTermination: game.TerminationTimeForfeit},
+		{name: "within margin", margin: time.Minute, wantResult: pgn.ResultDraw, wantTermination: TerminationMaxPlies},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			white := &slowPlayer{Player: &scriptedPlayer{name: "White", white: []string{"g1f3", "f3g1"}}, delay: 100 * time.Millisecond}
+			black := &scriptedPlayer{name: "Black", black: []string{"g8f6", "f6g8"}}
+			g := Play(context.Background(), &Config{
+				TimeControl: TimeControl{Time: 50 * time.Millisecond},
+				TimeMargin:  tt.margin,
+				MaxPlies:    2,
+			}, board.DefaultStartingPositionFEN, white, black)
+			if g.Result != tt.wantResult || g.Termination != tt.wantTermination {
+				t.Errorf("unexpected result: got=%s %s want=%s %s", g.Result, g.Termination, tt.wantResult, tt.wantTermination)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	// A mates with the scholar's mate as White, and with the fool's mate as Black
+	playerA := func() (Player, error) {
+		return &scriptedPlayer{name: "A", white: []string{"e2e4", "f1c4", "d1h5", "h5f7"}, black: []string{"e7e5", "d8h4"}}, nil
+	}
+	playerB := func() (Player, error) {
+		return &scriptedPlayer{name: "B", white: []string{"f2f3", "g2g4"}, black: []string{"a7a6", "a6a5", "a5a4"}}, nil
+	}
+
+	var games int
+	summary, err := Run(context.Background(), &Config{
+		PlayerA:     playerA,
+		PlayerB:     playerB,
+		Rounds:      2,
+		Concurrency: 2,
+		MaxPlies:    20,
+	}, func(g *Game, current *Summary) {
+		games++
+		if current.Games() != games {
+			t.Errorf("unexpected summary games: got=%d want=%d", current.Games(), games)
+		}
+	})
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if games != 4 || summary.Games() != 4 {
+		t.Errorf("unexpected games: got=%d want=%d", games, 4)
+	}
+	if summary.Wins != 4 {
+		t.Errorf("unexpected wins: got=%d want=%d", summary.Wins, 4)
+	}
+}
+
+func TestRunPlayerError(t *testing.T) {
+	t.Parallel()
+	errPlayer := errors.New("cannot start player")
+	playerA := func() (Player, error) { return &scriptedPlayer{name: "A"}, nil }
+	playerB := func() (Player, error) { return nil, errPlayer }
+
+	var games int
+	_, err := Run(context.Background(), &Config{
+		PlayerA:     playerA,
+		PlayerB:     playerB,
+		Rounds:      4,
+		Concurrency: 2,
+	}, func(g *Game, _ *Summary) {
+		games++
+	})
+	if !errors.Is(err, errPlayer) {
+		t.Errorf("unexpected error: got=%v want=%v", err, errPlayer)
+	}
+	if games != 0 {
+		t.Errorf("unexpected games: got=%d want=%d", games, 0)
+	}
+}
+
+func TestSummaryElo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		summary    Summary
+		wantElo    float64
+		wantMargin float64
+	}{
+		{summary: Summary{Wins: 10, Draws: 10, Losses: 10}, wantElo: 0, wantMargin: 104.6},
+		{summary: Summary{Wins: 60, Draws: 0, Losses: 40}, wantElo: 70.4, wantMargin: 70.6},
+		{summary: Summary{}, wantElo: 0, wantMargin: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.summary.String(), func(t *testing.T) {
+			t.Parallel()
+			elo, margin := tt.summary.Elo()
+			if math.Abs(elo-tt.wantElo) > 0.1 {
+				t.Errorf("unexpected elo: got=%.1f want=%.1f", elo, tt.wantElo)
+			}
+			if math.Abs(margin-tt.wantMargin) > 0.1 {
+				t.Errorf("unexpected margin: got=%.1f want=%.1f", margin, tt.wantMargin)
+			}
+		})
+	}
+}
+
+func TestEnginePlayer(t *testing.T) {
+	t.Parallel()
+	white := NewEnginePlayer("White", &engine.EngineConfig{HashTableSize: 1})
+	black := NewEnginePlayer("Black", &engine.EngineConfig{HashTableSize: 1})
+	g := Play(context.Background(), &Config{
+		TimeControl: TimeControl{Depth: 2},
+		MaxPlies:    6,
+	}, board.DefaultStartingPositionFEN, white, black)
+	if g.Err != nil {
+		t.Fatal("unexpected error:", g.Err)
+	}
+	if g.Termination != TerminationMaxPlies || len(g.Record.Moves) != 6 {
+		t.Errorf("unexpected game: got=%s after %d plies want=%s after %d plies", g.Termination, len(g.Record.Moves), TerminationMaxPlies, 6)
+	}
+}
@@ -0,0 +1,62 @@
+package match
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/daystram/gambit/board"
+)
+
+// LoadOpenings reads the opening suite file, see ReadOpenings.
+func LoadOpenings(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadOpenings(f)
+}
+
+// ReadOpenings reads an opening suite of one FEN or EPD position per line. Empty lines and lines starting
+// with # are skipped, EPD operations are dropped, and missing move clocks default to "0 1".
+func ReadOpenings(r io.Reader) ([]string, error) {
+	var fens []string
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.SplitN(text, ";", 2)[0])
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d: %w", line, board.ErrInvalidFEN)
+		}
+		if len(fields) < 6 || !isNumber(fields[4]) || !isNumber(fields[5]) {
+			fields = append(fields[:4], "0", "1")
+		}
+		fen := strings.Join(fields[:6], " ")
+		if _, err := board.NewBoard(board.WithFEN(fen)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		fens = append(fens, fen)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return fens, nil
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package match
+
+import (
+	"context"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+)
+
+// Player plays moves of a game. A player is only used by one game at a time.
+type Player interface {
+	// Name returns the name of the player, used in the PGN tags.
+	Name() string
+
+	// NewGame resets the player state before a game.
+	NewGame() error
+
+	// Move returns the move to play on the board, given the game clock. The history contains the moves
+	// applied since the starting position of the game. The board must not be modified.
+	Move(ctx context.Context, start *board.Board, history []board.Move, b *board.Board, clockCfg *engine.ClockConfig) (board.Move, error)
+
+	// Close releases the player resources.
+	Close() error
+}
+
+// PlayerFactory creates a player for each concurrent game.
+type PlayerFactory func() (Player, error)
+
+// EnginePlayer plays with an in-process engine.
+type EnginePlayer struct {
+	name   string
+	cfg    engine.EngineConfig
+	engine *engine.Engine
+}
+
+// NewEnginePlayerFactory creates players with engines using the config. The engine logger is discarded.
+func NewEnginePlayerFactory(name string, cfg *engine.EngineConfig) PlayerFactory {
+	return func() (Player, error) {
+		return NewEnginePlayer(name, cfg), nil
+	}
+}
+
+func NewEnginePlayer(name string, cfg *engine.EngineConfig) *EnginePlayer {
+	p := &EnginePlayer{
+		name: name,
+		cfg:  *cfg,
+	}
+	p.cfg.Logger = func(...any) {}
+	return p
+}
+
+func (p *EnginePlayer) Name() string {
+	return p.name
+}
+
+func (p *EnginePlayer) NewGame() error {
+	p.engine = engine.NewEngine(&p.cfg)
+	return nil
+}
+
+func (p *EnginePlayer) Move(ctx context.Context, _ *board.Board, _ []board.Move, b *board.Board, clockCfg *engine.ClockConfig) (board.Move, error) {
+	if p.engine == nil {
+		p.engine = engine.NewEngine(&p.cfg)
+	}
+	return p.engine.Search(ctx, b.Clone(), &engine.SearchConfig{
+		ClockConfig: *clockCfg,
+	})
+}
+
+func (p *EnginePlayer) Close() error {
+	return nil
+}
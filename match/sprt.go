@@ -0,0 +1,109 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// Decision is the outcome of a sequential probability ratio test.
+type Decision uint8
+
+const (
+	// DecisionNone is when more games are needed.
+	DecisionNone Decision = iota
+
+	// DecisionH0 is when the Elo difference is elo0 or less, i.e. the change is rejected.
+	DecisionH0
+
+	// DecisionH1 is when the Elo difference is elo1 or more, i.e. the change is accepted.
+	DecisionH1
+)
+
+func (d Decision) String() string {
+	switch d {
+	case DecisionH0:
+		return "H0 accepted"
+	case DecisionH1:
+		return "H1 accepted"
+	default:
+		return "undecided"
+	}
+}
+
+// Pentanomial counts the game pairs by the total score of player A in the pair: 0, 0.5, 1, 1.5, and 2.
+type Pentanomial [5]int
+
+// AddPair records the scores of player A in the two games of a pair.
+func (p *Pentanomial) AddPair(scoreA1, scoreA2 float64) {
+	p[int(math.Round((scoreA1+scoreA2)*2))]++
+}
+
+// Pairs returns the number of recorded pairs.
+func (p *Pentanomial) Pairs() int {
+	var n int
+	for _, c := range p {
+		n += c
+	}
+	return n
+}
+
+// stats returns the mean and variance of the pair score, normalized between 0 and 1.
+func (p *Pentanomial) stats() (float64, float64) {
+	n := float64(p.Pairs())
+	if n == 0 {
+		return 0.5, 0
+	}
+	var mean, variance float64
+	for i, c := range p {
+		mean += float64(i) / 4 * float64(c) / n
+	}
+	for i, c := range p {
+		variance += math.Pow(float64(i)/4-mean, 2) * float64(c) / n
+	}
+	return mean, variance
+}
+
+func (p *Pentanomial) String() string {
+	return fmt.Sprintf("[%d, %d, %d, %d, %d]", p[0], p[1], p[2], p[3], p[4])
+}
+
+// SPRT is a sequential probability ratio test of the logistic Elo difference of player A over player B, with
+// the hypotheses H0: elo <= Elo0 and H1: elo >= Elo1.
+type SPRT struct {
+	Elo0, Elo1  float64
+	Alpha, Beta float64
+}
+
+// Bounds returns the lower and upper bounds of the log-likelihood ratio.
+func (s *SPRT) Bounds() (float64, float64) {
+	return math.Log(s.Beta / (1 - s.Alpha)), math.Log((1 - s.Beta) / s.Alpha)
+}
+
+// LLR returns the log-likelihood ratio of the game pairs, using the normal approximation of the
+// pentanomial model.
+func (s *SPRT) LLR(p *Pentanomial) float64 {
+	mean, variance := p.stats()
+	if variance == 0 {
+		return 0
+	}
+	s0, s1 := eloToScore(s.Elo0), eloToScore(s.Elo1)
+	return float64(p.Pairs()) * (s1 - s0) * (2*mean - s0 - s1) / (2 * variance)
+}
+
+// Decide returns the decision of the test on the game pairs.
+func (s *SPRT) Decide(p *Pentanomial) Decision {
+	llr := s.LLR(p)
+	lower, upper := s.Bounds()
+	switch {
+	case llr <= lower:
+		return DecisionH0
+	case llr >= upper:
+		return DecisionH1
+	default:
+		return DecisionNone
+	}
+}
+
+func eloToScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
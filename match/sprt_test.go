@@ -0,0 +1,46 @@
+package match
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSPRT(t *testing.T) {
+	t.Parallel()
+	test := &SPRT{Elo0: 0, Elo1: 5, Alpha: 0.05, Beta: 0.05}
+	tests := []struct {
+		name         string
+		penta        Pentanomial
+		wantLLR      float64
+		wantDecision Decision
+	}{
+		{name: "empty", penta: Pentanomial{}, wantLLR: 0, wantDecision: DecisionNone},
+		{name: "balanced", penta: Pentanomial{1, 2, 3, 2, 1}, wantLLR: -0.0028, wantDecision: DecisionNone},
+		{name: "stronger", penta: Pentanomial{0, 0, 50, 50, 0}, wantLLR: 5.59, wantDecision: DecisionH1},
+		{name: "weaker", penta: Pentanomial{0, 50, 50, 0, 0}, wantLLR: -5.92, wantDecision: DecisionH0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if llr := test.LLR(&tt.penta); math.Abs(llr-tt.wantLLR) > 0.01 {
+				t.Errorf("unexpected LLR: got=%.4f want=%.4f", llr, tt.wantLLR)
+			}
+			if decision := test.Decide(&tt.penta); decision != tt.wantDecision {
+				t.Errorf("unexpected decision: got=%s want=%s", decision, tt.wantDecision)
+			}
+		})
+	}
+}
+
+func TestPentanomialAddPair(t *testing.T) {
+	t.Parallel()
+	var penta Pentanomial
+	for _, pair := range [][2]float64{{1, 1}, {1, 0.5}, {0.5, 0.5}, {1, 0}, {0, 0.5}, {0, 0}} {
+		penta.AddPair(pair[0], pair[1])
+	}
+	if want := (Pentanomial{1, 1, 2, 1, 1}); penta != want {
+		t.Errorf("unexpected pentanomial: got=%s want=%s", &penta, &want)
+	}
+}
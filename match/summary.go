@@ -0,0 +1,64 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// Summary is the score of player A against player B.
+type Summary struct {
+	Wins, Draws, Losses int
+}
+
+func (s *Summary) add(g *Game) {
+	switch score := g.ScoreA(); {
+	case g.Result == "" || g.Result == "*":
+		return
+	case score > 0.5:
+		s.Wins++
+	case score < 0.5:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+}
+
+// Games returns the number of decided games.
+func (s *Summary) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Score returns the average score of player A, with a draw as 0.5.
+func (s *Summary) Score() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games())
+}
+
+// Elo returns the Elo difference of player A over player B, with the 95% confidence margin.
+func (s *Summary) Elo() (float64, float64) {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0, 0
+	}
+	score := s.Score()
+	variance := (float64(s.Wins)*math.Pow(1-score, 2) +
+		float64(s.Draws)*math.Pow(0.5-score, 2) +
+		float64(s.Losses)*math.Pow(score, 2)) / n
+	stdErr := math.Sqrt(variance / n)
+	lower, upper := scoreToElo(score-1.959964*stdErr), scoreToElo(score+1.959964*stdErr)
+	return scoreToElo(score), (upper - lower) / 2
+}
+
+func (s *Summary) String() string {
+	elo, margin := s.Elo()
+	return fmt.Sprintf("games %d: +%d =%d -%d, score %.1f%%, elo %.1f +/- %.1f",
+		s.Games(), s.Wins, s.Draws, s.Losses, s.Score()*100, elo, margin)
+}
+
+// scoreToElo converts the expected score into an Elo difference, clamped for perfect scores.
+func scoreToElo(score float64) float64 {
+	score = math.Min(math.Max(score, 1e-6), 1-1e-6)
+	return -400 * math.Log10(1/score-1)
+}
@@ -0,0 +1,94 @@
+package match
+
+import (
+	"context"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/uci/client"
+)
+
+const (
+	uciStartupTimeout   = time.Minute // engines may initialize lookup tables before responding
+	uciHandshakeTimeout = 10 * time.Second
+)
+
+// UCIPlayer plays with an external engine executable over UCI.
+type UCIPlayer struct {
+	name   string
+	client *client.Client
+}
+
+// NewUCIPlayerFactory creates players running the executable, with the UCI options set after the handshake.
+// If name is empty, the engine reported name is used.
+func NewUCIPlayerFactory(name, path string, args []string, options map[string]string) PlayerFactory {
+	return func() (Player, error) {
+		return NewUCIPlayer(name, path, args, options)
+	}
+}
+
+func NewUCIPlayer(name, path string, args []string, options map[string]string) (*UCIPlayer, error) {
+	c, err := client.Start(path, args...)
+	if err != nil {
+		return nil, err
+	}
+	p := &UCIPlayer{
+		name:   name,
+		client: c,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), uciStartupTimeout)
+	defer cancel()
+	info, err := c.Handshake(ctx)
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	for optionName, value := range options {
+		if err := c.SetOption(optionName, value); err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+	}
+	if err := c.IsReady(ctx); err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	if p.name == "" {
+		p.name = info.Name
+	}
+	if p.name == "" {
+		p.name = path
+	}
+	return p, nil
+}
+
+func (p *UCIPlayer) Name() string {
+	return p.name
+}
+
+func (p *UCIPlayer) NewGame() error {
+	ctx, cancel := context.WithTimeout(context.Background(), uciHandshakeTimeout)
+	defer cancel()
+	return p.client.NewGame(ctx)
+}
+
+func (p *UCIPlayer) Move(ctx context.Context, start *board.Board, history []board.Move, b *board.Board, clockCfg *engine.ClockConfig) (board.Move, error) {
+	mvs := make([]string, 0, len(history))
+	for _, mv := range history {
+		mvs = append(mvs, mv.UCI())
+	}
+	if err := p.client.Position(start.FEN(), mvs); err != nil {
+		return board.Move{}, err
+	}
+	bestMove, err := p.client.Go(ctx, clockCfg, nil)
+	if err != nil {
+		return board.Move{}, err
+	}
+	return b.NewMoveFromUCI(bestMove.Move)
+}
+
+func (p *UCIPlayer) Close() error {
+	return p.client.Close()
+}
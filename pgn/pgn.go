@@ -0,0 +1,60 @@
+package pgn
+
+import (
+	"errors"
+
+	"github.com/daystram/gambit/board"
+)
+
+var (
+	ErrInvalidGame = errors.New("invalid game")
+)
+
+// Result is the game termination marker.
+type Result string
+
+const (
+	ResultWhiteWins Result = "1-0"
+	ResultBlackWins Result = "0-1"
+	ResultDraw      Result = "1/2-1/2"
+	ResultUnknown   Result = "*"
+)
+
+// Score returns the score of White, with a draw as 0.5. The second return value is false if the result is
+// unknown.
+func (r Result) Score() (float64, bool) {
+	switch r {
+	case ResultWhiteWins:
+		return 1, true
+	case ResultBlackWins:
+		return 0, true
+	case ResultDraw:
+		return 0.5, true
+	default:
+		return 0, false
+	}
+}
+
+func isResult(token string) bool {
+	switch Result(token) {
+	case ResultWhiteWins, ResultBlackWins, ResultDraw, ResultUnknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// Game is a game of the main line, with the moves resolved from the starting position.
+type Game struct {
+	Tags   map[string]string
+	Moves  []board.Move
+	Result Result
+}
+
+// Board returns the starting position of the game, from the FEN tag if present.
+func (g *Game) Board() (*board.Board, error) {
+	if fen, ok := g.Tags["FEN"]; ok {
+		return board.NewBoard(board.WithFEN(fen))
+	}
+	return board.NewBoard()
+}
@@ -0,0 +1,216 @@
+package pgn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/daystram/gambit/board"
+)
+
+// Reader reads consecutive games from a PGN stream. Comments, NAGs, and variations are skipped.
+type Reader struct {
+	r            *bufio.Reader
+	lineStart    bool // last read rune is at the start of a line
+	afterNewline bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:            bufio.NewReader(r),
+		afterNewline: true,
+	}
+}
+
+// Next reads the next game, returning io.EOF when there are no more games. A game with an invalid move is
+// consumed entirely and returned with an error wrapping ErrInvalidGame, so that reading may continue.
+func (r *Reader) Next() (*Game, error) {
+	g := &Game{
+		Tags:   make(map[string]string),
+		Result: ResultUnknown,
+	}
+	var sans []string
+	var started bool
+	for {
+		c, err := r.readRune()
+		if errors.Is(err, io.EOF) {
+			if !started {
+				return nil, io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case unicode.IsSpace(c):
+			continue
+		case c == '%' && r.lineStart:
+			if err := r.skipUntil('\n'); err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			continue
+		case c == '[':
+			if len(sans) != 0 {
+				// the previous game did not have a termination marker
+				_ = r.r.UnreadRune()
+				return r.resolve(g, sans)
+			}
+			started = true
+			tag, err := r.readUntil(']')
+			if err != nil {
+				return nil, fmt.Errorf("%w: unterminated tag", ErrInvalidGame)
+			}
+			name, value, ok := parseTag(tag)
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid tag %q", ErrInvalidGame, tag)
+			}
+			g.Tags[name] = value
+			continue
+		case c == '{':
+			if _, err := r.readUntil('}'); err != nil {
+				return nil, fmt.Errorf("%w: unterminated comment", ErrInvalidGame)
+			}
+			continue
+		case c == ';':
+			if err := r.skipUntil('\n'); err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			continue
+		case c == '(':
+			if err := r.skipVariation(); err != nil {
+				return nil, fmt.Errorf("%w: unterminated variation", ErrInvalidGame)
+			}
+			continue
+		}
+
+		started = true
+		token, err := r.readToken(c)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if strings.HasPrefix(token, "$") {
+			continue
+		}
+		if isResult(token) {
+			g.Result = Result(token)
+			break
+		}
+		// strip move numbers, e.g. "12.", "12...", or "12.e4"
+		token = strings.TrimLeft(token, "0123456789")
+		token = strings.TrimLeft(token, ".")
+		if token != "" {
+			sans = append(sans, token)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	return r.resolve(g, sans)
+}
+
+// resolve applies the moves from the starting position of the game.
+func (r *Reader) resolve(g *Game, sans []string) (*Game, error) {
+	if tagResult, ok := g.Tags["Result"]; ok && g.Result == ResultUnknown && isResult(tagResult) {
+		g.Result = Result(tagResult)
+	}
+	b, err := g.Board()
+	if err != nil {
+		return g, fmt.Errorf("%w: %v", ErrInvalidGame, err)
+	}
+	g.Moves = make([]board.Move, 0, len(sans))
+	for _, san := range sans {
+		mv, err := b.NewMoveFromSAN(san)
+		if err != nil {
+			return g, fmt.Errorf("%w: move %d %q: %v", ErrInvalidGame, len(g.Moves)+1, san, err)
+		}
+		b.Apply(mv)
+		g.Moves = append(g.Moves, mv)
+	}
+	return g, nil
+}
+
+func (r *Reader) readRune() (rune, error) {
+	c, _, err := r.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	r.lineStart, r.afterNewline = r.afterNewline, c == '\n'
+	return c, nil
+}
+
+func (r *Reader) readUntil(delim rune) (string, error) {
+	builder := strings.Builder{}
+	for {
+		c, err := r.readRune()
+		if err != nil {
+			return "", err
+		}
+		if c == delim {
+			return builder.String(), nil
+		}
+		_, _ = builder.WriteRune(c)
+	}
+}
+
+func (r *Reader) skipUntil(delim rune) error {
+	_, err := r.readUntil(delim)
+	return err
+}
+
+func (r *Reader) skipVariation() error {
+	depth := 1
+	for depth > 0 {
+		c, err := r.readRune()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '{':
+			if err := r.skipUntil('}'); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readToken reads a symbol token starting with the rune c.
+func (r *Reader) readToken(c rune) (string, error) {
+	builder := strings.Builder{}
+	_, _ = builder.WriteRune(c)
+	for {
+		c, err := r.readRune()
+		if err != nil {
+			return builder.String(), err
+		}
+		if unicode.IsSpace(c) || strings.ContainsRune("[]{}();", c) {
+			_ = r.r.UnreadRune()
+			return builder.String(), nil
+		}
+		_, _ = builder.WriteRune(c)
+	}
+}
+
+// parseTag parses the tag pair contents, e.g. `Event "Casual Game"`.
+func parseTag(tag string) (string, string, bool) {
+	tag = strings.TrimSpace(tag)
+	i := strings.IndexFunc(tag, unicode.IsSpace)
+	if i == -1 {
+		return "", "", false
+	}
+	name, value := tag[:i], strings.TrimSpace(tag[i:])
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", "", false
+	}
+	value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	return name, strings.ReplaceAll(value, `\\`, `\`), true
+}
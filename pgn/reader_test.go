@@ -0,0 +1,113 @@
+package pgn
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+	type game struct {
+		event   string
+		moves   []string
+		result  Result
+		wantErr error
+	}
+	tests := []struct {
+		name      string
+		pgn       string
+		wantGames []game
+	}{
+		{
+			name: "single game",
+			pgn: `[Event "Casual \"Blitz\" Game"]
+[Site "?"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 1-0
+`,
+			wantGames: []game{
+				{event: `Casual "Blitz" Game`, moves: []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "a7a6"}, result: ResultWhiteWins},
+			},
+		},
+		{
+			name: "comments, variations, and NAGs",
+			pgn: `[Event "A"]
+
+1.e4 {best by test} e5 (1... c5 2. Nf3 (2. c3) d6) 2.Nf3! $1 ; line comment
+2...Nc6?! 3. Bc4 1/2-1/2
+
+[Event "B"]
+[FEN "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1"]
+
+1. O-O-O Kf7 *
+`,
+			wantGames: []game{
+				{event: "A", moves: []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4"}, result: ResultDraw},
+				{event: "B", moves: []string{"e1c1", "e8f7"}, result: ResultUnknown},
+			},
+		},
+		{
+			name: "escape and missing termination",
+			pgn: `% exported by a tool
+[Event "A"]
+[Result "0-1"]
+
+1. d4 d5
+[Event "B"]
+
+1. e4 0-1`,
+			wantGames: []game{
+				{event: "A", moves: []string{"d2d4", "d7d5"}, result: ResultBlackWins},
+				{event: "B", moves: []string{"e2e4"}, result: ResultBlackWins},
+			},
+		},
+		{
+			name: "invalid move",
+			pgn: `[Event "A"]
+
+1. e4 e4 2. Nf3 1-0
+
+[Event "B"]
+
+1. d4 1-0
+`,
+			wantGames: []game{
+				{event: "A", moves: []string{"e2e4"}, result: ResultWhiteWins, wantErr: ErrInvalidGame},
+				{event: "B", moves: []string{"d2d4"}, result: ResultWhiteWins},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := NewReader(strings.NewReader(tt.pgn))
+			for i, want := range tt.wantGames {
+				g, err := r.Next()
+				if !errors.Is(err, want.wantErr) {
+					t.Fatalf("unexpected error on game %d: got=%v want=%v", i, err, want.wantErr)
+				}
+				if g.Tags["Event"] != want.event {
+					t.Errorf("unexpected event on game %d: got=%s want=%s", i, g.Tags["Event"], want.event)
+				}
+				if g.Result != want.result {
+					t.Errorf("unexpected result on game %d: got=%s want=%s", i, g.Result, want.result)
+				}
+				var moves []string
+				for _, mv := range g.Moves {
+					moves = append(moves, mv.UCI())
+				}
+				if strings.Join(moves, " ") != strings.Join(want.moves, " ") {
+					t.Errorf("unexpected moves on game %d: got=%v want=%v", i, moves, want.moves)
+				}
+			}
+			if _, err := r.Next(); !errors.Is(err, io.EOF) {
+				t.Errorf("unexpected error: got=%v want=%v", err, io.EOF)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/daystram/gambit/board"
+)
+
+const (
+	lineWidth = 80
+)
+
+// sevenTagRoster is the order of the mandatory tags, which precede the other tags.
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// Write writes the game in export format. Missing Seven Tag Roster tags are written as unknown, and the
+// Result tag always matches the game result.
+func (g *Game) Write(w io.Writer) error {
+	b, err := g.Board()
+	if err != nil {
+		return err
+	}
+
+	builder := strings.Builder{}
+	for _, name := range sevenTagRoster {
+		value, ok := g.Tags[name]
+		switch {
+		case name == "Result":
+			value = string(g.Result)
+		case !ok && name == "Date":
+			value = "????.??.??"
+		case !ok:
+			value = "?"
+		}
+		writeTag(&builder, name, value)
+	}
+	var names []string
+	for name := range g.Tags {
+		if !isSevenTagRoster(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeTag(&builder, name, g.Tags[name])
+	}
+	_, _ = builder.WriteRune('\n')
+
+	// movetext, wrapped by the line width
+	var line string
+	writeToken := func(token string) {
+		if line != "" && len(line)+1+len(token) > lineWidth {
+			_, _ = builder.WriteString(line + "\n")
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += token
+	}
+	moveNumber := int(b.FullMoveClock()) // tracked separately, the board clock may overflow in long games
+	for i, mv := range g.Moves {
+		switch {
+		case b.Turn() == board.SideWhite:
+			writeToken(fmt.Sprintf("%d.", moveNumber))
+		case i == 0:
+			writeToken(fmt.Sprintf("%d...", moveNumber))
+		}
+		writeToken(b.SAN(mv))
+		if b.Turn() == board.SideBlack {
+			moveNumber++
+		}
+		if _, ok := b.Apply(mv); !ok {
+			return fmt.Errorf("%w: illegal move %d %s", ErrInvalidGame, i+1, mv.UCI())
+		}
+	}
+	writeToken(string(g.Result))
+	_, _ = builder.WriteString(line + "\n\n")
+
+	_, err = io.WriteString(w, builder.String())
+	return err
+}
+
+func writeTag(builder *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	_, _ = builder.WriteString(fmt.Sprintf("[%s \"%s\"]\n", name, value))
+}
+
+func isSevenTagRoster(name string) bool {
+	for _, n := range sevenTagRoster {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		pgn     string
+		wantPGN string
+	}{
+		{
+			name: "starting position",
+			pgn:  `[White "Gambit"] [Black "Gambit \"Dev\""] [Opening "Ruy Lopez"] 1. e4 e5 2. Nf3 Nc6 3. Bb5 1-0`,
+			wantPGN: `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Gambit"]
+[Black "Gambit \"Dev\""]
+[Result "1-0"]
+[Opening "Ruy Lopez"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 1-0
+
+`,
+		},
+		{
+			name: "black to move",
+			pgn:  `[FEN "6k1/5ppp/8/8/8/8/5PPP/R5K1 b - - 0 30"] [SetUp "1"] 30... h6 31. Ra8+ Kh7 *`,
+			wantPGN: `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+[FEN "6k1/5ppp/8/8/8/8/5PPP/R5K1 b - - 0 30"]
+[SetUp "1"]
+
+30... h6 31. Ra8+ Kh7 *
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			g, err := NewReader(strings.NewReader(tt.pgn)).Next()
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			builder := strings.Builder{}
+			if err := g.Write(&builder); err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if got := builder.String(); got != tt.wantPGN {
+				t.Errorf("unexpected PGN: got=%s want=%s", got, tt.wantPGN)
+			}
+		})
+	}
+}
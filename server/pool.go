@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+)
+
+// Pool is a bounded set of engines, each running at most one search at a time.
+type Pool struct {
+	engines chan *engine.Engine
+}
+
+// NewPool creates size engines from the config.
+func NewPool(size int, cfg *engine.EngineConfig) *Pool {
+	p := &Pool{
+		engines: make(chan *engine.Engine, size),
+	}
+	for n := 0; n < size; n++ {
+		engineCfg := *cfg
+		if engineCfg.Logger == nil {
+			engineCfg.Logger = func(...any) {}
+		}
+		p.engines <- engine.NewEngine(&engineCfg)
+	}
+	return p
+}
+
+// Size returns the number of engines in the pool.
+func (p *Pool) Size() int {
+	return cap(p.engines)
+}
+
+// Search waits for an idle engine and searches the board, calling onInfo after each iteration. The board is
+// not modified.
+func (p *Pool) Search(
+	ctx context.Context, b *board.Board, clockCfg engine.ClockConfig, onInfo func(*engine.SearchInfo),
+) (board.Move, error) {
+	var e *engine.Engine
+	select {
+	case e = <-p.engines:
+	case <-ctx.Done():
+		return board.Move{}, fmt.Errorf("waiting for engine: %w", ctx.Err())
+	}
+	defer func() { p.engines <- e }()
+
+	return e.Search(ctx, b.Clone(), &engine.SearchConfig{ClockConfig: clockCfg, OnInfo: onInfo})
+}
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/pgn"
+)
+
+const (
+	DefaultMaxMovetime = 10 * time.Second
+
+	maxRequestSize = 1 << 20 // 1 MB
+)
+
+var errGameOver = errors.New("no legal moves")
+
+// AnalyzeRequest is the position and limits of an analysis. The position is the FEN, whose missing fields are
+// defaulted, or the final position of the PGN, or the starting position if neither is set, followed by the moves
+// in UCI notation. At most one limit is used, in the order of movetime, depth, and nodes, and the search never
+// exceeds the server maximum movetime.
+type AnalyzeRequest struct {
+	FEN      string   `json:"fen,omitempty"`
+	PGN      string   `json:"pgn,omitempty"`
+	Moves    []string `json:"moves,omitempty"`
+	Movetime int64    `json:"movetime,omitempty"` // in milliseconds
+	Depth    uint8    `json:"depth,omitempty"`
+	Nodes    uint32   `json:"nodes,omitempty"`
+}
+
+// Analysis is the result of an iteration, or of the whole search when BestMove is set. Time is in
+// milliseconds.
+type Analysis struct {
+	BestMove string   `json:"bestmove,omitempty"`
+	Score    *Score   `json:"score,omitempty"`
+	Depth    int      `json:"depth"`
+	Nodes    uint64   `json:"nodes"`
+	Time     int64    `json:"time"`
+	PV       []string `json:"pv"`
+}
+
+// Score is the evaluation from the side to move, either in centipawns or moves to mate.
+type Score struct {
+	Centipawns *int `json:"cp,omitempty"`
+	Mate       *int `json:"mate,omitempty"` // negative if the side to move is getting mated
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the analysis of positions over HTTP, with the searches run by the engines of the pool.
+//
+// POST /analyze takes an AnalyzeRequest and responds with the final Analysis. If the request accepts
+// text/event-stream, each iteration is streamed as an "info" Server-Sent Event, followed by a "bestmove" or
+// "error" event. The search is stopped when the request is cancelled.
+type Server struct {
+	pool        *Pool
+	maxMovetime time.Duration
+	mux         *http.ServeMux
+}
+
+type serverConfig struct {
+	maxMovetime time.Duration
+}
+
+type ServerOption func(*serverConfig)
+
+// WithMaxMovetime sets the limit of every search, defaulting to DefaultMaxMovetime.
+func WithMaxMovetime(d time.Duration) ServerOption {
+	return func(cfg *serverConfig) {
+		cfg.maxMovetime = d
+	}
+}
+
+func NewServer(pool *Pool, opts ...ServerOption) *Server {
+	cfg := &serverConfig{
+		maxMovetime: DefaultMaxMovetime,
+	}
+	for _, f := range opts {
+		f(cfg)
+	}
+
+	s := &Server{
+		pool:        pool,
+		maxMovetime: cfg.maxMovetime,
+		mux:         http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/analyze", s.handleAnalyze)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	var req AnalyzeRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	b, err := req.board()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	clockCfg := s.clockConfig(&req)
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.maxMovetime)
+	defer cancel()
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.stream(ctx, w, b, clockCfg)
+		return
+	}
+
+	var last *engine.SearchInfo
+	mv, err := s.pool.Search(ctx, b, clockCfg, func(info *engine.SearchInfo) {
+		if info.Type == engine.SearchInfoTypeIteration {
+			last = info
+		}
+	})
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newAnalysis(mv, last))
+}
+
+// stream runs the search, sending the iterations as Server-Sent Events.
+func (s *Server) stream(ctx context.Context, w http.ResponseWriter, b *board.Board, clockCfg engine.ClockConfig) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var last *engine.SearchInfo
+	send := func(event string, v any) {
+		data, _ := json.Marshal(v)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
+	}
+	mv, err := s.pool.Search(ctx, b, clockCfg, func(info *engine.SearchInfo) {
+		if info.Type != engine.SearchInfoTypeIteration {
+			return
+		}
+		last = info
+		send("info", newAnalysis(board.Move{}, info))
+	})
+	if err != nil {
+		send("error", &errorResponse{Error: err.Error()})
+		return
+	}
+	send("bestmove", newAnalysis(mv, last))
+}
+
+// clockConfig returns the search limit of the request, capped by the maximum movetime.
+func (s *Server) clockConfig(req *AnalyzeRequest) engine.ClockConfig {
+	switch movetime := time.Duration(req.Movetime) * time.Millisecond; {
+	case movetime > 0:
+		if movetime > s.maxMovetime {
+			movetime = s.maxMovetime
+		}
+		return engine.ClockConfig{Movetime: movetime}
+	case req.Depth != 0:
+		return engine.ClockConfig{Depth: req.Depth}
+	case req.Nodes != 0:
+		return engine.ClockConfig{Nodes: req.Nodes}
+	default:
+		return engine.ClockConfig{Movetime: s.maxMovetime}
+	}
+}
+
+// board resolves the position of the request.
+func (req *AnalyzeRequest) board() (*board.Board, error) {
+	var b *board.Board
+	var err error
+	switch {
+	case req.FEN != "" && req.PGN != "":
+		return nil, errors.New("expected either fen or pgn")
+	case req.PGN != "":
+		var g *pgn.Game
+		g, err = pgn.NewReader(strings.NewReader(req.PGN)).Next()
+		if err != nil {
+			return nil, fmt.Errorf("invalid pgn: %w", err)
+		}
+		if b, err = g.Board(); err != nil {
+			return nil, fmt.Errorf("invalid pgn: %w", err)
+		}
+		for _, mv := range g.Moves {
+			b.Apply(mv)
+		}
+	case req.FEN != "":
+		// pasted FENs are often truncated, but the position itself must be legal to search
+		if b, err = board.NewBoard(board.WithFEN(req.FEN), board.WithFENMode(board.FENModeLenient)); err != nil {
+			return nil, fmt.Errorf("invalid fen: %w", err)
+		}
+		if err = b.ValidatePosition(); err != nil {
+			return nil, fmt.Errorf("invalid fen: %w", err)
+		}
+	default:
+		if b, err = board.NewBoard(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, notation := range req.Moves {
+		mv, err := b.NewMoveFromUCI(notation)
+		if err != nil || !b.IsLegalMove(mv) {
+			return nil, fmt.Errorf("illegal move: %s", notation)
+		}
+		b.Apply(mv)
+	}
+	if !b.HasLegalMoves() {
+		return nil, errGameOver
+	}
+	return b, nil
+}
+
+func newAnalysis(mv board.Move, info *engine.SearchInfo) *Analysis {
+	a := &Analysis{
+		PV: []string{},
+	}
+	if !mv.IsNull() {
+		a.BestMove = mv.UCI()
+	}
+	if info == nil {
+		return a
+	}
+	a.Depth, a.Nodes, a.Time = int(info.Depth), uint64(info.Nodes), info.Time.Milliseconds()
+	for _, mv := range info.PV {
+		a.PV = append(a.PV, mv.UCI())
+	}
+	score := info.Score
+	if info.ScoreType == engine.ScoreTypeMate {
+		a.Score = &Score{Mate: &score}
+	} else {
+		a.Score = &Score{Centipawns: &score}
+	}
+	return a
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
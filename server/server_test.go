@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daystram/gambit/engine"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(NewServer(NewPool(2, &engine.EngineConfig{HashTableSize: 1}), WithMaxMovetime(5*time.Second)))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestServerAnalyze(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		wantStatus   int
+		wantBestMove string
+		wantMate     int
+		wantError    string
+	}{
+		{
+			name:       "startpos by depth",
+			method:     http.MethodPost,
+			body:       `{"depth": 2}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "mate in one from fen",
+			method:       http.MethodPost,
+			body:         `{"fen": "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "depth": 3}`,
+			wantStatus:   http.StatusOK,
+			wantBestMove: "a1a8",
+			wantMate:     1,
+		},
+		{
+			name:         "mate in one from pgn and moves",
+			method:       http.MethodPost,
+			body:         `{"pgn": "1. f3 e5 2. g4", "depth": 2}`,
+			wantStatus:   http.StatusOK,
+			wantBestMove: "d8h4",
+			wantMate:     1,
+		},
+		{
+			name:         "moves after fen",
+			method:       http.MethodPost,
+			body:         `{"fen": "6k1/5ppp/8/8/8/8/8/R5K1 b - - 0 1", "moves": ["g8h8"], "movetime": 600}`,
+			wantStatus:   http.StatusOK,
+			wantBestMove: "a1a8",
+			wantMate:     1,
+		},
+		{
+			name:         "truncated fen",
+			method:       http.MethodPost,
+			body:         `{"fen": "6k1/5ppp/8/8/8/8/8/R5K1", "depth": 3}`,
+			wantStatus:   http.StatusOK,
+			wantBestMove: "a1a8",
+			wantMate:     1,
+		},
+		{
+			name:       "illegal position",
+			method:     http.MethodPost,
+			body:       `{"fen": "R5k1/5ppp/8/8/8/8/8/6K1 w - - 0 1"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid fen",
+		},
+		{
+			name:       "invalid fen",
+			method:     http.MethodPost,
+			body:       `{"fen": "invalid"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid fen",
+		},
+		{
+			name:       "both fen and pgn",
+			method:     http.MethodPost,
+			body:       `{"fen": "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "pgn": "1. e4"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "expected either fen or pgn",
+		},
+		{
+			name:       "illegal move",
+			method:     http.MethodPost,
+			body:       `{"moves": ["e2e5"]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "illegal move: e2e5",
+		},
+		{
+			name:       "checkmated",
+			method:     http.MethodPost,
+			body:       `{"fen": "R5k1/5ppp/8/8/8/8/8/6K1 b - - 1 1"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "no legal moves",
+		},
+		{
+			name:       "malformed json",
+			method:     http.MethodPost,
+			body:       `{"depth": `,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid request",
+		},
+		{
+			name:       "method not allowed",
+			method:     http.MethodGet,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantError:  "method not allowed",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req, err := http.NewRequest(tt.method, srv.URL+"/analyze", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("unexpected status: got=%d want=%d", resp.StatusCode, tt.wantStatus)
+			}
+
+			if tt.wantError != "" {
+				var got errorResponse
+				if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.HasPrefix(got.Error, tt.wantError) {
+					t.Errorf("unexpected error: got=%q want=%q", got.Error, tt.wantError)
+				}
+				return
+			}
+			var got Analysis
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.BestMove == "" || (tt.wantBestMove != "" && got.BestMove != tt.wantBestMove) {
+				t.Errorf("unexpected best move: got=%q want=%q", got.BestMove, tt.wantBestMove)
+			}
+			if got.Depth == 0 || len(got.PV) == 0 || got.PV[0] != got.BestMove {
+				t.Errorf("unexpected analysis: got=%+v", got)
+			}
+			if tt.wantMate != 0 && (got.Score == nil || got.Score.Mate == nil || *got.Score.Mate != tt.wantMate) {
+				t.Errorf("unexpected score: got=%+v want mate=%d", got.Score, tt.wantMate)
+			}
+		})
+	}
+}
+
+func TestServerStream(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/analyze", strings.NewReader(`{"depth": 4}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("unexpected content type: got=%q want=%q", got, "text/event-stream")
+	}
+
+	var events []string
+	var last Analysis
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			events = append(events, strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			last = Analysis{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &last); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	wantEvents := []string{"info", "info", "info", "info", "bestmove"}
+	if strings.Join(events, ",") != strings.Join(wantEvents, ",") {
+		t.Errorf("unexpected events: got=%v want=%v", events, wantEvents)
+	}
+	if last.BestMove == "" || last.Depth != 4 {
+		t.Errorf("unexpected analysis: got=%+v", last)
+	}
+}
+
+func TestServerCancel(t *testing.T) {
+	t.Parallel()
+	srv := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/analyze", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Now()
+	if _, err := http.DefaultClient.Do(req); err == nil {
+		t.Fatalf("error expected: got=nil")
+	}
+
+	// the engines are released once the cancelled searches stop
+	req, err = http.NewRequest(http.MethodPost, srv.URL+"/analyze", strings.NewReader(`{"depth": 1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status: got=%d want=%d", resp.StatusCode, http.StatusOK)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("unexpected elapsed time: got=%s want<%s", elapsed, 4*time.Second)
+	}
+}
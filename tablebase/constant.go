@@ -0,0 +1,182 @@
+package tablebase
+
+import (
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/position"
+)
+
+const (
+	// MaxPieces is the maximum number of pieces supported by the Syzygy format.
+	MaxPieces = 7
+
+	maxLeadPawns = 5
+)
+
+// Piece types as encoded in the Syzygy files. Black pieces are offset by 8.
+const (
+	tbPawn   uint8 = 1
+	tbKnight uint8 = 2
+	tbBishop uint8 = 3
+	tbRook   uint8 = 4
+	tbQueen  uint8 = 5
+	tbKing   uint8 = 6
+
+	tbBlackOffset uint8 = 8
+)
+
+var (
+	tbPieceTypes = [6 + 1]uint8{
+		board.PiecePawn:   tbPawn,
+		board.PieceKnight: tbKnight,
+		board.PieceBishop: tbBishop,
+		board.PieceRook:   tbRook,
+		board.PieceQueen:  tbQueen,
+		board.PieceKing:   tbKing,
+	}
+	tbPieceSymbols = [6 + 1]byte{
+		tbPawn:   'P',
+		tbKnight: 'N',
+		tbBishop: 'B',
+		tbRook:   'R',
+		tbQueen:  'Q',
+		tbKing:   'K',
+	}
+
+	// Indexing tables, as described in https://github.com/official-stockfish/Stockfish.
+	mapPawns      [64]int
+	mapB1H1H7     [64]int
+	mapA1D1D4     [64]int
+	mapKK         [10][64]int
+	binomial      [MaxPieces][64]uint64
+	leadPawnIdx   [maxLeadPawns + 1][64]uint64
+	leadPawnsSize [maxLeadPawns + 1][4]uint64
+)
+
+func init() {
+	initMaps()
+}
+
+func initMaps() {
+	// mapB1H1H7 encodes a cell below the A1-H8 diagonal to 0..27
+	code := 0
+	for pos := position.A1; pos <= position.H8; pos++ {
+		if offA1H8(pos) < 0 {
+			mapB1H1H7[pos] = code
+			code++
+		}
+	}
+
+	// mapA1D1D4 encodes a cell in the A1-D1-D4 triangle to 0..9, with the diagonal cells last
+	var diagonal []position.Pos
+	code = 0
+	for _, pos := range []position.Pos{
+		position.A1, position.B1, position.C1, position.D1,
+		position.B2, position.C2, position.D2,
+		position.C3, position.D3,
+		position.D4,
+	} {
+		if offA1H8(pos) < 0 {
+			mapA1D1D4[pos] = code
+			code++
+		} else if offA1H8(pos) == 0 {
+			diagonal = append(diagonal, pos)
+		}
+	}
+	for _, pos := range diagonal {
+		mapA1D1D4[pos] = code
+		code++
+	}
+
+	// mapKK encodes the 462 legal placements of two Kings where the first is in the A1-D1-D4 triangle.
+	// If the first King is on the A1-D4 diagonal, the other shall not be above the A1-H8 diagonal.
+	type kkPair struct {
+		idx int
+		pos position.Pos
+	}
+	var bothOnDiagonal []kkPair
+	code = 0
+	for idx := 0; idx < 10; idx++ {
+		for pos1 := position.A1; pos1 <= position.D4; pos1++ {
+			if mapA1D1D4[pos1] != idx || (idx == 0 && pos1 != position.B1) { // B1 is mapped to 0
+				continue
+			}
+			for pos2 := position.A1; pos2 <= position.H8; pos2++ {
+				switch {
+				case distance(pos1, pos2) <= 1:
+					continue // illegal position
+				case offA1H8(pos1) == 0 && offA1H8(pos2) > 0:
+					continue // first on diagonal, second above
+				case offA1H8(pos1) == 0 && offA1H8(pos2) == 0:
+					bothOnDiagonal = append(bothOnDiagonal, kkPair{idx: idx, pos: pos2})
+				default:
+					mapKK[idx][pos2] = code
+					code++
+				}
+			}
+		}
+	}
+	for _, p := range bothOnDiagonal {
+		mapKK[p.idx][p.pos] = code
+		code++
+	}
+
+	// binomial[k][n] is the number of ways to choose k elements from a set of n elements
+	binomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < MaxPieces && k <= n; k++ {
+			if k > 0 {
+				binomial[k][n] += binomial[k-1][n-1]
+			}
+			if k < n {
+				binomial[k][n] += binomial[k][n-1]
+			}
+		}
+	}
+
+	// mapPawns encodes cells A2-H7 to 0..47, such that the leading Pawn has the highest value:
+	// nearest to the edge, and among Pawns on the same file, the one with the lowest rank.
+	availableCells := 47
+	for leadPawns := 1; leadPawns <= maxLeadPawns; leadPawns++ {
+		for file := position.FileA; file <= position.FileD; file++ {
+			var idx uint64
+			for rank := position.Rank2; rank <= position.Rank7; rank++ {
+				pos := rank*8 + file
+				if leadPawns == 1 {
+					mapPawns[pos] = availableCells
+					availableCells--
+					mapPawns[flipFile(pos)] = availableCells
+					availableCells--
+				}
+				leadPawnIdx[leadPawns][pos] = idx
+				idx += binomial[leadPawns-1][mapPawns[pos]]
+			}
+			leadPawnsSize[leadPawns][file] = idx
+		}
+	}
+}
+
+func offA1H8(pos position.Pos) int {
+	return int(pos.Y()) - int(pos.X())
+}
+
+func flipFile(pos position.Pos) position.Pos {
+	return pos ^ 7
+}
+
+func flipRank(pos position.Pos) position.Pos {
+	return pos ^ 56
+}
+
+func distance(pos1, pos2 position.Pos) int {
+	dx, dy := int(pos1.X()-pos2.X()), int(pos1.Y()-pos2.Y())
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
@@ -0,0 +1,1278 @@
+package tablebase
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/position"
+)
+
+// The tables of testTables in testdata are solved by retrograde analysis, with a move generator independent
+// of the board package, and encoded in the Syzygy format. To regenerate them:
+//
+//	go test ./tablebase -run TestGenerateTables -generate -timeout 1h
+var generate = flag.Bool("generate", false, "regenerate the tables in testdata")
+
+func TestGenerateTables(t *testing.T) {
+	if !*generate {
+		t.Skip("-generate not set")
+	}
+	s := &genSolver{materials: make(map[string]*genMaterial)}
+	for _, code := range testTables {
+		m := s.solve(code)
+		t.Logf("%s: longest win %d plies, longest loss %d plies", code, m.longest(WDLWin), m.longest(WDLLoss))
+		for _, typ := range []tableType{tableTypeWDL, tableTypeDTZ} {
+			buf, err := m.encode(typ)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			ext := extensionWDL
+			if typ == tableTypeDTZ {
+				ext = extensionDTZ
+			}
+			if err := os.WriteFile(filepath.Join(testdataPath, code+ext), buf, 0o644); err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+		}
+	}
+
+	// probe a sample of the positions back through the files
+	tb, err := NewSyzygy(testdataPath)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	defer tb.Close()
+	for _, code := range testTables {
+		m := s.materials[code]
+		var p genPosition
+		for idx := 0; idx < len(m.wdl); idx += 1 + len(m.wdl)/500000 {
+			if m.wdl[idx] == genIllegal {
+				continue
+			}
+			m.position(idx, &p)
+			b, err := board.NewBoard(board.WithFEN(m.fen(&p)))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			wantWDL, wantDTZ := m.result(idx)
+			wdl, err := tb.ProbeWDL(b)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if wdl != wantWDL {
+				t.Fatalf("unexpected WDL of %s: got=%s want=%s", m.fen(&p), wdl, wantWDL)
+			}
+			dtz, err := tb.ProbeDTZ(b)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			// cursed wins and blessed losses are stored in moves
+			if dtz != wantDTZ && (wdl == WDLWin || wdl == WDLLoss || dtz-wantDTZ > 1 || wantDTZ-dtz > 1) {
+				t.Fatalf("unexpected DTZ of %s: got=%d want=%d", m.fen(&p), dtz, wantDTZ)
+			}
+		}
+	}
+}
+
+const genIllegal int8 = -128
+
+// States of the positions while solving.
+const (
+	genStateIllegal uint8 = iota
+	genStateOpen
+	genStateWin
+	genStateLoss
+	genStateDraw
+)
+
+const genNoLoss uint8 = 0xFF // the side to move can avoid losing through a zeroing move
+
+var (
+	genKingSteps   = [][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	genKnightSteps = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	genRookDirs    = [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	genBishopDirs  = [][2]int{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+	genQueenDirs   = append(append([][2]int{}, genRookDirs...), genBishopDirs...)
+	genPromotions  = []uint8{tbQueen, tbRook, tbBishop, tbKnight}
+)
+
+// genSolver solves the materials, and the materials reached through captures and promotions.
+type genSolver struct {
+	materials map[string]*genMaterial
+}
+
+// genMaterial holds the solved positions of a material, indexed by the cells of the pieces and the side to
+// move. The Pawns are the last pieces, so that the positions sharing the Pawns are contiguous.
+type genMaterial struct {
+	code     string
+	pieces   []uint8 // Syzygy piece codes, White as the first side of the code
+	pawns    int
+	kings    [2]int
+	wdl      []int8  // genIllegal for illegal positions
+	dtz      []int16 // plies to zeroing the half move clock, by either side
+	zeroing  []bool  // the DTZ probes do not read the table, as the best move is a zeroing move
+	children map[int]*genChild
+}
+
+// genChild is the material reached by a capture or promotion, with the pieces mapped to the parent's.
+type genChild struct {
+	m    *genMaterial
+	from []int
+}
+
+type genPosition struct {
+	cells [MaxPieces]int // -1 once captured
+	board [64]int8       // 1 + the piece index, 0 if empty
+	stm   int
+}
+
+type genMove struct {
+	piece    int
+	to       int
+	captured int // -1 if none
+	promoted uint8
+}
+
+func (s *genSolver) solve(code string) *genMaterial {
+	if m, ok := s.materials[code]; ok {
+		return m
+	}
+	m := &genMaterial{code: code, children: make(map[int]*genChild)}
+	var color uint8
+	for i := 0; i < len(code); i++ {
+		if code[i] == 'v' {
+			color = tbBlackOffset
+			continue
+		}
+		m.pieces = append(m.pieces, symbolToType(code[i])|color)
+	}
+	genSort(m.pieces)
+	for i, pc := range m.pieces {
+		switch pc &^ tbBlackOffset {
+		case tbPawn:
+			m.pawns++
+		case tbKing:
+			m.kings[pc>>3] = i
+		}
+	}
+	if len(m.pieces) > 2 {
+		s.solveChildren(m)
+		m.solve()
+	}
+	s.materials[code] = m
+	return m
+}
+
+// genSort sorts the pieces by color and type, with the Pawns last.
+func genSort(pieces []uint8) {
+	sort.SliceStable(pieces, func(i, j int) bool { return genOrder(pieces[i]) < genOrder(pieces[j]) })
+}
+
+func genOrder(pc uint8) int {
+	if pc&^tbBlackOffset == tbPawn {
+		return 16 + int(pc)
+	}
+	return int(pc)
+}
+
+// solveChildren solves the materials reached through each capture and promotion.
+func (s *genSolver) solveChildren(m *genMaterial) {
+	for captured := -1; captured < len(m.pieces); captured++ {
+		if captured >= 0 && m.pieces[captured]&^tbBlackOffset == tbKing {
+			continue
+		}
+		for promoting := -1; promoting < len(m.pieces); promoting++ {
+			if promoting == captured || promoting >= 0 && m.pieces[promoting]&^tbBlackOffset != tbPawn {
+				continue
+			}
+			for _, promoted := range genPromotions {
+				if promoting < 0 && promoted != tbQueen {
+					continue // only once without a promotion
+				}
+				if captured < 0 && promoting < 0 {
+					continue
+				}
+				type slot struct {
+					pc   uint8
+					from int
+				}
+				var slots []slot
+				for i, pc := range m.pieces {
+					if i == captured {
+						continue
+					}
+					if i == promoting {
+						pc = promoted | pc&tbBlackOffset
+					}
+					slots = append(slots, slot{pc: pc, from: i})
+				}
+				sort.SliceStable(slots, func(i, j int) bool { return genOrder(slots[i].pc) < genOrder(slots[j].pc) })
+				var pieces []uint8
+				child := &genChild{}
+				for _, sl := range slots {
+					pieces = append(pieces, sl.pc)
+					child.from = append(child.from, sl.from)
+				}
+				child.m = s.solve(genCode(pieces))
+				m.children[genChildKey(captured, promoting, promoted)] = child
+			}
+		}
+	}
+}
+
+func genChildKey(captured, promoting int, promoted uint8) int {
+	if promoting < 0 {
+		promoted = 0
+	}
+	return ((captured+1)*(MaxPieces+1)+promoting+1)*8 + int(promoted)
+}
+
+func genCode(pieces []uint8) string {
+	var sb strings.Builder
+	for _, color := range []uint8{0, tbBlackOffset} {
+		if color != 0 {
+			sb.WriteByte('v')
+		}
+		for _, typ := range []uint8{tbKing, tbQueen, tbRook, tbBishop, tbKnight, tbPawn} {
+			for _, pc := range pieces {
+				if pc == typ|color {
+					sb.WriteByte(tbPieceSymbols[typ])
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+func (m *genMaterial) index(p *genPosition) int {
+	idx := 0
+	for i := len(m.pieces) - 1; i >= 0; i-- {
+		idx = idx*64 + p.cells[i]
+	}
+	return idx*2 + p.stm
+}
+
+// position decodes the index, returning false if the position is illegal.
+func (m *genMaterial) position(idx int, p *genPosition) bool {
+	*p = genPosition{stm: idx & 1}
+	for i := range p.cells {
+		p.cells[i] = -1
+	}
+	idx >>= 1
+	for i, pc := range m.pieces {
+		cell := idx & 63
+		idx >>= 6
+		if p.board[cell] != 0 || pc&^tbBlackOffset == tbPawn && (cell < 8 || cell >= 56) {
+			return false
+		}
+		p.cells[i] = cell
+		p.board[cell] = int8(i + 1)
+	}
+	return !m.inCheck(p, p.stm^1)
+}
+
+func (m *genMaterial) color(i int) int {
+	return int(m.pieces[i] >> 3)
+}
+
+// attacks returns true if the piece attacks the cell.
+func (m *genMaterial) attacks(p *genPosition, i, cell int) bool {
+	from := p.cells[i]
+	if from < 0 || from == cell {
+		return false
+	}
+	dx, dy := cell&7-from&7, cell>>3-from>>3
+	adx, ady := genAbs(dx), genAbs(dy)
+	switch m.pieces[i] &^ tbBlackOffset {
+	case tbPawn:
+		return adx == 1 && dy == 1-2*m.color(i)
+	case tbKnight:
+		return adx*ady == 2
+	case tbKing:
+		return adx <= 1 && ady <= 1
+	case tbBishop:
+		if adx != ady {
+			return false
+		}
+	case tbRook:
+		if dx != 0 && dy != 0 {
+			return false
+		}
+	case tbQueen:
+		if dx != 0 && dy != 0 && adx != ady {
+			return false
+		}
+	}
+	step := genSign(dy)*8 + genSign(dx)
+	for c := from + step; c != cell; c += step {
+		if p.board[c] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *genMaterial) inCheck(p *genPosition, color int) bool {
+	king := p.cells[m.kings[color]]
+	for i := range m.pieces {
+		if m.color(i) != color && m.attacks(p, i, king) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *genMaterial) apply(p *genPosition, mv genMove) genPosition {
+	q := *p
+	q.board[q.cells[mv.piece]] = 0
+	if mv.captured >= 0 {
+		q.cells[mv.captured] = -1
+	}
+	q.cells[mv.piece] = mv.to
+	q.board[mv.to] = int8(mv.piece + 1)
+	q.stm ^= 1
+	return q
+}
+
+// moves appends the legal moves of the side to move.
+func (m *genMaterial) moves(p *genPosition, mvs []genMove) []genMove {
+	add := func(i, to int, promoted uint8) {
+		mv := genMove{piece: i, to: to, captured: int(p.board[to]) - 1, promoted: promoted}
+		if q := m.apply(p, mv); !m.inCheck(&q, p.stm) {
+			mvs = append(mvs, mv)
+		}
+	}
+	for i, pc := range m.pieces {
+		if m.color(i) != p.stm || p.cells[i] < 0 {
+			continue
+		}
+		from := p.cells[i]
+		x, y := from&7, from>>3
+		var steps [][2]int
+		slides := true
+		switch pc &^ tbBlackOffset {
+		case tbPawn:
+			dy := 1 - 2*p.stm
+			last := y+dy == 0 || y+dy == 7
+			addPawn := func(to int) {
+				if !last {
+					add(i, to, 0)
+					return
+				}
+				for _, promoted := range genPromotions {
+					add(i, to, promoted)
+				}
+			}
+			if to := from + 8*dy; p.board[to] == 0 {
+				addPawn(to)
+				if start := 1 + 5*p.stm; y == start && p.board[to+8*dy] == 0 {
+					add(i, to+8*dy, 0)
+				}
+			}
+			for _, dx := range []int{-1, 1} {
+				if x+dx < 0 || x+dx > 7 {
+					continue
+				}
+				to := from + 8*dy + dx
+				if o := p.board[to]; o != 0 && m.color(int(o)-1) != p.stm {
+					addPawn(to)
+				}
+			}
+			continue
+		case tbKnight:
+			steps, slides = genKnightSteps, false
+		case tbKing:
+			steps, slides = genKingSteps, false
+		case tbBishop:
+			steps = genBishopDirs
+		case tbRook:
+			steps = genRookDirs
+		case tbQueen:
+			steps = genQueenDirs
+		}
+		for _, d := range steps {
+			for tx, ty := x+d[0], y+d[1]; tx >= 0 && tx < 8 && ty >= 0 && ty < 8; tx, ty = tx+d[0], ty+d[1] {
+				to := ty*8 + tx
+				if o := p.board[to]; o != 0 {
+					if m.color(int(o)-1) != p.stm && m.pieces[o-1]&^tbBlackOffset != tbKing {
+						add(i, to, 0)
+					}
+					break
+				}
+				add(i, to, 0)
+				if !slides {
+					break
+				}
+			}
+		}
+	}
+	return mvs
+}
+
+// unmoves calls fn with the index of each position preceding p by a move not zeroing the half move clock.
+func (m *genMaterial) unmoves(p *genPosition, fn func(int)) {
+	q := *p
+	q.stm ^= 1
+	for i, pc := range m.pieces {
+		if m.color(i) == p.stm || pc&^tbBlackOffset == tbPawn {
+			continue
+		}
+		from := p.cells[i]
+		x, y := from&7, from>>3
+		var steps [][2]int
+		slides := true
+		switch pc &^ tbBlackOffset {
+		case tbKnight:
+			steps, slides = genKnightSteps, false
+		case tbKing:
+			steps, slides = genKingSteps, false
+		case tbBishop:
+			steps = genBishopDirs
+		case tbRook:
+			steps = genRookDirs
+		case tbQueen:
+			steps = genQueenDirs
+		}
+		for _, d := range steps {
+			for tx, ty := x+d[0], y+d[1]; tx >= 0 && tx < 8 && ty >= 0 && ty < 8; tx, ty = tx+d[0], ty+d[1] {
+				to := ty*8 + tx
+				if p.board[to] != 0 {
+					break
+				}
+				q.cells[i] = to
+				fn(m.index(&q))
+				if !slides {
+					break
+				}
+			}
+		}
+		q.cells[i] = from
+	}
+}
+
+// exit returns the WDL of a zeroing move, for the side making the move.
+func (m *genMaterial) exit(p *genPosition, mv genMove) WDL {
+	q := m.apply(p, mv)
+	promoting := -1
+	if mv.promoted != 0 {
+		promoting = mv.piece
+	}
+	if mv.captured < 0 && promoting < 0 {
+		return -WDL(m.wdl[m.index(&q)]) // Pawn push, already solved
+	}
+	child := m.children[genChildKey(mv.captured, promoting, mv.promoted)]
+	if len(child.from) == 2 {
+		return WDLDraw
+	}
+	var cq genPosition
+	cq.stm = q.stm
+	for k, from := range child.from {
+		cq.cells[k] = q.cells[from]
+	}
+	return -WDL(child.m.wdl[child.m.index(&cq)])
+}
+
+// solve computes the positions by retrograde analysis, from the most advanced Pawns. The positions are
+// solved by increasing distance to zeroing; a zeroing move into a cursed or blessed position is taken to
+// happen after 100 plies, so that the distance decides the 50 move rule.
+func (m *genMaterial) solve() {
+	n := 2 << (6 * len(m.pieces))
+	m.wdl = make([]int8, n)
+	for idx := range m.wdl {
+		m.wdl[idx] = genIllegal // Pawns on the first or last rank are never solved
+	}
+	m.dtz = make([]int16, n)
+	state := make([]uint8, n)
+	count := make([]uint8, n)
+	lossExit := make([]uint8, n)
+	winExit := make([]uint8, n) // or genNoLoss if all moves are zeroing moves
+	m.zeroing = make([]bool, n)
+
+	groupSize := 2 << (6 * (len(m.pieces) - m.pawns))
+	type group struct{ base, advance int }
+	var groups []group
+	for g := 0; g < 1<<(6*m.pawns); g++ {
+		advance, valid := 0, true
+		for k := 0; k < m.pawns; k++ {
+			cell := g >> (6 * k) & 63
+			rank := cell >> 3
+			if m.color(len(m.pieces)-m.pawns+k) == 1 {
+				rank = 7 - rank
+			}
+			advance += rank
+			valid = valid && cell >= 8 && cell < 56
+		}
+		if valid {
+			groups = append(groups, group{base: g * groupSize, advance: advance})
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].advance > groups[j].advance })
+
+	var p genPosition
+	var mvs []genMove
+	for _, g := range groups {
+		var buckets [][]uint32
+		push := func(level, idx int, loss bool) {
+			for len(buckets) <= level {
+				buckets = append(buckets, nil)
+			}
+			e := uint32(idx) << 1
+			if loss {
+				e |= 1
+			}
+			buckets[level] = append(buckets[level], e)
+		}
+
+		for idx := g.base; idx < g.base+groupSize; idx++ {
+			if !m.position(idx, &p) {
+				continue
+			}
+			state[idx] = genStateOpen
+			mvs = m.moves(&p, mvs[:0])
+			if len(mvs) == 0 {
+				if m.inCheck(&p, p.stm) {
+					push(0, idx, true)
+				} else {
+					state[idx] = genStateDraw
+				}
+				continue
+			}
+			var quiet, winLevel, lossLevel int
+			noLoss := false
+			for _, mv := range mvs {
+				if mv.captured < 0 && m.pieces[mv.piece]&^tbBlackOffset != tbPawn {
+					quiet++
+					continue
+				}
+				switch m.exit(&p, mv) {
+				case WDLWin:
+					winLevel = 1
+				case WDLCursedWin:
+					if winLevel == 0 {
+						winLevel = 101
+					}
+				case WDLDraw:
+					noLoss = true
+				case WDLBlessedLoss:
+					lossLevel = 101
+				case WDLLoss:
+					if lossLevel == 0 {
+						lossLevel = 1
+					}
+				}
+			}
+			winExit[idx] = uint8(winLevel)
+			if quiet == 0 {
+				winExit[idx] = genNoLoss
+			}
+			if winLevel != 0 {
+				noLoss = true
+				m.dtz[idx] = int16(winLevel)
+				push(winLevel, idx, false)
+			}
+			if noLoss {
+				count[idx] = genNoLoss
+				continue
+			}
+			count[idx], lossExit[idx] = uint8(quiet), uint8(lossLevel)
+			if quiet == 0 {
+				push(lossLevel, idx, true)
+			}
+		}
+
+		for level := 0; level < len(buckets); level++ {
+			for _, e := range buckets[level] {
+				idx, loss := int(e>>1), e&1 != 0
+				if state[idx] != genStateOpen {
+					continue
+				}
+				m.position(idx, &p)
+				m.dtz[idx] = int16(level)
+				if loss {
+					state[idx] = genStateLoss
+					m.unmoves(&p, func(q int) {
+						if state[q] == genStateOpen && (m.dtz[q] == 0 || int(m.dtz[q]) > level+1) {
+							m.dtz[q] = int16(level + 1)
+							push(level+1, q, false)
+						}
+					})
+					continue
+				}
+				state[idx] = genStateWin
+				m.unmoves(&p, func(q int) {
+					if state[q] != genStateOpen || count[q] == genNoLoss {
+						return
+					}
+					count[q]--
+					if count[q] == 0 {
+						lossLevel := level + 1
+						if int(lossExit[q]) > lossLevel {
+							lossLevel = int(lossExit[q])
+						}
+						push(lossLevel, q, true)
+					}
+				})
+			}
+			buckets[level] = nil
+		}
+
+		for idx := g.base; idx < g.base+groupSize; idx++ {
+			switch state[idx] {
+			case genStateIllegal:
+				m.wdl[idx] = genIllegal
+			case genStateWin:
+				m.wdl[idx] = int8(WDLWin)
+				if m.dtz[idx] > 100 {
+					m.wdl[idx] = int8(WDLCursedWin)
+				}
+			case genStateLoss:
+				m.wdl[idx] = int8(WDLLoss)
+				if m.dtz[idx] > 100 {
+					m.wdl[idx] = int8(WDLBlessedLoss)
+				}
+			default:
+				m.wdl[idx], m.dtz[idx] = int8(WDLDraw), 0
+			}
+			m.zeroing[idx] = winExit[idx] == genNoLoss || int(winExit[idx]) == int(m.dtz[idx]) && m.wdl[idx] > 0
+		}
+	}
+}
+
+// result returns the WDL and DTZ of the position, as returned by the probes.
+func (m *genMaterial) result(idx int) (WDL, int) {
+	wdl, dtz := WDL(m.wdl[idx]), int(m.dtz[idx])
+	switch {
+	case wdl == WDLDraw:
+		return wdl, 0
+	case dtz == 0:
+		return wdl, -1 // mated
+	case wdl < WDLDraw:
+		return wdl, -dtz
+	default:
+		return wdl, dtz
+	}
+}
+
+func (m *genMaterial) longest(wdl WDL) int {
+	var longest int
+	for idx, w := range m.wdl {
+		if WDL(w) == wdl && int(m.dtz[idx]) > longest {
+			longest = int(m.dtz[idx])
+		}
+	}
+	return longest
+}
+
+func (m *genMaterial) fen(p *genPosition) string {
+	var sb strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			o := p.board[rank*8+file]
+			if o == 0 {
+				empty++
+				continue
+			}
+			if empty != 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			pc := m.pieces[o-1]
+			sym := tbPieceSymbols[pc&^tbBlackOffset]
+			if pc&tbBlackOffset != 0 {
+				sym += 'a' - 'A'
+			}
+			sb.WriteByte(sym)
+		}
+		if empty != 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if rank != 0 {
+			sb.WriteByte('/')
+		}
+	}
+	return sb.String() + map[int]string{0: " w - - 0 1", 1: " b - - 0 1"}[p.stm]
+}
+
+// genPairs is a pairsData being encoded, with the values by index; negative values are "don't care".
+type genPairs struct {
+	d      *pairsData
+	values []int
+
+	blockSizeLog, spanLog uint8
+	symbols               [][2]int // the value and 0xFFF for leaves, or the pair of symbols
+	lowestSym             []uint16
+	sparseIndex           [][2]int // block, offset
+	blockLengths          []int
+	data                  []byte
+}
+
+// encode encodes the WDL or DTZ table of the material. DTZ tables store the side to move compressing best,
+// and symmetric tables only White to move.
+func (m *genMaterial) encode(typ tableType) ([]byte, error) {
+	t := newTable(typ, m.code, m.code)
+	if typ == tableTypeDTZ && t.key != t.key2 {
+		var best []byte
+		for _, stm := range []int{0, 1} {
+			buf, err := m.encodeSide(newTable(typ, m.code, m.code), stm)
+			if err != nil {
+				return nil, err
+			}
+			if best == nil || len(buf) < len(best) {
+				best = buf
+			}
+		}
+		return best, nil
+	}
+	return m.encodeSide(t, 0)
+}
+
+func (m *genMaterial) encodeSide(t *table, dtzSide int) ([]byte, error) {
+	maxFile := 0
+	if t.hasPawns {
+		maxFile = 3
+	}
+	sides := t.sides()
+
+	// the pieces follow the code, with the leading Pawns first
+	var order []uint8
+	if t.hasPawns {
+		white, black := m.pawnsOf(0), m.pawnsOf(tbBlackOffset)
+		lead := tbPawn
+		if black != 0 && (white == 0 || black < white) {
+			lead |= tbBlackOffset
+		}
+		for _, pc := range m.pieces {
+			if pc == lead {
+				order = append(order, pc)
+			}
+		}
+	}
+	var color uint8
+	for i := 0; i < len(m.code); i++ {
+		if m.code[i] == 'v' {
+			color = tbBlackOffset
+			continue
+		}
+		pc := symbolToType(m.code[i]) | color
+		if len(order) == 0 || order[0] != pc {
+			order = append(order, pc)
+		}
+	}
+
+	pairs := make(map[*pairsData]*genPairs)
+	var all []*genPairs
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := &pairsData{}
+			copy(d.pieces[:], order)
+			t.setGroups(d, [2]int{0, 0x0F}, f)
+			if t.typ == tableTypeDTZ {
+				d.flags = uint8(dtzSide) | flagWinPlies | flagLossPlies
+			}
+			t.items[i][f] = d
+			size := 0
+			for k, l := range d.groupLen {
+				if l == 0 {
+					size = int(d.groupIdx[k])
+					break
+				}
+			}
+			gp := &genPairs{d: d, values: make([]int, size)}
+			for k := range gp.values {
+				gp.values[k] = -1
+			}
+			pairs[d] = gp
+			all = append(all, gp)
+		}
+	}
+
+	// DTZ values are mapped by frequency, for each WDL
+	var maps [4][]int
+	var mapped [4]map[int]int
+	if t.typ == tableTypeDTZ {
+		var freqs [4]map[int]int
+		for k := range freqs {
+			freqs[k] = make(map[int]int)
+		}
+		for idx, w := range m.wdl {
+			if w != genIllegal && WDL(w) != WDLDraw && idx&1 == dtzSide && !m.zeroing[idx] {
+				freqs[genMapIdx(WDL(w))][genStoredDTZ(WDL(w), int(m.dtz[idx]))]++
+			}
+		}
+		for k, freq := range freqs {
+			for v := range freq {
+				maps[k] = append(maps[k], v)
+			}
+			sort.Slice(maps[k], func(i, j int) bool {
+				a, b := maps[k][i], maps[k][j]
+				return freq[a] > freq[b] || freq[a] == freq[b] && a < b
+			})
+			if len(maps[k]) > 0xFF {
+				return nil, fmt.Errorf("DTZ map too large: %s", m.code)
+			}
+			mapped[k] = make(map[int]int)
+			for i, v := range maps[k] {
+				mapped[k][v] = i
+			}
+		}
+	}
+
+	var p genPosition
+	var cells [MaxPieces]position.Pos
+	var pieces [MaxPieces]uint8
+	for idx, w := range m.wdl {
+		if w == genIllegal || t.key == t.key2 && idx&1 != 0 || t.typ == tableTypeDTZ && (WDL(w) == WDLDraw || idx&1 != dtzSide || m.zeroing[idx]) {
+			continue
+		}
+		m.position(idx, &p)
+		size, leadPawnsCount := 0, 0
+		for pass := 0; pass < 2; pass++ {
+			for i, pc := range m.pieces {
+				if lead := t.hasPawns && pc == order[0]; lead == (pass == 0) {
+					cells[size], pieces[size] = position.Pos(p.cells[i]), pc
+					size++
+				}
+			}
+			if pass == 0 {
+				leadPawnsCount = size
+			}
+		}
+		d, _, tbIdx, ok := t.index(cells[:size], pieces[:size], leadPawnsCount, p.stm)
+		if !ok {
+			return nil, fmt.Errorf("unexpected side to move: %s", m.fen(&p))
+		}
+		value := int(w) + 2
+		if t.typ == tableTypeDTZ {
+			k := genMapIdx(WDL(w))
+			stored := genStoredDTZ(WDL(w), int(m.dtz[idx]))
+			value = mapped[k][stored]
+		}
+		gp := pairs[d]
+		if old := gp.values[tbIdx]; old >= 0 && old != value {
+			return nil, fmt.Errorf("inconsistent index %d of %s: %s", tbIdx, m.code, m.fen(&p))
+		}
+		gp.values[tbIdx] = value
+	}
+	for _, gp := range all {
+		if err := gp.compress(); err != nil {
+			return nil, fmt.Errorf("%s: %w", m.code, err)
+		}
+	}
+	if t.typ == tableTypeDTZ {
+		for _, gp := range all {
+			gp.d.flags |= flagMapped
+		}
+	}
+
+	// header
+	var buf bytes.Buffer
+	magic := magicWDL
+	if t.typ == tableTypeDTZ {
+		magic = magicDTZ
+	}
+	buf.Write(magic[:])
+	var header uint8
+	if t.key != t.key2 {
+		header |= 1 // split
+	}
+	if t.hasPawns {
+		header |= 2
+	}
+	buf.WriteByte(header)
+	for f := 0; f <= maxFile; f++ {
+		buf.WriteByte(0) // leading group first for both sides
+		for k := range order {
+			buf.WriteByte(order[k] | order[k]<<4)
+		}
+	}
+	genAlign(&buf, 2)
+
+	// sizes, and the canonical Huffman codes and pairs of the symbols
+	for _, gp := range all {
+		buf.WriteByte(gp.d.flags)
+		if gp.d.flags&flagSingleValue != 0 {
+			buf.WriteByte(uint8(gp.values[0]))
+			continue
+		}
+		buf.Write([]byte{gp.blockSizeLog, gp.spanLog, 0})
+		_ = binary.Write(&buf, binary.LittleEndian, uint32(len(gp.blockLengths)))
+		buf.Write([]byte{gp.d.maxSymLen, gp.d.minSymLen})
+		_ = binary.Write(&buf, binary.LittleEndian, gp.lowestSym)
+		_ = binary.Write(&buf, binary.LittleEndian, uint16(len(gp.symbols)))
+		for _, sym := range gp.symbols {
+			buf.Write([]byte{uint8(sym[0]), uint8(sym[0]>>8&0x0F | sym[1]<<4), uint8(sym[1] >> 4)})
+		}
+		genAlign(&buf, 2)
+	}
+
+	if t.typ == tableTypeDTZ {
+		for f := 0; f <= maxFile; f++ {
+			for _, k := range []int{0, 1, 2, 3} {
+				buf.WriteByte(uint8(len(maps[k])))
+				for _, v := range maps[k] {
+					buf.WriteByte(uint8(v))
+				}
+			}
+		}
+		genAlign(&buf, 2)
+	}
+
+	for _, gp := range all {
+		for _, e := range gp.sparseIndex {
+			_ = binary.Write(&buf, binary.LittleEndian, uint32(e[0]))
+			_ = binary.Write(&buf, binary.LittleEndian, uint16(e[1]))
+		}
+	}
+	for _, gp := range all {
+		for _, l := range gp.blockLengths {
+			_ = binary.Write(&buf, binary.LittleEndian, uint16(l-1))
+		}
+	}
+	for _, gp := range all {
+		genAlign(&buf, 64)
+		buf.Write(gp.data)
+	}
+	buf.Write(make([]byte, 8)) // the decoder reads ahead of the last block
+	return buf.Bytes(), nil
+}
+
+func (m *genMaterial) pawnsOf(color uint8) int {
+	var count int
+	for _, pc := range m.pieces {
+		if pc == tbPawn|color {
+			count++
+		}
+	}
+	return count
+}
+
+// genMapIdx returns the DTZ map of the WDL, following the order of pairsData.mapIdx.
+func genMapIdx(wdl WDL) int {
+	return [5]int{1, 3, 0, 2, 0}[wdl+2]
+}
+
+// genStoredDTZ returns the DTZ as stored: in plies for wins and losses, and in moves past 100 plies for
+// cursed wins and blessed losses.
+func genStoredDTZ(wdl WDL, dtz int) int {
+	switch {
+	case wdl == WDLCursedWin || wdl == WDLBlessedLoss:
+		return (dtz - 101) / 2
+	case dtz == 0:
+		return 0 // mated
+	default:
+		return dtz - 1
+	}
+}
+
+func genAlign(buf *bytes.Buffer, n int) {
+	for buf.Len()%n != 0 {
+		buf.WriteByte(0)
+	}
+}
+
+// compress replaces the most frequent pairs of symbols by new symbols, then encodes the symbols with a
+// canonical Huffman code in blocks. The "don't care" values repeat the previous value.
+func (gp *genPairs) compress() error {
+	prev := 0
+	for _, v := range gp.values {
+		if v >= 0 {
+			prev = v
+			break
+		}
+	}
+	single := true
+	for i, v := range gp.values {
+		if v < 0 {
+			gp.values[i] = prev
+		}
+		single = single && gp.values[i] == gp.values[0]
+		prev = gp.values[i]
+	}
+	if single {
+		gp.d.flags |= flagSingleValue
+		return nil
+	}
+
+	const maxSymbols = 0xFFF
+	seq := make([]int, len(gp.values))
+	leaves := make(map[int]int)
+	var symlen []int
+	for i, v := range gp.values {
+		s, ok := leaves[v]
+		if !ok {
+			s = len(gp.symbols)
+			leaves[v] = s
+			gp.symbols = append(gp.symbols, [2]int{v, 0xFFF})
+			symlen = append(symlen, 0)
+		}
+		seq[i] = s
+	}
+	counts := make([]int32, maxSymbols*maxSymbols)
+	var touched []int
+	for len(gp.symbols) < maxSymbols {
+		for _, k := range touched {
+			counts[k] = 0
+		}
+		touched = touched[:0]
+		best := -1
+		for i, last := 0, -1; i+1 < len(seq); i++ {
+			a, b := seq[i], seq[i+1]
+			if a == b && last == i-1 {
+				continue // overlapping
+			}
+			if symlen[a]+symlen[b]+1 > 0xFF {
+				continue
+			}
+			k := a*maxSymbols + b
+			if counts[k] == 0 {
+				touched = append(touched, k)
+			}
+			counts[k]++
+			if a == b {
+				last = i
+			}
+			if best < 0 || counts[k] > counts[best] {
+				best = k
+			}
+		}
+		if best < 0 || counts[best] < 4 {
+			break
+		}
+		a, b := best/maxSymbols, best%maxSymbols
+		s := len(gp.symbols)
+		gp.symbols = append(gp.symbols, [2]int{a, b})
+		symlen = append(symlen, symlen[a]+symlen[b]+1)
+		out := seq[:0]
+		for i := 0; i < len(seq); i++ {
+			if i+1 < len(seq) && seq[i] == a && seq[i+1] == b {
+				out = append(out, s)
+				i++
+				continue
+			}
+			out = append(out, seq[i])
+		}
+		seq = out
+	}
+
+	freq := make([]int, len(gp.symbols))
+	for _, s := range seq {
+		freq[s]++
+	}
+	lengths := genHuffman(freq)
+
+	// number the symbols by decreasing code length, the symbols without a code first
+	minLen, maxLen := 64, 0
+	for _, l := range lengths {
+		if l != 0 && l < minLen {
+			minLen = l
+		}
+		if l > maxLen {
+			maxLen = l
+		}
+	}
+	ids := make([]int, len(gp.symbols))
+	for i := range ids {
+		ids[i] = i
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		li, lj := lengths[ids[i]], lengths[ids[j]]
+		return li == 0 && lj != 0 || li != 0 && lj != 0 && li > lj
+	})
+	number := make([]int, len(gp.symbols))
+	for n, s := range ids {
+		number[s] = n
+	}
+	symbols := make([][2]int, len(gp.symbols))
+	for s, sym := range gp.symbols {
+		if sym[1] != 0xFFF {
+			sym = [2]int{number[sym[0]], number[sym[1]]}
+		}
+		symbols[number[s]] = sym
+	}
+	gp.symbols = symbols
+	for i, s := range seq {
+		seq[i] = number[s]
+	}
+	numberedLen := make([]int, len(lengths))
+	numberedLen2 := make([]int, len(lengths))
+	for s, l := range lengths {
+		numberedLen[number[s]] = l
+		numberedLen2[number[s]] = symlen[s]
+	}
+	lengths, symlen = numberedLen, numberedLen2
+
+	count := make([]int, maxLen+2)
+	for _, l := range lengths {
+		if l != 0 {
+			count[l]++
+		}
+	}
+	lowest := make([]int, maxLen+2)
+	base := make([]uint64, maxLen+2)
+	lowest[maxLen] = len(lengths)
+	for _, l := range lengths {
+		if l != 0 {
+			lowest[maxLen]--
+		}
+	}
+	for l := maxLen - 1; l >= minLen; l-- {
+		lowest[l] = lowest[l+1] + count[l+1]
+		if (base[l+1]+uint64(count[l+1]))%2 != 0 {
+			return fmt.Errorf("incomplete Huffman code")
+		}
+		base[l] = (base[l+1] + uint64(count[l+1])) / 2
+	}
+	gp.d.minSymLen, gp.d.maxSymLen = uint8(minLen), uint8(maxLen)
+	if base[minLen]+uint64(count[minLen]) != 1<<minLen {
+		return fmt.Errorf("incomplete Huffman code")
+	}
+	for l := minLen; l <= maxLen; l++ {
+		gp.lowestSym = append(gp.lowestSym, uint16(lowest[l]))
+	}
+
+	// pack the codes in blocks, and index every span values
+	gp.blockSizeLog, gp.spanLog = 6, 10
+	blockSize, span := 1<<gp.blockSizeLog, 1<<gp.spanLog
+	var block []byte
+	var bits, values int
+	var starts []int // first value of each block
+	flush := func() {
+		gp.data = append(gp.data, block...)
+		gp.data = append(gp.data, make([]byte, blockSize-len(block))...)
+		gp.blockLengths = append(gp.blockLengths, values)
+		block, bits, values = block[:0], 0, 0
+	}
+	total := 0
+	for _, s := range seq {
+		l := lengths[s]
+		if bits+l > blockSize*8 || values+symlen[s]+1 > 0x10000-span {
+			flush()
+		}
+		if values == 0 {
+			starts = append(starts, total)
+		}
+		code := base[l] + uint64(s-lowest[l])
+		for k := l - 1; k >= 0; k-- {
+			if bits%8 == 0 {
+				block = append(block, 0)
+			}
+			if code>>k&1 != 0 {
+				block[bits/8] |= 0x80 >> (bits % 8)
+			}
+			bits++
+		}
+		values += symlen[s] + 1
+		total += symlen[s] + 1
+	}
+	flush()
+	if total != len(gp.values) {
+		return fmt.Errorf("unexpected value count: got=%d want=%d", total, len(gp.values))
+	}
+	for k := 0; k*span < len(gp.values); k++ {
+		target := k*span + span/2
+		anchor := target
+		if anchor >= len(gp.values) {
+			anchor = len(gp.values) - 1
+		}
+		b := sort.SearchInts(starts, anchor+1) - 1
+		if target-starts[b] > 0xFFFF {
+			return fmt.Errorf("sparse index out of range")
+		}
+		gp.sparseIndex = append(gp.sparseIndex, [2]int{b, target - starts[b]})
+	}
+	return nil
+}
+
+// genHuffman returns the Huffman code lengths of the frequencies, limited to 32 bits. At least two symbols
+// are given a code.
+func genHuffman(freq []int) []int {
+	freq = append([]int{}, freq...)
+	used := 0
+	for _, f := range freq {
+		if f != 0 {
+			used++
+		}
+	}
+	for s := 0; used < 2; s++ {
+		if freq[s] == 0 {
+			freq[s] = 1
+			used++
+		}
+	}
+	for {
+		lengths := make([]int, len(freq))
+		h := &genHeap{}
+		for s, f := range freq {
+			if f != 0 {
+				heap.Push(h, genNode{freq: f, symbols: []int{s}})
+			}
+		}
+		for h.Len() > 1 {
+			a, b := heap.Pop(h).(genNode), heap.Pop(h).(genNode)
+			for _, s := range append(a.symbols, b.symbols...) {
+				lengths[s]++
+			}
+			heap.Push(h, genNode{freq: a.freq + b.freq, symbols: append(append([]int{}, a.symbols...), b.symbols...)})
+		}
+		maxLen := 0
+		for _, l := range lengths {
+			if l > maxLen {
+				maxLen = l
+			}
+		}
+		if maxLen <= 32 {
+			return lengths
+		}
+		for s := range freq {
+			if freq[s] != 0 {
+				freq[s] = freq[s]/2 + 1
+			}
+		}
+	}
+}
+
+type genNode struct {
+	freq    int
+	symbols []int
+}
+
+type genHeap []genNode
+
+func (h genHeap) Len() int            { return len(h) }
+func (h genHeap) Less(i, j int) bool  { return h[i].freq < h[j].freq }
+func (h genHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *genHeap) Push(x interface{}) { *h = append(*h, x.(genNode)) }
+func (h *genHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func genAbs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func genSign(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,159 @@
+package tablebase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/daystram/gambit/board"
+)
+
+// testTables are the tables in testdata, see TestGenerateTables.
+var testTables = []string{"KBvK", "KNvK", "KQvK", "KRvK", "KPvK", "KRvKQ", "KRvKR", "KRvKB", "KRvKN", "KRvKP"}
+
+const testdataPath = "testdata"
+
+// openTestSyzygy opens the tables in testdata.
+func openTestSyzygy(t *testing.T) *Syzygy {
+	t.Helper()
+	tb, err := NewSyzygy(testdataPath)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	t.Cleanup(func() { _ = tb.Close() })
+	return tb
+}
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+	tb := openTestSyzygy(t)
+
+	tests := []struct {
+		name    string
+		fen     string
+		wantWDL WDL
+		wantDTZ int // checked if not zero, or for draws
+	}{
+		{name: "KQvK win", fen: "4k3/8/8/8/8/8/8/Q3K3 w - - 0 1", wantWDL: WDLWin},
+		{name: "KQvK loss", fen: "4k3/8/8/8/8/8/8/Q3K3 b - - 0 1", wantWDL: WDLLoss},
+		{name: "KRvK win", fen: "8/8/8/4k3/8/8/8/R3K3 w - - 0 1", wantWDL: WDLWin},
+		{name: "KRvK rook hanging", fen: "8/8/8/8/8/8/3k4/3R3K b - - 0 1", wantWDL: WDLDraw, wantDTZ: 0},
+		{name: "KPvK opposition win", fen: "8/4k3/8/4K3/4P3/8/8/8 b - - 0 1", wantWDL: WDLLoss},
+		{name: "KPvK opposition draw", fen: "8/4k3/8/4K3/4P3/8/8/8 w - - 0 1", wantWDL: WDLDraw, wantDTZ: 0},
+		{name: "KPvK rook pawn draw", fen: "k7/8/K7/P7/8/8/8/8 w - - 0 1", wantWDL: WDLDraw, wantDTZ: 0},
+		{name: "KQvK mate in one", fen: "k7/8/1K6/8/8/8/8/6Q1 w - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+		{name: "KQvK mated", fen: "k6Q/8/1K6/8/8/8/8/8 b - - 0 1", wantWDL: WDLLoss, wantDTZ: -1},
+		{name: "KRvK mate in one", fen: "k7/8/1K6/8/8/8/8/7R w - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+		{name: "KRvK stalemate", fen: "k7/8/K7/8/8/8/8/1R6 b - - 0 1", wantWDL: WDLDraw, wantDTZ: 0},
+		{name: "KPvK promotion", fen: "8/4P3/8/8/8/k7/8/K7 w - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+		{name: "KRvKP capture", fen: "8/8/8/8/8/3k4/p7/R3K3 w - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+		{name: "KRvKP black capture", fen: "r3k3/P7/3K4/8/8/8/8/8 b - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+		{name: "KRvKP draw", fen: "7K/8/8/8/8/8/1pk5/7R w - - 0 1", wantWDL: WDLDraw, wantDTZ: 0},
+		{name: "KRvKR white capture", fen: "4k3/8/8/8/8/8/8/rR2K3 w - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+		{name: "KRvKR black capture", fen: "4k3/8/8/8/8/8/8/rR2K3 b - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+		{name: "KRvKQ rook capture", fen: "4k3/8/8/8/8/8/8/qR2K3 w - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+		{name: "KRvKQ queen capture", fen: "4k3/8/8/8/8/8/8/qR2K3 b - - 0 1", wantWDL: WDLWin, wantDTZ: 1},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			wdl, err := tb.ProbeWDL(b)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if wdl != tt.wantWDL {
+				t.Errorf("unexpected WDL: got=%s want=%s", wdl, tt.wantWDL)
+			}
+
+			dtz, err := tb.ProbeDTZ(b)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if signOf(dtz) != signOf(int(tt.wantWDL)) {
+				t.Errorf("unexpected DTZ sign: got=%d want=%s", dtz, tt.wantWDL)
+			}
+			if (tt.wantDTZ != 0 || tt.wantWDL == WDLDraw) && dtz != tt.wantDTZ {
+				t.Errorf("unexpected DTZ: got=%d want=%d", dtz, tt.wantDTZ)
+			}
+		})
+	}
+}
+
+func TestProbeRoot(t *testing.T) {
+	t.Parallel()
+	tb := openTestSyzygy(t)
+
+	tests := []struct {
+		name    string
+		fen     string
+		wantWDL WDL // of the best moves, for the side to move
+	}{
+		{name: "KQvK", fen: "4k3/8/8/8/8/8/8/Q3K3 w - - 0 1", wantWDL: WDLWin},
+		{name: "KRvK rook hanging", fen: "8/8/8/8/8/8/3k4/3R3K b - - 0 1", wantWDL: WDLDraw},
+		{name: "KPvK opposition draw", fen: "8/4k3/8/4K3/4P3/8/8/8 w - - 0 1", wantWDL: WDLDraw},
+		{name: "KPvK key square", fen: "8/3k4/8/4K3/4P3/8/8/8 w - - 0 1", wantWDL: WDLWin},
+		{name: "KRvKP draw", fen: "7K/8/8/8/8/8/1pk5/7R w - - 0 1", wantWDL: WDLDraw},
+		{name: "KRvKR black capture", fen: "4k3/8/8/8/8/8/8/rR2K3 b - - 0 1", wantWDL: WDLWin},
+		{name: "KRvKQ queen capture", fen: "4k3/8/8/8/8/8/8/qR2K3 b - - 0 1", wantWDL: WDLWin},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			rms, err := tb.ProbeRoot(b, true)
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			if got, want := len(rms), len(b.GenerateLegalMoves(nil)); got != want {
+				t.Errorf("unexpected root move count: got=%d want=%d", got, want)
+			}
+
+			best := BestRootMoves(rms)
+			if len(best) == 0 {
+				t.Fatal("unexpected best root moves: got=0")
+			}
+			for _, mv := range best {
+				unApply, _ := b.Apply(mv)
+				wdl, err := tb.ProbeWDL(b)
+				unApply()
+				if err != nil {
+					t.Fatal("unexpected error:", err)
+				}
+				if wdl != -tt.wantWDL {
+					t.Errorf("unexpected WDL after %s: got=%s want=%s", mv, wdl, -tt.wantWDL)
+				}
+			}
+		})
+	}
+}
+
+func TestProbeAfterClose(t *testing.T) {
+	t.Parallel()
+	tb, err := NewSyzygy(testdataPath)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	b, err := board.NewBoard(board.WithFEN("4k3/8/8/8/8/8/8/Q3K3 w - - 0 1"))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if _, err := tb.ProbeWDL(b); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if err := tb.Close(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if _, err := tb.ProbeWDL(b); !errors.Is(err, ErrClosed) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,94 @@
+package tablebase
+
+import (
+	"github.com/daystram/gambit/board"
+)
+
+const (
+	rankWin  = 1000
+	rankLoss = -1000
+)
+
+// RootMove is a legal move at the root, ranked by its DTZ. Certain wins are ranked equally, and losing
+// moves are ranked equally unless a draw by the fifty move rule is in sight.
+type RootMove struct {
+	Move board.Move
+	DTZ  int
+	Rank int
+}
+
+// ProbeRoot ranks the legal moves of the board using the DTZ tables, counting the half move clock of the
+// board towards the fifty move rule. If rule50 is false, cursed wins and blessed losses are ranked as
+// wins and losses.
+func (t *Syzygy) ProbeRoot(b *board.Board, rule50 bool) ([]RootMove, error) {
+	if err := t.check(b); err != nil {
+		return nil, err
+	}
+
+	var rms []RootMove
+	halfMoveClock := int(b.HalfMoveClock())
+	for _, mv := range b.GeneratePseudoLegalMoves() {
+		unApply, ok := b.Apply(mv)
+		if !ok {
+			unApply()
+			continue
+		}
+
+		var dtz int
+		var err error
+		if b.HalfMoveClock() == 0 {
+			// zeroing move
+			var wdl WDL
+			wdl, _, err = t.search(b, false)
+			dtz = dtzBeforeZeroing(-wdl)
+		} else {
+			dtz, err = t.probeDTZ(b)
+			dtz = -dtz
+			dtz += signOf(dtz)
+		}
+
+		// mating move
+		if dtz == 2 && b.IsKingChecked(b.Turn()) && !b.HasLegalMoves() {
+			dtz = 1
+		}
+		unApply()
+		if err != nil {
+			return nil, err
+		}
+
+		rank := 0
+		switch {
+		case dtz > 0:
+			rank = rankWin
+			if dtz+halfMoveClock > 99 {
+				rank = rankWin - (dtz + halfMoveClock)
+			}
+		case dtz < 0:
+			rank = rankLoss
+			if -dtz*2+halfMoveClock >= 100 {
+				rank = rankLoss + (-dtz + halfMoveClock)
+			}
+		}
+		if !rule50 && rank != 0 {
+			rank = rankWin * signOf(rank)
+		}
+		rms = append(rms, RootMove{Move: mv, DTZ: dtz, Rank: rank})
+	}
+	return rms, nil
+}
+
+// BestRootMoves returns the moves with the highest rank.
+func BestRootMoves(rms []RootMove) []board.Move {
+	var mvs []board.Move
+	bestRank := rankLoss - 1
+	for _, rm := range rms {
+		if rm.Rank > bestRank {
+			bestRank = rm.Rank
+			mvs = mvs[:0]
+		}
+		if rm.Rank == bestRank {
+			mvs = append(mvs, rm.Move)
+		}
+	}
+	return mvs
+}
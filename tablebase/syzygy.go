@@ -0,0 +1,406 @@
+package tablebase
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/position"
+)
+
+var (
+	ErrTableNotFound  = errors.New("table not found")
+	ErrCorruptedTable = errors.New("corrupted table")
+	ErrCastling       = errors.New("castling rights present")
+	ErrClosed         = errors.New("tablebase closed")
+)
+
+// WDL is the result of a position with perfect play, relative to the side to move.
+type WDL int8
+
+const (
+	// WDLLoss is a loss.
+	WDLLoss WDL = iota - 2
+
+	// WDLBlessedLoss is a loss, but a draw under the 50 move rule.
+	WDLBlessedLoss
+
+	// WDLDraw is a draw.
+	WDLDraw
+
+	// WDLCursedWin is a win, but a draw under the 50 move rule.
+	WDLCursedWin
+
+	// WDLWin is a win.
+	WDLWin
+)
+
+func (w WDL) String() string {
+	switch w {
+	case WDLLoss:
+		return "Loss"
+	case WDLBlessedLoss:
+		return "BlessedLoss"
+	case WDLDraw:
+		return "Draw"
+	case WDLCursedWin:
+		return "CursedWin"
+	case WDLWin:
+		return "Win"
+	default:
+		return ""
+	}
+}
+
+// Syzygy probes Syzygy endgame tablebase files. The files are memory mapped on first access.
+// Syzygy is safe for concurrent use, as long as each goroutine probes its own board.
+type Syzygy struct {
+	wdl       map[uint64]*table
+	dtz       map[uint64]*table
+	maxPieces int
+}
+
+// NewSyzygy loads the tables found in the directories of the path list, separated by the OS path list
+// separator. Only the file names are inspected; the files are read on first probe.
+func NewSyzygy(paths string) (*Syzygy, error) {
+	t := &Syzygy{
+		wdl: make(map[uint64]*table),
+		dtz: make(map[uint64]*table),
+	}
+	if paths == "" || paths == "<empty>" {
+		return t, nil
+	}
+
+	for _, dir := range filepath.SplitList(paths) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			var typ tableType
+			var tables map[uint64]*table
+			switch filepath.Ext(name) {
+			case extensionWDL:
+				typ, tables = tableTypeWDL, t.wdl
+			case extensionDTZ:
+				typ, tables = tableTypeDTZ, t.dtz
+			default:
+				continue
+			}
+			code, ok := tableCode(name)
+			if !ok || entry.IsDir() {
+				continue
+			}
+			tb := newTable(typ, filepath.Join(dir, name), code)
+			if _, ok := tables[tb.key]; ok {
+				continue // first path takes precedence
+			}
+			tables[tb.key] = tb
+			tables[tb.key2] = tb
+			if typ == tableTypeWDL && tb.pieceCount > t.maxPieces {
+				t.maxPieces = tb.pieceCount
+			}
+		}
+	}
+	return t, nil
+}
+
+// MaxPieces returns the largest number of pieces, including Kings, of the available WDL tables.
+func (t *Syzygy) MaxPieces() int {
+	if t == nil {
+		return 0
+	}
+	return t.maxPieces
+}
+
+// Close unmaps the loaded files, waiting for the probes in progress. Probing afterwards returns ErrClosed.
+func (t *Syzygy) Close() error {
+	var errs []string
+	for _, tables := range []map[uint64]*table{t.wdl, t.dtz} {
+		for key, tb := range tables {
+			if key != tb.key {
+				continue // also stored under key2
+			}
+			if err := tb.close(); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) != 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ProbeWDL returns the WDL result of the board, relative to the side to move. The board must not have any
+// castling rights. The fifty move rule is only considered through the cursed and blessed results, which
+// assume the half move clock has just been reset.
+func (t *Syzygy) ProbeWDL(b *board.Board) (WDL, error) {
+	if err := t.check(b); err != nil {
+		return WDLDraw, err
+	}
+	wdl, _, err := t.search(b, false)
+	return wdl, err
+}
+
+// ProbeDTZ returns the distance to zeroing of the half move clock in plies, with a positive sign if the
+// side to move wins, negative if it loses, and zero if the position is drawn. Cursed wins and blessed
+// losses are offset by 100.
+func (t *Syzygy) ProbeDTZ(b *board.Board) (int, error) {
+	if err := t.check(b); err != nil {
+		return 0, err
+	}
+	return t.probeDTZ(b)
+}
+
+func (t *Syzygy) check(b *board.Board) error {
+	if b.CastleRights() != 0 {
+		return ErrCastling
+	}
+	if count := bits.OnesCount64(occupancy(b, board.SideUnknown, board.PieceUnknown)); count > t.MaxPieces() {
+		return fmt.Errorf("%w: %d pieces", ErrTableNotFound, count)
+	}
+	return nil
+}
+
+// probeTable probes the WDL or DTZ table of the board.
+func (t *Syzygy) probeTable(b *board.Board, typ tableType, wdl WDL) (int, bool, error) {
+	key := materialKey(b)
+	if bits.OnesCount64(occupancy(b, board.SideUnknown, board.PieceUnknown)) == 2 {
+		return int(WDLDraw), true, nil // KvK
+	}
+
+	tables := t.wdl
+	if typ == tableTypeDTZ {
+		tables = t.dtz
+	}
+	tb, ok := tables[key]
+	if !ok {
+		return 0, false, ErrTableNotFound
+	}
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+	if err := tb.load(); err != nil {
+		return 0, false, err
+	}
+
+	var value int
+	var sameStm bool
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%w: %s: %v", ErrCorruptedTable, filepath.Base(tb.path), r)
+			}
+		}()
+		value, sameStm = tb.probe(b, key, wdl)
+	}()
+	return value, sameStm, err
+}
+
+// search probes the table, taking into account the captures (and Pawn moves when zeroing) which the
+// tables may not store correctly. The second return value is true if the best move is a zeroing move.
+func (t *Syzygy) search(b *board.Board, checkZeroing bool) (WDL, bool, error) {
+	bestValue := WDLLoss
+	var moveCount, totalCount int
+
+	for _, mv := range b.GeneratePseudoLegalMoves() {
+		unApply, ok := b.Apply(mv)
+		if !ok {
+			unApply()
+			continue
+		}
+		totalCount++
+		if !mv.IsCapture && (!checkZeroing || mv.Piece != board.PiecePawn) {
+			unApply()
+			continue
+		}
+		moveCount++
+
+		value, _, err := t.search(b, false)
+		value = -value
+		unApply()
+		if err != nil {
+			return WDLDraw, false, err
+		}
+
+		if value > bestValue {
+			bestValue = value
+			if value >= WDLWin {
+				return value, true, nil // winning zeroing move
+			}
+		}
+	}
+
+	// if all legal moves have been searched, the stored value may be wrong, e.g. en passant is not stored
+	noMoreMoves := moveCount != 0 && moveCount == totalCount
+
+	var value WDL
+	if noMoreMoves {
+		value = bestValue
+	} else {
+		v, _, err := t.probeTable(b, tableTypeWDL, WDLDraw)
+		if err != nil {
+			return WDLDraw, false, err
+		}
+		value = WDL(v)
+	}
+
+	// the table stores a "don't care" value if the best value is a win
+	if bestValue >= value {
+		return bestValue, bestValue > WDLDraw || noMoreMoves, nil
+	}
+	return value, false, nil
+}
+
+func (t *Syzygy) probeDTZ(b *board.Board) (int, error) {
+	wdl, zeroing, err := t.search(b, true)
+	if err != nil || wdl == WDLDraw { // DTZ tables do not store draws
+		return 0, err
+	}
+
+	// the stored value cannot be used when the best move is a zeroing move
+	if zeroing {
+		return dtzBeforeZeroing(wdl), nil
+	}
+
+	dtz, sameStm, err := t.probeTable(b, tableTypeDTZ, wdl)
+	if err != nil {
+		return 0, err
+	}
+	if sameStm {
+		if wdl == WDLBlessedLoss || wdl == WDLCursedWin {
+			dtz += 100
+		}
+		return dtz * signOf(int(wdl)), nil
+	}
+
+	// the table stores the other side to move, search 1 ply to find the winning move minimizing DTZ
+	minDTZ := 0xFFFF
+	for _, mv := range b.GeneratePseudoLegalMoves() {
+		zeroingMove := mv.IsCapture || mv.Piece == board.PiecePawn
+		unApply, ok := b.Apply(mv)
+		if !ok {
+			unApply()
+			continue
+		}
+
+		// for zeroing moves, take the DTZ before the move is made
+		if zeroingMove {
+			var childWDL WDL
+			childWDL, _, err = t.search(b, false)
+			dtz = -dtzBeforeZeroing(childWDL)
+		} else {
+			dtz, err = t.probeDTZ(b)
+			dtz = -dtz
+		}
+
+		// mating move
+		if dtz == 1 && b.IsKingChecked(b.Turn()) && !b.HasLegalMoves() {
+			minDTZ = 1
+		}
+
+		if !zeroingMove {
+			dtz += signOf(dtz)
+		}
+		if dtz < minDTZ && signOf(dtz) == signOf(int(wdl)) {
+			minDTZ = dtz
+		}
+		unApply()
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// no legal moves, position is mate
+	if minDTZ == 0xFFFF {
+		return -1, nil
+	}
+	return minDTZ, nil
+}
+
+// dtzBeforeZeroing recovers the DTZ of the move before a zeroing move, from the WDL of the position.
+func dtzBeforeZeroing(wdl WDL) int {
+	switch wdl {
+	case WDLWin:
+		return 1
+	case WDLCursedWin:
+		return 101
+	case WDLBlessedLoss:
+		return -101
+	case WDLLoss:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func signOf(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// materialKey packs the piece counts of both sides into a key.
+func materialKey(b *board.Board) uint64 {
+	var key uint64
+	for _, s := range []board.Side{board.SideWhite, board.SideBlack} {
+		for _, p := range []board.Piece{board.PiecePawn, board.PieceKnight, board.PieceBishop, board.PieceRook, board.PieceQueen, board.PieceKing} {
+			key += uint64(bits.OnesCount64(occupancy(b, s, p))) << materialKeyShift(tbPiece(s, p))
+		}
+	}
+	return key
+}
+
+// materialKeyFromCode computes the material key of the table code, with the first side as the given side.
+func materialKeyFromCode(code string, first board.Side) uint64 {
+	var key uint64
+	var offset uint8
+	if first == board.SideBlack {
+		offset = tbBlackOffset
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] == 'v' {
+			offset ^= tbBlackOffset
+			continue
+		}
+		key += 1 << materialKeyShift(symbolToType(code[i])|offset)
+	}
+	return key
+}
+
+func materialKeyShift(tbPiece uint8) uint64 {
+	return uint64(tbPiece) * 4
+}
+
+// occupancy returns the cells occupied by the side and piece; unknown side or piece matches any.
+func occupancy(b *board.Board, s board.Side, p board.Piece) uint64 {
+	var bm uint64
+	for _, side := range []board.Side{board.SideWhite, board.SideBlack} {
+		if s != board.SideUnknown && s != side {
+			continue
+		}
+		for piece := board.PiecePawn; piece <= board.PieceKing; piece++ {
+			if p != board.PieceUnknown && p != piece {
+				continue
+			}
+			bm |= uint64(b.GetBitmap(side, piece))
+		}
+	}
+	return bm
+}
+
+func lsb(bm uint64) position.Pos {
+	return position.Pos(bits.TrailingZeros64(bm))
+}
@@ -0,0 +1,241 @@
+package tablebase
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/daystram/gambit/board"
+)
+
+func TestTableCode(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		wantCode string
+		wantOK   bool
+	}{
+		{name: "KQvK.rtbw", wantCode: "KQvK", wantOK: true},
+		{name: "KRPvKR.rtbz", wantCode: "KRPvKR", wantOK: true},
+		{name: "KQRBNPvK.rtbw", wantCode: "KQRBNPvK", wantOK: true},
+		{name: "KQRBNPPvK.rtbw", wantOK: false},
+		{name: "KQK.rtbw", wantOK: false},
+		{name: "KvvK.rtbw", wantOK: false},
+		{name: "QvK.rtbw", wantOK: false},
+		{name: "KXvK.rtbw", wantOK: false},
+		{name: "KQv.rtbw", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			gotCode, gotOK := tableCode(tt.name)
+			if gotOK != tt.wantOK {
+				t.Fatalf("unexpected ok: got=%v want=%v", gotOK, tt.wantOK)
+			}
+			if gotCode != tt.wantCode {
+				t.Errorf("unexpected code: got=%s want=%s", gotCode, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMaterialKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		fen  string
+		code string
+	}{
+		{fen: "8/8/3k4/8/8/8/8/3QK3 w - - 0 1", code: "KQvK"},
+		{fen: "8/8/3kr3/8/8/8/8/3QK3 b - - 0 1", code: "KQvKR"},
+		{fen: "8/8/3kq3/8/8/8/8/3RK3 w - - 0 1", code: "KQvKR"},
+		{fen: "8/8/3k4/8/8/8/2P5/3RK3 w - - 0 1", code: "KRPvK"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.fen, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			tb := newTable(tableTypeWDL, tt.code+extensionWDL, tt.code)
+			if key := materialKey(b); key != tb.key && key != tb.key2 {
+				t.Errorf("unexpected material key: got=%x want=%x or %x", key, tb.key, tb.key2)
+			}
+		})
+	}
+}
+
+func TestInitMaps(t *testing.T) {
+	t.Parallel()
+	var kkCount int
+	for _, row := range mapKK {
+		for _, code := range row {
+			if code+1 > kkCount {
+				kkCount = code + 1
+			}
+		}
+	}
+	if kkCount != 462 {
+		t.Errorf("unexpected KK positions: got=%d want=%d", kkCount, 462)
+	}
+	if got, want := binomial[2][6], uint64(15); got != want {
+		t.Errorf("unexpected binomial: got=%d want=%d", got, want)
+	}
+}
+
+func TestNewSyzygy(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"KQvK.rtbw", "KQvK.rtbz", "KRPvKR.rtbw", "README.txt", "KXvK.rtbw"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("bad"), 0o600); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		paths         string
+		wantMaxPieces int
+		wantErr       bool
+	}{
+		{name: "empty", paths: "", wantMaxPieces: 0},
+		{name: "empty UCI", paths: "<empty>", wantMaxPieces: 0},
+		{name: "directory", paths: dir, wantMaxPieces: 5},
+		{name: "missing directory", paths: filepath.Join(dir, "missing"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tb, err := NewSyzygy(tt.paths)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("error expected: got=nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			defer tb.Close()
+			if got := tb.MaxPieces(); got != tt.wantMaxPieces {
+				t.Errorf("unexpected max pieces: got=%d want=%d", got, tt.wantMaxPieces)
+			}
+		})
+	}
+}
+
+func TestProbeWDLErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "KQvK.rtbw"), []byte("bad table"), 0o600); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	tb, err := NewSyzygy(dir)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	t.Cleanup(func() { _ = tb.Close() }) // after the parallel subtests
+
+	tests := []struct {
+		name    string
+		fen     string
+		wantWDL WDL
+		wantErr error
+	}{
+		{name: "castling", fen: "4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", wantErr: ErrCastling},
+		{name: "too many pieces", fen: "4k3/8/8/8/8/8/8/RN2K3 w - - 0 1", wantErr: ErrTableNotFound},
+		{name: "missing table", fen: "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", wantErr: ErrTableNotFound},
+		{name: "corrupted table", fen: "4k3/8/8/8/8/8/8/Q3K3 w - - 0 1", wantErr: ErrCorruptedTable},
+		{name: "KvK", fen: "4k3/8/8/8/8/8/8/4K3 w - - 0 1", wantWDL: WDLDraw},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b, err := board.NewBoard(board.WithFEN(tt.fen))
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+			wdl, err := tb.ProbeWDL(b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: got=%v want=%v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && wdl != tt.wantWDL {
+				t.Errorf("unexpected WDL: got=%s want=%s", wdl, tt.wantWDL)
+			}
+		})
+	}
+}
+
+func TestSyzygyClose(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "KQvK.rtbw"), []byte("bad table"), 0o600); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	tb, err := NewSyzygy(dir)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	b, err := board.NewBoard(board.WithFEN("4k3/8/8/8/8/8/8/Q3K3 w - - 0 1"))
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, err := tb.ProbeWDL(b); !errors.Is(err, ErrCorruptedTable) {
+		t.Fatalf("unexpected error: got=%v want=%v", err, ErrCorruptedTable)
+	}
+	if err := tb.Close(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if _, err := tb.ProbeWDL(b); !errors.Is(err, ErrClosed) {
+		t.Errorf("unexpected error: got=%v want=%v", err, ErrClosed)
+	}
+	if err := tb.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSyzygyCloseWhileProbing(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "KQvK.rtbw"), []byte("bad table"), 0o600); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	tb, err := NewSyzygy(dir)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, err := board.NewBoard(board.WithFEN("4k3/8/8/8/8/8/8/Q3K3 w - - 0 1"))
+			if err != nil {
+				t.Error("unexpected error:", err)
+				return
+			}
+			for j := 0; j < 100; j++ {
+				if _, err := tb.ProbeWDL(b); !errors.Is(err, ErrCorruptedTable) && !errors.Is(err, ErrClosed) {
+					t.Errorf("unexpected error: got=%v want=%v or %v", err, ErrCorruptedTable, ErrClosed)
+					return
+				}
+			}
+		}()
+	}
+	if err := tb.Close(); err != nil {
+		t.Error("unexpected error:", err)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,771 @@
+package tablebase
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/exp/mmap"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/position"
+)
+
+type tableType uint8
+
+const (
+	tableTypeWDL tableType = iota
+	tableTypeDTZ
+)
+
+const (
+	extensionWDL = ".rtbw"
+	extensionDTZ = ".rtbz"
+)
+
+// Flags stored per PairsData.
+const (
+	flagSTM         uint8 = 1
+	flagMapped      uint8 = 2
+	flagWinPlies    uint8 = 4
+	flagLossPlies   uint8 = 8
+	flagWide        uint8 = 16
+	flagSingleValue uint8 = 128
+)
+
+var (
+	magicWDL = [4]byte{0x71, 0xE8, 0x23, 0x5D}
+	magicDTZ = [4]byte{0xD7, 0x66, 0x0C, 0xA5}
+)
+
+// pairsData contains the low level indexing information to access the compressed values.
+type pairsData struct {
+	flags         uint8
+	maxSymLen     uint8
+	minSymLen     uint8
+	numBlocks     uint32
+	blockSize     uint64
+	span          uint64
+	lowestSym     []uint16
+	btree         [][2]uint16 // left and right symbols expanding each symbol
+	symlen        []uint8     // number of values (-1) represented by each symbol
+	base64        []uint64
+	blockLengths  int // offset of the blockLength table
+	blockLenSize  uint32
+	sparseIndex   int // offset of the sparseIndex table
+	sparseIdxSize uint64
+	data          int // offset of the compressed data
+
+	pieces   [MaxPieces]uint8
+	groupIdx [MaxPieces + 1]uint64
+	groupLen [MaxPieces + 1]int
+	mapIdx   [4]uint16 // WDLWin, WDLLoss, WDLCursedWin, WDLBlessedLoss, used in DTZ
+}
+
+// table holds the indexing information of a WDL or DTZ file, loaded on first access.
+type table struct {
+	typ  tableType
+	path string
+
+	key             uint64
+	key2            uint64
+	pieceCount      int
+	hasPawns        bool
+	hasUniquePieces bool
+	pawnCount       [2]int // lead color, other color
+
+	mu      sync.RWMutex // read locked while probing, so that close waits for the probes in progress
+	once    sync.Once
+	reader  *mmap.ReaderAt
+	err     error
+	mapBase int              // offset of the DTZ map
+	items   [2][4]*pairsData // [stm][file]
+}
+
+func newTable(typ tableType, path string, code string) *table {
+	t := &table{
+		typ:  typ,
+		path: path,
+		key:  materialKeyFromCode(code, board.SideWhite),
+		key2: materialKeyFromCode(code, board.SideBlack),
+	}
+
+	var counts [2][6 + 1]int
+	side := 0
+	for i := 0; i < len(code); i++ {
+		if code[i] == 'v' {
+			side = 1
+			continue
+		}
+		counts[side][symbolToType(code[i])]++
+		t.pieceCount++
+	}
+	t.hasPawns = counts[0][tbPawn]+counts[1][tbPawn] != 0
+	for _, c := range counts {
+		for p := tbPawn; p < tbKing; p++ {
+			if c[p] == 1 {
+				t.hasUniquePieces = true
+			}
+		}
+	}
+
+	// the leading color is the side with less Pawns, for better compression
+	whiteLeads := counts[1][tbPawn] == 0 || (counts[0][tbPawn] != 0 && counts[1][tbPawn] >= counts[0][tbPawn])
+	if whiteLeads {
+		t.pawnCount = [2]int{counts[0][tbPawn], counts[1][tbPawn]}
+	} else {
+		t.pawnCount = [2]int{counts[1][tbPawn], counts[0][tbPawn]}
+	}
+	return t
+}
+
+func (t *table) sides() int {
+	if t.typ == tableTypeWDL && t.key != t.key2 {
+		return 2
+	}
+	return 1
+}
+
+func (t *table) get(stm, file int) *pairsData {
+	if !t.hasPawns {
+		file = 0
+	}
+	if t.typ == tableTypeDTZ {
+		stm = 0
+	}
+	return t.items[stm][file]
+}
+
+// load memory maps the file and populates the indexing information, once.
+func (t *table) load() error {
+	t.once.Do(func() {
+		t.reader, t.err = mmap.Open(t.path)
+		if t.err != nil {
+			return
+		}
+		magic := magicWDL
+		if t.typ == tableTypeDTZ {
+			magic = magicDTZ
+		}
+		if t.reader.Len() < 4 ||
+			t.reader.At(0) != magic[0] || t.reader.At(1) != magic[1] ||
+			t.reader.At(2) != magic[2] || t.reader.At(3) != magic[3] {
+			t.err = fmt.Errorf("%w: %s", ErrCorruptedTable, filepath.Base(t.path))
+			return
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				t.err = fmt.Errorf("%w: %s: %v", ErrCorruptedTable, filepath.Base(t.path), r)
+			}
+		}()
+		t.parse(4)
+	})
+	return t.err
+}
+
+func (t *table) parse(off int) {
+	const (
+		splitFlag    = 1
+		hasPawnsFlag = 2
+	)
+	if header := t.u8(off); (header&hasPawnsFlag != 0) != t.hasPawns || (header&splitFlag != 0) != (t.key != t.key2) {
+		panic("unexpected table header")
+	}
+	off++
+
+	sides := t.sides()
+	maxFile := 0
+	if t.hasPawns {
+		maxFile = 3
+	}
+	pp := t.hasPawns && t.pawnCount[1] != 0 // Pawns on both sides
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			t.items[i][f] = &pairsData{}
+		}
+
+		order := [2][2]int{{int(t.u8(off) & 0x0F), 0x0F}, {int(t.u8(off) >> 4), 0x0F}}
+		if pp {
+			order[0][1] = int(t.u8(off+1) & 0x0F)
+			order[1][1] = int(t.u8(off+1) >> 4)
+			off++
+		}
+		off++
+
+		for k := 0; k < t.pieceCount; k, off = k+1, off+1 {
+			for i := 0; i < sides; i++ {
+				if i == 0 {
+					t.items[i][f].pieces[k] = t.u8(off) & 0x0F
+				} else {
+					t.items[i][f].pieces[k] = t.u8(off) >> 4
+				}
+			}
+		}
+
+		for i := 0; i < sides; i++ {
+			t.setGroups(t.items[i][f], order[i], f)
+		}
+	}
+	off += off & 1 // word alignment
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			off = t.setSizes(t.items[i][f], off)
+		}
+	}
+
+	if t.typ == tableTypeDTZ {
+		off = t.setDTZMap(off, maxFile)
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := t.items[i][f]
+			d.sparseIndex = off
+			off += int(d.sparseIdxSize) * 6
+		}
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := t.items[i][f]
+			d.blockLengths = off
+			off += int(d.blockLenSize) * 2
+		}
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := 0; i < sides; i++ {
+			d := t.items[i][f]
+			off = (off + 0x3F) &^ 0x3F // 64 byte alignment
+			d.data = off
+			off += int(uint64(d.numBlocks) * d.blockSize)
+		}
+	}
+}
+
+// setGroups groups together the pieces that will be encoded together, and computes the starting index of
+// each group. The leading group is formed by the leading Pawns, or by 3 unique pieces, or by the King pair.
+func (t *table) setGroups(d *pairsData, order [2]int, file int) {
+	n := 0
+	firstLen := 2
+	if t.hasPawns {
+		firstLen = 0
+	} else if t.hasUniquePieces {
+		firstLen = 3
+	}
+
+	d.groupLen[n] = 1
+	for i := 1; i < t.pieceCount; i++ {
+		firstLen--
+		if firstLen > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+	n++
+	d.groupLen[n] = 0 // zero-terminated
+
+	pp := t.hasPawns && t.pawnCount[1] != 0
+	next := 1
+	freeCells := 64 - d.groupLen[0]
+	if pp {
+		next = 2
+		freeCells -= d.groupLen[1]
+	}
+
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		switch {
+		case k == order[0]: // leading Pawns or pieces
+			d.groupIdx[0] = idx
+			switch {
+			case t.hasPawns:
+				idx *= leadPawnsSize[d.groupLen[0]][file]
+			case t.hasUniquePieces:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case k == order[1]: // remaining Pawns
+			d.groupIdx[1] = idx
+			idx *= binomial[d.groupLen[1]][48-d.groupLen[0]]
+		default: // remaining pieces
+			d.groupIdx[next] = idx
+			idx *= binomial[d.groupLen[next]][freeCells]
+			freeCells -= d.groupLen[next]
+			next++
+		}
+	}
+	d.groupIdx[n] = idx
+}
+
+func (t *table) setSizes(d *pairsData, off int) int {
+	d.flags = t.u8(off)
+	off++
+
+	if d.flags&flagSingleValue != 0 {
+		d.minSymLen = t.u8(off) // the single value is stored here
+		return off + 1
+	}
+
+	// the last groupIdx stores the size of the table
+	var tableSize uint64
+	for i, l := range d.groupLen {
+		if l == 0 {
+			tableSize = d.groupIdx[i]
+			break
+		}
+	}
+
+	d.blockSize = 1 << t.u8(off)
+	d.span = 1 << t.u8(off+1)
+	d.sparseIdxSize = (tableSize + d.span - 1) / d.span
+	padding := uint32(t.u8(off + 2))
+	d.numBlocks = t.u32le(off + 3)
+	d.blockLenSize = d.numBlocks + padding // padded to ensure sparseIndex does not point out of range
+	d.maxSymLen = t.u8(off + 7)
+	d.minSymLen = t.u8(off + 8)
+	off += 9
+
+	// canonical Huffman code, longer symbols have lower numerical values
+	symLenCount := int(d.maxSymLen) - int(d.minSymLen) + 1
+	d.lowestSym = make([]uint16, symLenCount)
+	for i := range d.lowestSym {
+		d.lowestSym[i] = t.u16le(off + 2*i)
+	}
+	d.base64 = make([]uint64, symLenCount)
+	for i := symLenCount - 2; i >= 0; i-- {
+		d.base64[i] = (d.base64[i+1] + uint64(d.lowestSym[i]) - uint64(d.lowestSym[i+1])) / 2
+	}
+	for i := range d.base64 {
+		d.base64[i] <<= 64 - i - int(d.minSymLen) // right-padding to 64 bits
+	}
+	off += symLenCount * 2
+
+	// recursive pairing: each symbol expands into a pair of child symbols
+	symCount := int(t.u16le(off))
+	off += 2
+	d.btree = make([][2]uint16, symCount)
+	for s := range d.btree {
+		b0, b1, b2 := uint16(t.u8(off+3*s)), uint16(t.u8(off+3*s+1)), uint16(t.u8(off+3*s+2))
+		d.btree[s] = [2]uint16{(b1&0x0F)<<8 | b0, b2<<4 | b1>>4}
+	}
+	d.symlen = make([]uint8, symCount)
+	visited := make([]bool, symCount)
+	for s := range d.symlen {
+		if !visited[s] {
+			d.symlen[s] = d.setSymLen(uint16(s), visited)
+		}
+	}
+
+	return off + symCount*3 + symCount&1
+}
+
+func (d *pairsData) setSymLen(s uint16, visited []bool) uint8 {
+	visited[s] = true
+	sr := d.btree[s][1]
+	if sr == 0xFFF {
+		return 0
+	}
+	sl := d.btree[s][0]
+	if !visited[sl] {
+		d.symlen[sl] = d.setSymLen(sl, visited)
+	}
+	if !visited[sr] {
+		d.symlen[sr] = d.setSymLen(sr, visited)
+	}
+	return d.symlen[sl] + d.symlen[sr] + 1
+}
+
+// setDTZMap reads the maps to reconstruct the original DTZ values, which are stored sorted by frequency.
+func (t *table) setDTZMap(off, maxFile int) int {
+	t.mapBase = off
+	for f := 0; f <= maxFile; f++ {
+		d := t.get(0, f)
+		if d.flags&flagMapped == 0 {
+			continue
+		}
+		if d.flags&flagWide != 0 {
+			off += off & 1 // word alignment
+			for i := 0; i < 4; i++ {
+				d.mapIdx[i] = uint16((off-t.mapBase)/2 + 1)
+				off += 2*int(t.u16le(off)) + 2
+			}
+		} else {
+			for i := 0; i < 4; i++ {
+				d.mapIdx[i] = uint16(off - t.mapBase + 1)
+				off += int(t.u8(off)) + 1
+			}
+		}
+	}
+	return off + off&1 // word alignment
+}
+
+// decompressPairs returns the value stored at the index.
+func (t *table) decompressPairs(d *pairsData, idx uint64) int {
+	if d.flags&flagSingleValue != 0 {
+		return int(d.minSymLen)
+	}
+
+	// locate the block storing the value through the nearest sparse index entry, which points to the
+	// value at k * span + span / 2
+	k := idx / d.span
+	block := t.u32le(d.sparseIndex + int(k)*6)
+	offset := int(t.u16le(d.sparseIndex + int(k)*6 + 4))
+	offset += int(int64(idx%d.span) - int64(d.span/2))
+
+	for offset < 0 {
+		block--
+		offset += int(t.u16le(d.blockLengths+int(block)*2)) + 1
+	}
+	for offset > int(t.u16le(d.blockLengths+int(block)*2)) {
+		offset -= int(t.u16le(d.blockLengths+int(block)*2)) + 1
+		block++
+	}
+
+	// read the canonical Huffman symbols of the block
+	ptr := d.data + int(uint64(block)*d.blockSize)
+	buf64 := t.u64be(ptr)
+	ptr += 8
+	buf64Size := 64
+	var sym uint16
+	for {
+		l := 0
+		for buf64 < d.base64[l] {
+			l++
+		}
+		sym = uint16((buf64 - d.base64[l]) >> (64 - l - int(d.minSymLen)))
+		sym += d.lowestSym[l]
+		if offset < int(d.symlen[sym])+1 {
+			break
+		}
+		offset -= int(d.symlen[sym]) + 1
+		l += int(d.minSymLen)
+		buf64 <<= l
+		buf64Size -= l
+		if buf64Size <= 32 {
+			buf64Size += 32
+			buf64 |= uint64(t.u32be(ptr)) << (64 - buf64Size)
+			ptr += 4
+		}
+	}
+
+	// expand the symbol into its pair until the leaf storing the value is reached
+	for d.symlen[sym] != 0 {
+		left := d.btree[sym][0]
+		if offset < int(d.symlen[left])+1 {
+			sym = left
+		} else {
+			offset -= int(d.symlen[left]) + 1
+			sym = d.btree[sym][1]
+		}
+	}
+	return int(d.btree[sym][0])
+}
+
+// mapScore converts the stored value into a WDL score, or a DTZ value in plies.
+func (t *table) mapScore(file, value int, wdl WDL) int {
+	if t.typ == tableTypeWDL {
+		return value - 2
+	}
+
+	wdlMap := [5]int{1, 3, 0, 2, 0}
+	d := t.get(0, file)
+	if d.flags&flagMapped != 0 {
+		idx := int(d.mapIdx[wdlMap[wdl+2]])
+		if d.flags&flagWide != 0 {
+			value = int(t.u16le(t.mapBase + 2*(idx+value)))
+		} else {
+			value = int(t.u8(t.mapBase + idx + value))
+		}
+	}
+
+	// DTZ may be stored in moves, convert to plies
+	if (wdl == WDLWin && d.flags&flagWinPlies == 0) ||
+		(wdl == WDLLoss && d.flags&flagLossPlies == 0) ||
+		wdl == WDLCursedWin || wdl == WDLBlessedLoss {
+		value *= 2
+	}
+	return value + 1
+}
+
+// probe computes the index of the board in the table and returns the stored value. The second return value
+// is false if the DTZ table only stores the positions for the other side to move.
+func (t *table) probe(b *board.Board, key uint64, wdl WDL) (int, bool) {
+	var cells [MaxPieces]position.Pos
+	var pieces [MaxPieces]uint8
+	var size, leadPawnsCount int
+	var leadPawns uint64
+
+	// symmetric tables only store White to move; tables are computed with White as the stronger side
+	symmetricBlackToMove := t.key == t.key2 && b.Turn() == board.SideBlack
+	blackStronger := key != t.key
+	flip := symmetricBlackToMove || blackStronger
+	var flipColor uint8
+	var flipCells position.Pos
+	stm := 0
+	if b.Turn() == board.SideBlack {
+		stm = 1
+	}
+	if flip {
+		flipColor, flipCells = tbBlackOffset, 56
+		stm ^= 1
+	}
+
+	if t.hasPawns {
+		pc := t.get(0, 0).pieces[0] ^ flipColor
+		leadSide := board.SideWhite
+		if pc&tbBlackOffset != 0 {
+			leadSide = board.SideBlack
+		}
+		leadPawns = occupancy(b, leadSide, board.PiecePawn)
+		for bm := leadPawns; bm != 0; bm &= bm - 1 {
+			cells[size] = lsb(bm) ^ flipCells
+			pieces[size] = pc ^ flipColor
+			size++
+		}
+		leadPawnsCount = size
+	}
+	for bm := occupancy(b, board.SideUnknown, board.PieceUnknown) &^ leadPawns; bm != 0; bm &= bm - 1 {
+		pos := lsb(bm)
+		s, p := b.GetSideAndPieces(pos)
+		cells[size] = pos ^ flipCells
+		pieces[size] = tbPiece(s, p) ^ flipColor
+		size++
+	}
+
+	d, file, idx, ok := t.index(cells[:size], pieces[:size], leadPawnsCount, stm)
+	if !ok {
+		return 0, false
+	}
+	return t.mapScore(file, t.decompressPairs(d, idx), wdl), true
+}
+
+// index computes the index of the position in the table, given with the leading Pawns first, and with the
+// colors and side to move relative to the table. The cells and pieces are reordered in place. The last return
+// value is false if the DTZ table only stores the positions for the other side to move.
+func (t *table) index(cells []position.Pos, pieces []uint8, leadPawnsCount, stm int) (*pairsData, int, uint64, bool) {
+	size := len(cells)
+	file := 0
+
+	// the leading Pawn is the one nearest to the edge and with the lowest rank
+	if t.hasPawns {
+		best := 0
+		for i := 1; i < leadPawnsCount; i++ {
+			if mapPawns[cells[i]] > mapPawns[cells[best]] {
+				best = i
+			}
+		}
+		cells[0], cells[best] = cells[best], cells[0]
+		file = int(cells[0].X())
+		if file > 3 {
+			file = 7 - file
+		}
+	}
+
+	// DTZ tables are one-sided
+	if t.typ == tableTypeDTZ {
+		if int(t.get(stm, file).flags&flagSTM) != stm && (t.key != t.key2 || t.hasPawns) {
+			return nil, 0, 0, false
+		}
+	}
+
+	d := t.get(stm, file)
+
+	// reorder the pieces to follow the sequence stored in the table
+	for i := leadPawnsCount; i < size-1; i++ {
+		for j := i + 1; j < size; j++ {
+			if d.pieces[i] == pieces[j] {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+				cells[i], cells[j] = cells[j], cells[i]
+				break
+			}
+		}
+	}
+
+	// map the cells so that the leading piece is in the A1-D1-D4 triangle
+	if cells[0].X() > position.FileD {
+		for i := 0; i < size; i++ {
+			cells[i] = flipFile(cells[i])
+		}
+	}
+
+	var idx uint64
+	if t.hasPawns {
+		idx = leadPawnIdx[leadPawnsCount][cells[0]]
+		sort.SliceStable(cells[1:leadPawnsCount], func(i, j int) bool {
+			return mapPawns[cells[1+i]] < mapPawns[cells[1+j]]
+		})
+		for i := 1; i < leadPawnsCount; i++ {
+			idx += binomial[i][mapPawns[cells[i]]]
+		}
+	} else {
+		if cells[0].Y() > position.Rank4 {
+			for i := 0; i < size; i++ {
+				cells[i] = flipRank(cells[i])
+			}
+		}
+
+		// ensure the first piece of the leading group not on the A1-H8 diagonal is below it
+		for i := 0; i < d.groupLen[0]; i++ {
+			if offA1H8(cells[i]) == 0 {
+				continue
+			}
+			if offA1H8(cells[i]) > 0 {
+				for j := i; j < size; j++ {
+					cells[j] = ((cells[j] >> 3) | (cells[j] << 3)) & 63
+				}
+			}
+			break
+		}
+
+		if t.hasUniquePieces {
+			var adjust1, adjust2 uint64
+			if cells[1] > cells[0] {
+				adjust1++
+			}
+			if cells[2] > cells[0] {
+				adjust2++
+			}
+			if cells[2] > cells[1] {
+				adjust2++
+			}
+			c1, c2 := uint64(cells[1]), uint64(cells[2])
+			r0, r1, r2 := uint64(cells[0].Y()), uint64(cells[1].Y()), uint64(cells[2].Y())
+			switch {
+			case offA1H8(cells[0]) != 0:
+				idx = (uint64(mapA1D1D4[cells[0]])*63+(c1-adjust1))*62 + c2 - adjust2
+			case offA1H8(cells[1]) != 0:
+				idx = (6*63+r0*28+uint64(mapB1H1H7[cells[1]]))*62 + c2 - adjust2
+			case offA1H8(cells[2]) != 0:
+				idx = 6*63*62 + 4*28*62 + r0*7*28 + (r1-adjust1)*28 + uint64(mapB1H1H7[cells[2]])
+			default:
+				idx = 6*63*62 + 4*28*62 + 4*7*28 + r0*7*6 + (r1-adjust1)*6 + (r2 - adjust2)
+			}
+		} else {
+			idx = uint64(mapKK[mapA1D1D4[cells[0]]][cells[1]])
+		}
+	}
+
+	// encode the remaining Pawns, then the remaining pieces, in ascending cell order
+	idx *= d.groupIdx[0]
+	groupStart := d.groupLen[0]
+	remainingPawns := t.hasPawns && t.pawnCount[1] != 0
+	for next := 1; d.groupLen[next] != 0; next++ {
+		group := cells[groupStart : groupStart+d.groupLen[next]]
+		sort.SliceStable(group, func(i, j int) bool { return group[i] < group[j] })
+		var n uint64
+		for i, pos := range group {
+			adjust := 0
+			for _, prev := range cells[:groupStart] {
+				if pos > prev {
+					adjust++
+				}
+			}
+			cell := int(pos) - adjust
+			if remainingPawns {
+				cell -= 8
+			}
+			n += binomial[i+1][cell]
+		}
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		groupStart += d.groupLen[next]
+	}
+
+	return d, file, idx, true
+}
+
+func (t *table) u8(off int) uint8 {
+	return t.reader.At(off)
+}
+
+func (t *table) u16le(off int) uint16 {
+	var buf [2]byte
+	t.read(buf[:], off)
+	return binary.LittleEndian.Uint16(buf[:])
+}
+
+func (t *table) u32le(off int) uint32 {
+	var buf [4]byte
+	t.read(buf[:], off)
+	return binary.LittleEndian.Uint32(buf[:])
+}
+
+func (t *table) u32be(off int) uint32 {
+	var buf [4]byte
+	t.read(buf[:], off)
+	return binary.BigEndian.Uint32(buf[:])
+}
+
+func (t *table) u64be(off int) uint64 {
+	var buf [8]byte
+	t.read(buf[:], off)
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+func (t *table) read(buf []byte, off int) {
+	if _, err := t.reader.ReadAt(buf, int64(off)); err != nil {
+		panic(err)
+	}
+}
+
+// close unmaps the file once the probes in progress finish, and prevents it from being loaded again.
+func (t *table) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.once.Do(func() {})
+	t.err = ErrClosed
+	if t.reader == nil {
+		return nil
+	}
+	err := t.reader.Close()
+	t.reader = nil
+	return err
+}
+
+// tableCode returns the Syzygy table code of the file name, e.g. "KQvKR", if valid.
+func tableCode(name string) (string, bool) {
+	code := name[:len(name)-len(filepath.Ext(name))]
+	sides := 0
+	pieces := 0
+	for i := 0; i < len(code); i++ {
+		switch {
+		case code[i] == 'v':
+			if i == 0 || code[i-1] == 'v' {
+				return "", false
+			}
+			sides++
+		case symbolToType(code[i]) != 0:
+			pieces++
+		default:
+			return "", false
+		}
+	}
+	if sides != 1 || pieces > MaxPieces || code[0] != 'K' || code[len(code)-1] == 'v' {
+		return "", false
+	}
+	return code, true
+}
+
+func symbolToType(sym byte) uint8 {
+	for p, s := range tbPieceSymbols {
+		if s == sym && p != 0 {
+			return uint8(p)
+		}
+	}
+	return 0
+}
+
+func tbPiece(s board.Side, p board.Piece) uint8 {
+	if s == board.SideBlack {
+		return tbPieceTypes[p] | tbBlackOffset
+	}
+	return tbPieceTypes[p]
+}
@@ -0,0 +1,188 @@
+package testsuite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/epd"
+)
+
+var ErrMissingSolution = errors.New("missing bm or am operation")
+
+// Config configures a test suite run.
+type Config struct {
+	Positions []*epd.Position
+
+	// EngineConfig creates a fresh engine for each position, so its result does not depend on the others.
+	EngineConfig *engine.EngineConfig
+
+	// Limit is the search limit of each position.
+	Limit engine.ClockConfig
+
+	// Concurrency is the number of positions searched at the same time.
+	Concurrency int
+}
+
+// Result is the outcome of a single position.
+type Result struct {
+	Index    int // 0-indexed position in the suite
+	ID       string
+	Position *epd.Position
+	Move     board.Move
+	Solved   bool
+
+	// TimeToSolution and DepthToSolution are of the first iteration since which the best move has solved the
+	// position, if solved.
+	TimeToSolution  time.Duration
+	DepthToSolution uint8
+
+	Time  time.Duration
+	Nodes uint32
+	Err   error
+}
+
+// Summary is the number of positions solved.
+type Summary struct {
+	Positions, Solved int
+
+	// TimeToSolution is the sum of the time to solution of the solved positions.
+	TimeToSolution time.Duration
+}
+
+func (s *Summary) add(r *Result) {
+	s.Positions++
+	if r.Solved {
+		s.Solved++
+		s.TimeToSolution += r.TimeToSolution
+	}
+}
+
+func (s *Summary) String() string {
+	var rate float64
+	if s.Positions != 0 {
+		rate = float64(s.Solved) / float64(s.Positions) * 100
+	}
+	return fmt.Sprintf("solved %d/%d (%.1f%%), total time to solution %s",
+		s.Solved, s.Positions, rate, s.TimeToSolution.Round(time.Millisecond))
+}
+
+// IsSolution returns true if the move is one of the best moves, and none of the moves to avoid.
+func IsSolution(p *epd.Position, mv board.Move) bool {
+	bm, am := p.Moves(epd.OpcodeBestMove), p.Moves(epd.OpcodeAvoidMove)
+	if len(bm) != 0 && !containsMove(bm, mv) {
+		return false
+	}
+	return !containsMove(am, mv)
+}
+
+// Run searches the positions, calling onResult after each position finishes. Positions are searched in
+// order, but may finish in any order. Cancelling the context stops scheduling new positions.
+func Run(ctx context.Context, cfg *Config, onResult func(*Result)) (*Summary, error) {
+	if cfg.EngineConfig == nil {
+		return nil, errors.New("missing engine config")
+	}
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range cfg.Positions {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	summary := &Summary{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// a fresh engine per position, so that the results do not depend on the order of the positions
+				// or the concurrency through the transposition table and killers
+				engineCfg := *cfg.EngineConfig
+				r := Solve(ctx, engine.NewEngine(&engineCfg), cfg.Positions[i], cfg.Limit)
+				r.Index = i
+				if ctx.Err() != nil {
+					return // incomplete search
+				}
+
+				mu.Lock()
+				summary.add(r)
+				if onResult != nil {
+					onResult(r)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return summary, ctx.Err()
+}
+
+// Solve searches the position with the engine, checking the best move against the bm and am operations.
+func Solve(ctx context.Context, e *engine.Engine, p *epd.Position, limit engine.ClockConfig) *Result {
+	r := &Result{
+		Position: p,
+	}
+	r.ID, _ = p.Text(epd.OpcodeID)
+	if len(p.Moves(epd.OpcodeBestMove)) == 0 && len(p.Moves(epd.OpcodeAvoidMove)) == 0 {
+		r.Err = ErrMissingSolution
+		return r
+	}
+
+	var solvedSince *engine.SearchInfo
+	startTime := time.Now()
+	mv, err := e.Search(ctx, p.Board.Clone(), &engine.SearchConfig{
+		ClockConfig: limit,
+		OnInfo: func(info *engine.SearchInfo) {
+			if info.Type != engine.SearchInfoTypeIteration || len(info.PV) == 0 {
+				return
+			}
+			r.Nodes = info.Nodes
+			switch solved := IsSolution(p, info.PV[0]); {
+			case solved && solvedSince == nil:
+				solvedSince = info
+			case !solved:
+				solvedSince = nil
+			}
+		},
+	})
+	r.Time = time.Since(startTime)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	r.Move = mv
+	r.Solved = IsSolution(p, mv)
+	if r.Solved {
+		r.TimeToSolution = r.Time
+		if solvedSince != nil {
+			r.TimeToSolution, r.DepthToSolution = solvedSince.Time, solvedSince.Depth
+		}
+	}
+	return r
+}
+
+func containsMove(mvs []board.Move, mv board.Move) bool {
+	for _, candidate := range mvs {
+		if candidate.Equals(mv) {
+			return true
+		}
+	}
+	return false
+}
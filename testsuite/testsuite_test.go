@@ -0,0 +1,128 @@
+package testsuite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/epd"
+)
+
+func TestIsSolution(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		epd  string
+		move string
+		want bool
+	}{
+		{name: "best move", epd: "4k3/8/8/8/8/8/8/R3K3 w Q - bm Ra8+;", move: "a1a8", want: true},
+		{name: "not best move", epd: "4k3/8/8/8/8/8/8/R3K3 w Q - bm Ra8+;", move: "a1a7", want: false},
+		{name: "one of best moves", epd: "4k3/8/8/8/8/8/8/R3K3 w Q - bm Ra7 Ra8+;", move: "a1a7", want: true},
+		{name: "avoided move", epd: "4k3/8/8/8/8/8/8/R3K3 w Q - am O-O-O;", move: "e1c1", want: false},
+		{name: "not avoided move", epd: "4k3/8/8/8/8/8/8/R3K3 w Q - am O-O-O;", move: "a1a8", want: true},
+		{name: "best and avoided move", epd: "4k3/8/8/8/8/8/8/R3K3 w Q - bm Ra8+; am Ra8+;", move: "a1a8", want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := epd.Parse(tt.epd)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			mv, err := p.Board.NewMoveFromUCI(tt.move)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := IsSolution(p, mv); got != tt.want {
+				t.Errorf("unexpected solution: got=%v want=%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	var positions []*epd.Position
+	for _, line := range []string{
+		`6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8#; id "mate";`,
+		`rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - bm Qh4#; id "fool";`,
+		`3rk3/8/8/8/8/8/8/3QK3 w - - am Qxd8+; id "avoid";`,
+		`4k3/8/8/8/8/8/8/4K2R w K - id "missing";`,
+	} {
+		p, err := epd.Parse(line)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		positions = append(positions, p)
+	}
+
+	results := make(map[string]*Result)
+	summary, err := Run(context.Background(), &Config{
+		Positions:    positions,
+		EngineConfig: &engine.EngineConfig{HashTableSize: 1},
+		Limit:        engine.ClockConfig{Depth: 3},
+		Concurrency:  2,
+	}, func(r *Result) {
+		results[r.ID] = r
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, id := range []string{"mate", "fool", "avoid"} {
+		r := results[id]
+		if r == nil || r.Err != nil || !r.Solved || r.DepthToSolution == 0 || r.TimeToSolution > r.Time {
+			t.Errorf("unexpected result %s: got=%+v", id, r)
+		}
+	}
+	if r := results["missing"]; r == nil || !errors.Is(r.Err, ErrMissingSolution) {
+		t.Errorf("unexpected result missing: got=%+v", r)
+	}
+	if summary.Positions != 4 || summary.Solved != 3 {
+		t.Errorf("unexpected summary: got=%s", summary)
+	}
+}
+
+func TestRunReproducible(t *testing.T) {
+	t.Parallel()
+	var positions []*epd.Position
+	for _, line := range []string{
+		`r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5; id "ruy";`,
+		`rnbqkb1r/pppp1ppp/5n2/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Nxe5; id "petrov";`,
+		`r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - bm Ng5; id "two knights";`,
+	} {
+		p, err := epd.Parse(line)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		positions = append(positions, p)
+	}
+	reversed := []*epd.Position{positions[2], positions[1], positions[0]}
+
+	nodes := func(positions []*epd.Position, concurrency int) map[string]uint32 {
+		got := make(map[string]uint32)
+		_, err := Run(context.Background(), &Config{
+			Positions:    positions,
+			EngineConfig: &engine.EngineConfig{HashTableSize: 1},
+			Limit:        engine.ClockConfig{Depth: 4},
+			Concurrency:  concurrency,
+		}, func(r *Result) {
+			got[r.ID] = r.Nodes
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return got
+	}
+	want := nodes(positions, 1)
+	for _, got := range []map[string]uint32{nodes(reversed, 1), nodes(positions, 3)} {
+		for id, n := range want {
+			if got[id] != n {
+				t.Errorf("unexpected nodes %s: got=%d want=%d", id, got[id], n)
+			}
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daystram/gambit/engine"
+)
+
+const (
+	quitTimeout = 10 * time.Second
+)
+
+var ErrTerminated = errors.New("engine terminated")
+
+// EngineInfo is the identity and options reported by the engine during the handshake.
+type EngineInfo struct {
+	Name    string
+	Author  string
+	Options []Option
+}
+
+// Client drives an engine over UCI. Commands are not safe for concurrent use, except Stop.
+type Client struct {
+	mu     sync.Mutex // guards writes
+	w      io.Writer
+	lines  chan string
+	closer io.Closer
+	cmd    *exec.Cmd
+}
+
+// New creates a client communicating over rw. The engine is expected to be already running.
+func New(rw io.ReadWriter) *Client {
+	c := &Client{
+		w:     rw,
+		lines: make(chan string, 64),
+	}
+	if closer, ok := rw.(io.Closer); ok {
+		c.closer = closer
+	}
+	go c.read(rw)
+	return c
+}
+
+// Start launches the engine executable and creates a client communicating over its standard input and
+// output.
+func Start(path string, args ...string) (*Client, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		w:      stdin,
+		lines:  make(chan string, 64),
+		closer: stdin,
+		cmd:    cmd,
+	}
+	go c.read(stdout)
+	return c, nil
+}
+
+func (c *Client) read(r io.Reader) {
+	defer close(c.lines)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		c.lines <- scanner.Text()
+	}
+}
+
+// Handshake sends uci and waits for uciok, returning the reported identity and options.
+func (c *Client) Handshake(ctx context.Context) (*EngineInfo, error) {
+	if err := c.send("uci"); err != nil {
+		return nil, err
+	}
+	info := &EngineInfo{}
+	err := c.waitFor(ctx, "uciok", func(line string) {
+		switch {
+		case strings.HasPrefix(line, "id name "):
+			info.Name = strings.TrimSpace(strings.TrimPrefix(line, "id name "))
+		case strings.HasPrefix(line, "id author "):
+			info.Author = strings.TrimSpace(strings.TrimPrefix(line, "id author "))
+		case strings.HasPrefix(line, "option "):
+			if opt, err := ParseOption(line); err == nil {
+				info.Options = append(info.Options, *opt)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// SetOption sets the option, without waiting for the engine to apply it. Use IsReady to synchronize.
+func (c *Client) SetOption(name, value string) error {
+	if value == "" {
+		return c.send("setoption name " + name)
+	}
+	return c.send(fmt.Sprintf("setoption name %s value %s", name, value))
+}
+
+// IsReady sends isready and waits for readyok.
+func (c *Client) IsReady(ctx context.Context) error {
+	if err := c.send("isready"); err != nil {
+		return err
+	}
+	return c.waitFor(ctx, "readyok", nil)
+}
+
+// NewGame sends ucinewgame and waits for the engine to be ready.
+func (c *Client) NewGame(ctx context.Context) error {
+	if err := c.send("ucinewgame"); err != nil {
+		return err
+	}
+	return c.IsReady(ctx)
+}
+
+// Position sets the position from the FEN, or the starting position if empty, followed by the moves in
+// UCI notation.
+func (c *Client) Position(fen string, moves []string) error {
+	position := "position startpos"
+	if fen != "" {
+		position = "position fen " + fen
+	}
+	if len(moves) != 0 {
+		position += " moves " + strings.Join(moves, " ")
+	}
+	return c.send(position)
+}
+
+// Go starts the search limited by the clock, calling onInfo for each info line, and waits for the best move.
+// Cancelling the context sends stop, and the best move reported afterwards is still returned.
+func (c *Client) Go(ctx context.Context, clockCfg *engine.ClockConfig, onInfo func(*Info)) (*BestMove, error) {
+	if err := c.send(goCommand(clockCfg)); err != nil {
+		return nil, err
+	}
+
+	done := ctx.Done()
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return nil, ErrTerminated
+			}
+			switch fields := strings.Fields(line); {
+			case len(fields) == 0:
+			case fields[0] == "bestmove":
+				return ParseBestMove(line)
+			case fields[0] == "info" && onInfo != nil:
+				if info, err := ParseInfo(line); err == nil {
+					onInfo(info)
+				}
+			}
+		case <-done:
+			done = nil // still wait for bestmove to keep the session in sync
+			if err := c.Stop(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Stop sends stop, ending the current search.
+func (c *Client) Stop() error {
+	return c.send("stop")
+}
+
+// Close sends quit and waits for the engine process to exit, killing it after a timeout.
+func (c *Client) Close() error {
+	_ = c.send("quit")
+	if c.closer != nil {
+		_ = c.closer.Close()
+	}
+	if c.cmd == nil {
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(quitTimeout):
+		_ = c.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+func (c *Client) send(command string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := io.WriteString(c.w, command+"\n")
+	return err
+}
+
+// waitFor reads the lines until the expected line, passing the other lines to onLine.
+func (c *Client) waitFor(ctx context.Context, expected string, onLine func(string)) error {
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return ErrTerminated
+			}
+			if strings.TrimSpace(line) == expected {
+				return nil
+			}
+			if onLine != nil {
+				onLine(line)
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %s: %w", expected, ctx.Err())
+		}
+	}
+}
+
+func goCommand(clockCfg *engine.ClockConfig) string {
+	switch {
+	case clockCfg == nil:
+		return "go infinite"
+	case clockCfg.WhiteTime != 0 || clockCfg.BlackTime != 0:
+		command := fmt.Sprintf("go wtime %d btime %d", clockCfg.WhiteTime.Milliseconds(), clockCfg.BlackTime.Milliseconds())
+		if clockCfg.WhiteIncrement != 0 || clockCfg.BlackIncrement != 0 {
+			command += fmt.Sprintf(" winc %d binc %d",
+				clockCfg.WhiteIncrement.Milliseconds(), clockCfg.BlackIncrement.Milliseconds())
+		}
+		return command
+	case clockCfg.Movetime != 0:
+		return fmt.Sprintf("go movetime %d", clockCfg.Movetime.Milliseconds())
+	case clockCfg.Depth != 0:
+		return fmt.Sprintf("go depth %d", clockCfg.Depth)
+	case clockCfg.Nodes != 0:
+		return fmt.Sprintf("go nodes %d", clockCfg.Nodes)
+	default:
+		return "go infinite"
+	}
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/uci"
+)
+
+const engineEnv = "GAMBIT_CLIENT_TEST_ENGINE"
+
+// TestMain runs Gambit's own UCI interface when the test binary is started as the engine process.
+func TestMain(m *testing.M) {
+	if os.Getenv(engineEnv) == "1" {
+		_ = uci.NewInterface().Run()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testContext returns a context ending at the deadline of the test, if any.
+func testContext(t *testing.T) context.Context {
+	ctx := context.Background()
+	if deadline, ok := t.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		t.Cleanup(cancel)
+	}
+	return ctx
+}
+
+// TestStart runs Gambit in a child process, which pays for the lookup table initialization again.
+func TestStart(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping engine process in short mode")
+	}
+	t.Setenv(engineEnv, "1")
+	c, err := Start(os.Args[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := c.Handshake(testContext(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := uci.EngineName + " " + uci.EngineVersion; info.Name != want {
+		t.Errorf("unexpected name: got=%s want=%s", info.Name, want)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+	engineR, clientW := io.Pipe()
+	clientR, engineW := io.Pipe()
+	go func() {
+		_ = uci.NewInterface(uci.WithReader(engineR), uci.WithWriter(engineW)).Run()
+		_ = engineR.Close()
+		_ = engineW.Close()
+	}()
+	c := New(struct {
+		io.Reader
+		io.Writer
+	}{clientR, clientW})
+	defer c.Close()
+
+	ctx := testContext(t)
+	info, err := c.Handshake(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := uci.EngineName + " " + uci.EngineVersion; info.Name != want {
+		t.Errorf("unexpected name: got=%s want=%s", info.Name, want)
+	}
+	if info.Author != uci.EngineAuthor {
+		t.Errorf("unexpected author: got=%s want=%s", info.Author, uci.EngineAuthor)
+	}
+	var hash *Option
+	for i := range info.Options {
+		if info.Options[i].Name == "Hash" {
+			hash = &info.Options[i]
+		}
+	}
+	if hash == nil || hash.Type != "spin" {
+		t.Errorf("unexpected Hash option: got=%+v", hash)
+	}
+
+	if err := c.SetOption("Hash", "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.NewGame(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Position("", []string{"e2e4", "e7e5"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var infos []*Info
+	bm, err := c.Go(ctx, &engine.ClockConfig{Depth: 3}, func(info *Info) {
+		infos = append(infos, info)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bm.Move == "" {
+		t.Errorf("unexpected bestmove: got=%+v", bm)
+	}
+	if len(infos) == 0 || infos[len(infos)-1].Depth != 3 || infos[len(infos)-1].Score == nil {
+		t.Errorf("unexpected infos: got=%d", len(infos))
+	} else if last := infos[len(infos)-1]; last.PV[0] != bm.Move {
+		t.Errorf("unexpected pv: got=%s want=%s", last.PV[0], bm.Move)
+	}
+}
+
+func TestClientGoCancel(t *testing.T) {
+	t.Parallel()
+	engineR, clientW := io.Pipe()
+	clientR, engineW := io.Pipe()
+	c := New(struct {
+		io.Reader
+		io.Writer
+	}{clientR, clientW})
+
+	// scripted engine searching until stopped
+	go func() {
+		defer engineR.Close()
+		defer engineW.Close()
+		scanner := bufio.NewScanner(engineR)
+		for scanner.Scan() {
+			switch fields := strings.Fields(scanner.Text()); fields[0] {
+			case "go":
+				_, _ = io.WriteString(engineW, "info depth 1 score cp 10 pv d2d4\n")
+			case "stop":
+				_, _ = io.WriteString(engineW, "bestmove d2d4 ponder d7d5\n")
+			case "quit":
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bm, err := c.Go(ctx, nil, func(info *Info) {
+		cancel()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (BestMove{Move: "d2d4", Ponder: "d7d5"}); *bm != want {
+		t.Errorf("unexpected bestmove: got=%+v want=%+v", bm, want)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := c.Go(context.Background(), nil, nil); err == nil {
+		t.Errorf("error expected: got=nil")
+	}
+}
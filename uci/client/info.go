@@ -0,0 +1,170 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Info is a parsed info line of the engine. Fields not reported by the line are left zero.
+type Info struct {
+	Depth          int
+	SelDepth       int
+	MultiPV        int
+	Score          *Score
+	Time           time.Duration
+	Nodes          uint64
+	NPS            uint64
+	HashFull       int
+	TBHits         uint64
+	CurrMove       string
+	CurrMoveNumber int
+	PV             []string
+	String         string
+}
+
+// Score is the evaluation of the engine from the side to move, either in centipawns or moves to mate.
+type Score struct {
+	Centipawns int
+	Mate       int // negative if the engine is getting mated
+	IsMate     bool
+	Lowerbound bool
+	Upperbound bool
+}
+
+func (s *Score) String() string {
+	var bound string
+	switch {
+	case s.Lowerbound:
+		bound = " lowerbound"
+	case s.Upperbound:
+		bound = " upperbound"
+	}
+	if s.IsMate {
+		return fmt.Sprintf("mate %d%s", s.Mate, bound)
+	}
+	return fmt.Sprintf("cp %d%s", s.Centipawns, bound)
+}
+
+// BestMove is the result of a search, in UCI notation. Ponder is empty if not reported.
+type BestMove struct {
+	Move   string
+	Ponder string
+}
+
+// ParseInfo parses an info line, e.g. "info depth 1 score cp 36 nodes 41 pv g1f3". Unknown keys are skipped.
+func ParseInfo(line string) (*Info, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "info" {
+		return nil, fmt.Errorf("not an info line: %s", line)
+	}
+
+	info := &Info{}
+	for i := 1; i < len(fields); i++ {
+		key := fields[i]
+		next := func() (string, error) {
+			if i+1 >= len(fields) {
+				return "", fmt.Errorf("missing info %s value", key)
+			}
+			i++
+			return fields[i], nil
+		}
+		nextInt := func() (int, error) {
+			value, err := next()
+			if err != nil {
+				return 0, err
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return 0, fmt.Errorf("invalid info %s value: %w", key, err)
+			}
+			return n, nil
+		}
+		nextUint := func() (uint64, error) {
+			value, err := next()
+			if err != nil {
+				return 0, err
+			}
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid info %s value: %w", key, err)
+			}
+			return n, nil
+		}
+
+		var err error
+		switch key {
+		case "depth":
+			info.Depth, err = nextInt()
+		case "seldepth":
+			info.SelDepth, err = nextInt()
+		case "multipv":
+			info.MultiPV, err = nextInt()
+		case "time":
+			var ms uint64
+			ms, err = nextUint()
+			info.Time = time.Duration(ms) * time.Millisecond
+		case "nodes":
+			info.Nodes, err = nextUint()
+		case "nps":
+			info.NPS, err = nextUint()
+		case "hashfull":
+			info.HashFull, err = nextInt()
+		case "tbhits":
+			info.TBHits, err = nextUint()
+		case "currmove":
+			info.CurrMove, err = next()
+		case "currmovenumber":
+			info.CurrMoveNumber, err = nextInt()
+		case "score":
+			info.Score = &Score{}
+			var kind string
+			if kind, err = next(); err != nil {
+				break
+			}
+			switch kind {
+			case "cp":
+				info.Score.Centipawns, err = nextInt()
+			case "mate":
+				info.Score.IsMate = true
+				info.Score.Mate, err = nextInt()
+			default:
+				err = fmt.Errorf("invalid info score type: %s", kind)
+			}
+			for err == nil && i+1 < len(fields) {
+				if fields[i+1] == "lowerbound" {
+					info.Score.Lowerbound = true
+				} else if fields[i+1] == "upperbound" {
+					info.Score.Upperbound = true
+				} else {
+					break
+				}
+				i++
+			}
+		case "pv":
+			info.PV = append([]string{}, fields[i+1:]...)
+			i = len(fields)
+		case "string":
+			info.String = strings.Join(fields[i+1:], " ")
+			i = len(fields)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// ParseBestMove parses a bestmove line, e.g. "bestmove e2e4 ponder e7e5".
+func ParseBestMove(line string) (*BestMove, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "bestmove" {
+		return nil, fmt.Errorf("invalid bestmove line: %s", line)
+	}
+	bm := &BestMove{Move: fields[1]}
+	if len(fields) >= 4 && fields[2] == "ponder" {
+		bm.Ponder = fields[3]
+	}
+	return bm, nil
+}
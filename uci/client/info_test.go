@@ -0,0 +1,139 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInfo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line    string
+		want    *Info
+		wantErr bool
+	}{
+		{
+			line: "info depth 2 score cp 20 time 1 nodes 187 nps 492900 pv g1f3 g8f6",
+			want: &Info{Depth: 2, Score: &Score{Centipawns: 20}, Time: time.Millisecond, Nodes: 187, NPS: 492900, PV: []string{"g1f3", "g8f6"}},
+		},
+		{
+			line: "info depth 12 seldepth 18 multipv 2 score mate -3 lowerbound hashfull 512 tbhits 4 pv e1e2",
+			want: &Info{Depth: 12, SelDepth: 18, MultiPV: 2, Score: &Score{Mate: -3, IsMate: true, Lowerbound: true}, HashFull: 512, TBHits: 4, PV: []string{"e1e2"}},
+		},
+		{
+			line: "info currmove e2e4 currmovenumber 1",
+			want: &Info{CurrMove: "e2e4", CurrMoveNumber: 1},
+		},
+		{
+			line: "info string found 5-piece tablebase",
+			want: &Info{String: "found 5-piece tablebase"},
+		},
+		{line: "info depth x", wantErr: true},
+		{line: "info depth", wantErr: true},
+		{line: "info score wdl 1 2 3", wantErr: true},
+		{line: "bestmove e2e4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+			info, err := ParseInfo(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("error expected: got=nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(info, tt.want) {
+				t.Errorf("unexpected info: got=%+v want=%+v", info, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBestMove(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line    string
+		want    *BestMove
+		wantErr bool
+	}{
+		{line: "bestmove e2e4", want: &BestMove{Move: "e2e4"}},
+		{line: "bestmove e2e4 ponder e7e5", want: &BestMove{Move: "e2e4", Ponder: "e7e5"}},
+		{line: "bestmove", wantErr: true},
+		{line: "info depth 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+			bm, err := ParseBestMove(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("error expected: got=nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *bm != *tt.want {
+				t.Errorf("unexpected bestmove: got=%+v want=%+v", bm, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOption(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line    string
+		want    *Option
+		wantErr bool
+	}{
+		{
+			line: "option name Hash type spin default 64 min 0 max 2048",
+			want: &Option{Name: "Hash", Type: "spin", Default: "64", Min: "0", Max: "2048"},
+		},
+		{
+			line: "option name SyzygyPath type string default <empty>",
+			want: &Option{Name: "SyzygyPath", Type: "string", Default: "<empty>"},
+		},
+		{
+			line: "option name Clear Hash type button",
+			want: &Option{Name: "Clear Hash", Type: "button"},
+		},
+		{
+			line: "option name Style type combo default Normal var Solid var Normal var Risky",
+			want: &Option{Name: "Style", Type: "combo", Default: "Normal", Vars: []string{"Solid", "Normal", "Risky"}},
+		},
+		{line: "option name Hash", wantErr: true},
+		{line: "id name Gambit", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.line, func(t *testing.T) {
+			t.Parallel()
+			opt, err := ParseOption(tt.line)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("error expected: got=nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(opt, tt.want) {
+				t.Errorf("unexpected option: got=%+v want=%+v", opt, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Option is an option advertised by the engine. Min and Max are only set for spin options, and Vars for
+// combo options.
+type Option struct {
+	Name    string
+	Type    string
+	Default string
+	Min     string
+	Max     string
+	Vars    []string
+}
+
+// ParseOption parses an option line, e.g. "option name Hash type spin default 64 min 0 max 2048". Names and
+// values may contain spaces.
+func ParseOption(line string) (*Option, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "option" {
+		return nil, fmt.Errorf("not an option line: %s", line)
+	}
+
+	opt := &Option{}
+	var key string
+	var value []string
+	flush := func() {
+		joined := strings.Join(value, " ")
+		switch key {
+		case "name":
+			opt.Name = joined
+		case "type":
+			opt.Type = joined
+		case "default":
+			opt.Default = joined
+		case "min":
+			opt.Min = joined
+		case "max":
+			opt.Max = joined
+		case "var":
+			opt.Vars = append(opt.Vars, joined)
+		}
+		value = nil
+	}
+	for _, field := range fields[1:] {
+		switch field {
+		case "name", "type", "default", "min", "max", "var":
+			if key != "name" || field == "type" { // names may contain keywords until the type
+				flush()
+				key = field
+				continue
+			}
+		}
+		value = append(value, field)
+	}
+	flush()
+
+	if opt.Name == "" || opt.Type == "" {
+		return nil, fmt.Errorf("invalid option line: %s", line)
+	}
+	return opt, nil
+}
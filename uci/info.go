@@ -0,0 +1,48 @@
+package uci
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daystram/gambit/engine"
+)
+
+// formatInfo returns the info line of the search progress.
+func formatInfo(info *engine.SearchInfo) string {
+	var sb strings.Builder
+	switch info.Type {
+	case engine.SearchInfoTypeCurrMove:
+		fmt.Fprintf(&sb, "info depth %d currmove %s currmovenumber %d", info.Depth, info.CurrMove.UCI(), info.CurrMoveNumber)
+	case engine.SearchInfoTypeProgress:
+		fmt.Fprintf(&sb, "info time %d nodes %d nps %d hashfull %d", info.Time.Milliseconds(), info.Nodes, info.NPS, info.HashFull)
+	case engine.SearchInfoTypeIncomplete:
+		fmt.Fprintf(&sb, "info string depth %d incomplete after %d root moves, best %s score %s",
+			info.Depth, info.CurrMoveNumber, formatPV(info), formatScore(info))
+	default:
+		fmt.Fprintf(&sb, "info depth %d", info.Depth)
+		if info.SelDepth != 0 {
+			fmt.Fprintf(&sb, " seldepth %d", info.SelDepth)
+		}
+		fmt.Fprintf(&sb, " score %s time %d nodes %d nps %d hashfull %d",
+			formatScore(info), info.Time.Milliseconds(), info.Nodes, info.NPS, info.HashFull)
+		if len(info.PV) != 0 {
+			sb.WriteString(" pv " + formatPV(info))
+		}
+	}
+	return sb.String()
+}
+
+func formatScore(info *engine.SearchInfo) string {
+	if info.ScoreType == engine.ScoreTypeMate {
+		return fmt.Sprintf("mate %d", info.Score)
+	}
+	return fmt.Sprintf("cp %d", info.Score)
+}
+
+func formatPV(info *engine.SearchInfo) string {
+	mvs := make([]string, 0, len(info.PV))
+	for _, mv := range info.PV {
+		mvs = append(mvs, mv.UCI())
+	}
+	return strings.Join(mvs, " ")
+}
@@ -0,0 +1,72 @@
+package uci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+)
+
+func TestFormatInfo(t *testing.T) {
+	t.Parallel()
+	b, err := board.NewBoard()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e2e4, err := b.NewMoveFromUCI("e2e4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		name string
+		info *engine.SearchInfo
+		want string
+	}{
+		{
+			name: "centipawns",
+			info: &engine.SearchInfo{
+				Depth: 3, ScoreType: engine.ScoreTypeCentipawns, Score: 25, Time: 12 * time.Millisecond,
+				Nodes: 1200, NPS: 100000, HashFull: 5, PV: []board.Move{e2e4},
+			},
+			want: "info depth 3 score cp 25 time 12 nodes 1200 nps 100000 hashfull 5 pv e2e4",
+		},
+		{
+			name: "mated with seldepth",
+			info: &engine.SearchInfo{
+				Depth: 4, SelDepth: 9, ScoreType: engine.ScoreTypeMate, Score: -2, PV: []board.Move{e2e4},
+			},
+			want: "info depth 4 seldepth 9 score mate -2 time 0 nodes 0 nps 0 hashfull 0 pv e2e4",
+		},
+		{
+			name: "currmove",
+			info: &engine.SearchInfo{
+				Type: engine.SearchInfoTypeCurrMove, Depth: 12, CurrMove: e2e4, CurrMoveNumber: 3,
+			},
+			want: "info depth 12 currmove e2e4 currmovenumber 3",
+		},
+		{
+			name: "progress",
+			info: &engine.SearchInfo{
+				Type: engine.SearchInfoTypeProgress, Time: 2 * time.Second, Nodes: 3000000, NPS: 1500000, HashFull: 420,
+			},
+			want: "info time 2000 nodes 3000000 nps 1500000 hashfull 420",
+		},
+		{
+			name: "incomplete",
+			info: &engine.SearchInfo{
+				Type: engine.SearchInfoTypeIncomplete, Depth: 9, Score: -15, PV: []board.Move{e2e4}, CurrMoveNumber: 4,
+			},
+			want: "info string depth 9 incomplete after 4 root moves, best e2e4 score cp -15",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := formatInfo(tt.info); got != tt.want {
+				t.Errorf("unexpected info: got=%q want=%q", got, tt.want)
+			}
+		})
+	}
+}
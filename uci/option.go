@@ -0,0 +1,219 @@
+package uci
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type optionType string
+
+const (
+	optionTypeCheck  optionType = "check"
+	optionTypeSpin   optionType = "spin"
+	optionTypeCombo  optionType = "combo"
+	optionTypeButton optionType = "button"
+	optionTypeString optionType = "string"
+
+	// emptyString is the UCI convention for an empty string value.
+	emptyString = "<empty>"
+)
+
+var errUnknownOption = errors.New("unknown option")
+
+// option is an option advertised to the GUI. The value is validated by its type before onChange is called.
+type option struct {
+	name       string
+	typ        optionType
+	defaultVal string
+	min, max   int
+	vars       []string
+
+	// onChange applies the validated value, which is empty for buttons and strings set to <empty>.
+	onChange func(value string) error
+}
+
+func newCheckOption(name string, defaultVal bool, onChange func(bool) error) *option {
+	return &option{
+		name:       name,
+		typ:        optionTypeCheck,
+		defaultVal: strconv.FormatBool(defaultVal),
+		onChange: func(value string) error {
+			return onChange(value == "true")
+		},
+	}
+}
+
+func newSpinOption(name string, defaultVal, min, max int, onChange func(int) error) *option {
+	return &option{
+		name:       name,
+		typ:        optionTypeSpin,
+		defaultVal: strconv.Itoa(defaultVal),
+		min:        min,
+		max:        max,
+		onChange: func(value string) error {
+			n, _ := strconv.Atoi(value)
+			return onChange(n)
+		},
+	}
+}
+
+func newComboOption(name, defaultVal string, vars []string, onChange func(string) error) *option {
+	return &option{
+		name:       name,
+		typ:        optionTypeCombo,
+		defaultVal: defaultVal,
+		vars:       vars,
+		onChange:   onChange,
+	}
+}
+
+func newButtonOption(name string, onPress func() error) *option {
+	return &option{
+		name: name,
+		typ:  optionTypeButton,
+		onChange: func(string) error {
+			return onPress()
+		},
+	}
+}
+
+func newStringOption(name, defaultVal string, onChange func(string) error) *option {
+	return &option{
+		name:       name,
+		typ:        optionTypeString,
+		defaultVal: defaultVal,
+		onChange:   onChange,
+	}
+}
+
+// String returns the option line sent in reply to uci.
+func (o *option) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "option name %s type %s", o.name, o.typ)
+	switch o.typ {
+	case optionTypeButton:
+	case optionTypeString:
+		defaultVal := o.defaultVal
+		if defaultVal == "" {
+			defaultVal = emptyString
+		}
+		fmt.Fprintf(&sb, " default %s", defaultVal)
+	default:
+		fmt.Fprintf(&sb, " default %s", o.defaultVal)
+	}
+	if o.typ == optionTypeSpin {
+		fmt.Fprintf(&sb, " min %d max %d", o.min, o.max)
+	}
+	for _, v := range o.vars {
+		fmt.Fprintf(&sb, " var %s", v)
+	}
+	return sb.String()
+}
+
+// normalize validates the value, returning it in the canonical form passed to onChange.
+func (o *option) normalize(value string) (string, error) {
+	switch o.typ {
+	case optionTypeCheck:
+		switch strings.ToLower(value) {
+		case "true":
+			return "true", nil
+		case "false":
+			return "false", nil
+		}
+		return "", fmt.Errorf("invalid value: %s, expected true or false", value)
+	case optionTypeSpin:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid value: %s", value)
+		}
+		if n < o.min || n > o.max {
+			return "", fmt.Errorf("value out of range: %d, expected between %d and %d", n, o.min, o.max)
+		}
+		return strconv.Itoa(n), nil
+	case optionTypeCombo:
+		for _, v := range o.vars {
+			if strings.EqualFold(v, value) {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("invalid value: %s, expected one of %s", value, strings.Join(o.vars, ", "))
+	case optionTypeButton:
+		if value != "" {
+			return "", fmt.Errorf("unexpected value for button: %s", value)
+		}
+		return "", nil
+	default:
+		if value == emptyString {
+			return "", nil
+		}
+		return value, nil
+	}
+}
+
+// optionRegistry holds the options in the order they are advertised. Names are case-insensitive.
+type optionRegistry struct {
+	options []*option
+	byName  map[string]*option
+}
+
+func newOptionRegistry(opts ...*option) *optionRegistry {
+	r := &optionRegistry{
+		byName: make(map[string]*option, len(opts)),
+	}
+	for _, opt := range opts {
+		r.options = append(r.options, opt)
+		r.byName[strings.ToLower(opt.name)] = opt
+	}
+	return r
+}
+
+// Lines returns the option lines, in registration order.
+func (r *optionRegistry) Lines() []string {
+	lines := make([]string, 0, len(r.options))
+	for _, opt := range r.options {
+		lines = append(lines, opt.String())
+	}
+	return lines
+}
+
+// Set validates the value and applies it to the option.
+func (r *optionRegistry) Set(name, value string) error {
+	opt, ok := r.byName[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("%w: %s", errUnknownOption, name)
+	}
+	normalized, err := opt.normalize(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", opt.name, err)
+	}
+	if err := opt.onChange(normalized); err != nil {
+		return fmt.Errorf("%s: %w", opt.name, err)
+	}
+	return nil
+}
+
+// parseSetOption parses the arguments of setoption, i.e. "name <id> [value <x>]", where both the name and the
+// value may contain spaces.
+func parseSetOption(args []string) (string, string, error) {
+	if len(args) < 2 || args[0] != "name" {
+		return "", "", errors.New("expected name")
+	}
+	end := 1
+	for end < len(args) && args[end] != "value" {
+		end++
+	}
+	name := strings.Join(args[1:end], " ")
+	if name == "" {
+		return "", "", errors.New("expected name")
+	}
+	if end == len(args) {
+		return name, "", nil
+	}
+	value := strings.Join(args[end+1:], " ")
+	if value == "" {
+		return "", "", fmt.Errorf("expected value for %s", name)
+	}
+	return name, value, nil
+}
@@ -0,0 +1,136 @@
+package uci
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseSetOption(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		args      []string
+		wantName  string
+		wantValue string
+		wantErr   bool
+	}{
+		{args: []string{"name", "Hash", "value", "16"}, wantName: "Hash", wantValue: "16"},
+		{args: []string{"name", "Clear", "Hash"}, wantName: "Clear Hash"},
+		{args: []string{"name", "BookFile", "value", "/tmp/my", "book.bin"}, wantName: "BookFile", wantValue: "/tmp/my book.bin"},
+		{args: []string{"name", "Skill", "Level", "value", "3"}, wantName: "Skill Level", wantValue: "3"},
+		{args: []string{"name"}, wantErr: true},
+		{args: []string{"name", "value", "1"}, wantErr: true},
+		{args: []string{"name", "Hash", "value"}, wantErr: true},
+		{args: []string{"Hash", "value", "16"}, wantErr: true},
+		{args: []string{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprint(tt.args), func(t *testing.T) {
+			t.Parallel()
+			name, value, err := parseSetOption(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("error expected: got=nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != tt.wantName || value != tt.wantValue {
+				t.Errorf("unexpected option: got=%q=%q want=%q=%q", name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestOptionRegistry(t *testing.T) {
+	t.Parallel()
+	var check bool
+	var spin int
+	var combo, str string
+	var pressed int
+	r := newOptionRegistry(
+		newCheckOption("Check", false, func(value bool) error {
+			check = value
+			return nil
+		}),
+		newSpinOption("Spin Value", 8, 1, 16, func(value int) error {
+			spin = value
+			return nil
+		}),
+		newComboOption("Combo", "Normal", []string{"Solid", "Normal", "Risky"}, func(value string) error {
+			combo = value
+			return nil
+		}),
+		newButtonOption("Button", func() error {
+			pressed++
+			return nil
+		}),
+		newStringOption("String", "", func(value string) error {
+			str = value
+			return nil
+		}),
+		newStringOption("Failing", "x", func(value string) error {
+			return errors.New("cannot apply")
+		}),
+	)
+
+	wantLines := []string{
+		"option name Check type check default false",
+		"option name Spin Value type spin default 8 min 1 max 16",
+		"option name Combo type combo default Normal var Solid var Normal var Risky",
+		"option name Button type button",
+		"option name String type string default <empty>",
+		"option name Failing type string default x",
+	}
+	lines := r.Lines()
+	if len(lines) != len(wantLines) {
+		t.Fatalf("unexpected line count: got=%d want=%d", len(lines), len(wantLines))
+	}
+	for n := range lines {
+		if lines[n] != wantLines[n] {
+			t.Errorf("unexpected line: got=%q want=%q", lines[n], wantLines[n])
+		}
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "check", value: "TRUE"},
+		{name: "Check", value: "yes", wantErr: true},
+		{name: "spin value", value: "16"},
+		{name: "Spin Value", value: "17", wantErr: true},
+		{name: "Spin Value", value: "0", wantErr: true},
+		{name: "Spin Value", value: "x", wantErr: true},
+		{name: "Combo", value: "risky"},
+		{name: "Combo", value: "Wild", wantErr: true},
+		{name: "Button", value: ""},
+		{name: "Button", value: "now", wantErr: true},
+		{name: "String", value: "a b c"},
+		{name: "Failing", value: "y", wantErr: true},
+		{name: "Unknown", value: "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := r.Set(tt.name, tt.value)
+		if tt.wantErr && err == nil {
+			t.Errorf("error expected for %s=%s: got=nil", tt.name, tt.value)
+		} else if !tt.wantErr && err != nil {
+			t.Errorf("unexpected error for %s=%s: %v", tt.name, tt.value, err)
+		}
+	}
+	if !check || spin != 16 || combo != "Risky" || pressed != 1 || str != "a b c" {
+		t.Errorf("unexpected values: got=%v,%d,%s,%d,%q", check, spin, combo, pressed, str)
+	}
+
+	if err := r.Set("String", emptyString); err != nil || str != "" {
+		t.Errorf("unexpected empty string: got=%q err=%v", str, err)
+	}
+	if err := r.Set("Unknown", ""); !errors.Is(err, errUnknownOption) {
+		t.Errorf("unexpected error: got=%v want=%v", err, errUnknownOption)
+	}
+}
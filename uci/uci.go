@@ -5,17 +5,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/daystram/gambit/bench"
 	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/book"
 	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/tablebase"
 )
 
 var (
+	errQuit = errors.New("quit")
+
 	EngineName    = "Gambit"
 	EngineVersion = "Dev"
 	EngineAuthor  = "Danny August Ramaputra"
@@ -24,75 +30,241 @@ var (
 		debug:         false,
 		hashTableSize: engine.DefaultHashTableSizeMB,
 		parallelPerft: false,
+		syzygyPath:    "",
+		ownBook:       false,
+		bookFile:      "",
+		ponder:        false,
 	}
+
+	maxHashTableSizeMB = 4096
 )
 
 type options struct {
 	debug         bool
 	hashTableSize uint32
 	parallelPerft bool
+	syzygyPath    string
+	ownBook       bool
+	bookFile      string
+	ponder        bool
 }
 
 type Interface struct {
-	board   *board.Board
-	engine  *engine.Engine
-	options options
+	board       *board.Board
+	engine      *engine.Engine
+	options     options
+	tablebase   *tablebase.Syzygy
+	openingBook *book.Book
+	registry    *optionRegistry
+
+	mu     sync.Mutex // guards search
+	search *search    // the current or last search, nil if none started
+
+	reader io.Reader
+	writer io.Writer
+	logger func(...any)
+	outMu  sync.Mutex // guards writer, so that lines from the search goroutine never interleave with replies
+}
+
+type engineState uint8
+
+const (
+	engineStateIdle engineState = iota
+	engineStateSearching
+	engineStatePondering
+)
+
+// search is a search goroutine started by go. The bestmove line is written before done is closed.
+type search struct {
+	state    engineState // guarded by Interface.mu
+	discard  bool        // guarded by Interface.mu, set to drop the result of a pondering search on ponderhit
+	board    *board.Board
+	clockCfg engine.ClockConfig // clock used on ponderhit
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+type interfaceConfig struct {
+	reader io.Reader
+	writer io.Writer
+	logger func(...any)
+}
 
-	engineRunning bool
-	engineCancel  context.CancelFunc
+type InterfaceOption func(*interfaceConfig)
+
+// WithReader sets the source of the commands, defaulting to os.Stdin.
+func WithReader(r io.Reader) InterfaceOption {
+	return func(cfg *interfaceConfig) {
+		cfg.reader = r
+	}
+}
+
+// WithWriter sets the destination of the responses, defaulting to os.Stdout.
+func WithWriter(w io.Writer) InterfaceOption {
+	return func(cfg *interfaceConfig) {
+		cfg.writer = w
+	}
 }
 
-func NewInterface() *Interface {
-	return &Interface{
+// WithLogger sets the logger receiving a transcript of the session, with the received commands prefixed by
+// "> " and the sent lines by "< ". Nothing is logged by default.
+func WithLogger(logger func(...any)) InterfaceOption {
+	return func(cfg *interfaceConfig) {
+		cfg.logger = logger
+	}
+}
+
+func NewInterface(opts ...InterfaceOption) *Interface {
+	cfg := &interfaceConfig{
+		reader: os.Stdin,
+		writer: os.Stdout,
+	}
+	for _, f := range opts {
+		f(cfg)
+	}
+
+	i := &Interface{
 		options: defaultOptions,
+		reader:  cfg.reader,
+		writer:  cfg.writer,
+		logger:  cfg.logger,
 	}
+	i.registry = i.newOptionRegistry()
+	return i
 }
 
+func (i *Interface) newOptionRegistry() *optionRegistry {
+	return newOptionRegistry(
+		newCheckOption("Debug", defaultOptions.debug, func(value bool) error {
+			i.options.debug = value
+			return nil
+		}),
+		newSpinOption("Hash", int(defaultOptions.hashTableSize), 0, maxHashTableSizeMB, func(value int) error {
+			i.options.hashTableSize = uint32(value)
+			i.newEngine()
+			return nil
+		}),
+		newButtonOption("Clear Hash", func() error {
+			i.newEngine()
+			return nil
+		}),
+		newCheckOption("Ponder", defaultOptions.ponder, func(value bool) error {
+			i.options.ponder = value
+			return nil
+		}),
+		newCheckOption("OwnBook", defaultOptions.ownBook, func(value bool) error {
+			i.options.ownBook = value
+			return nil
+		}),
+		newStringOption("BookFile", defaultOptions.bookFile, func(value string) error {
+			if value == "" {
+				i.options.bookFile, i.openingBook = "", nil
+				return nil
+			}
+			bk, err := book.Open(value)
+			if err != nil {
+				return fmt.Errorf("cannot load book: %w", err)
+			}
+			i.options.bookFile, i.openingBook = value, bk
+			i.println(fmt.Sprintf("info string found %d book entries", bk.Len()))
+			return nil
+		}),
+		newStringOption("SyzygyPath", defaultOptions.syzygyPath, func(value string) error {
+			tb, err := tablebase.NewSyzygy(value)
+			if err != nil {
+				return fmt.Errorf("cannot load tablebase: %w", err)
+			}
+			if i.tablebase != nil {
+				_ = i.tablebase.Close()
+			}
+			i.options.syzygyPath, i.tablebase = value, tb
+			i.newEngine()
+			if value != "" {
+				i.println(fmt.Sprintf("info string found %d-piece tablebase", tb.MaxPieces()))
+			}
+			return nil
+		}),
+		newCheckOption("ParallelPerft", defaultOptions.parallelPerft, func(value bool) error {
+			i.options.parallelPerft = value
+			return nil
+		}),
+	)
+}
+
+// Run reads and executes the commands until quit or the end of the input.
 func (i *Interface) Run() error {
 	ctx := context.Background()
 	i.reset(ctx)
 	i.println(fmt.Sprintf("%s %s", EngineName, EngineVersion))
 
-	reader := bufio.NewReader(os.Stdin)
+	reader := bufio.NewReader(i.reader)
 	for {
 		cmd, err := reader.ReadString('\n')
-		if err != nil {
+		if err != nil && (!errors.Is(err, io.EOF) || cmd == "") {
+			i.commandStop(ctx)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
 			return err
 		}
 		args := strings.Fields(strings.TrimSpace(cmd))
 		if len(args) == 0 {
 			continue
 		}
+		if i.logger != nil {
+			i.logger("> " + strings.Join(args, " "))
+		}
 
-		switch args[0] {
-		case "uci":
-			i.commandUCI(ctx)
-		case "ucinewgame":
-			i.reset(ctx)
-		case "isready":
-			i.commandReady(ctx)
-		case "setoption":
-			i.commandSetOption(ctx, args[1:])
-		case "position":
-			i.commandPosition(ctx, args[1:])
-		case "d":
-			i.commandDraw(ctx)
-		case "go":
-			i.commandGo(ctx, args[1:])
-		case "stop":
-			i.commandStop(ctx)
-		case "quit":
-			return nil
+		if err := i.execute(ctx, args); err != nil {
+			if errors.Is(err, errQuit) {
+				return nil
+			}
+			i.println(fmt.Sprintf("info string error: %v", err))
 		}
 	}
 }
 
+// execute runs the command, returning an error if it is malformed.
+func (i *Interface) execute(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "uci":
+		i.commandUCI(ctx)
+	case "ucinewgame":
+		i.reset(ctx)
+	case "isready":
+		i.commandReady(ctx)
+	case "setoption":
+		return i.commandSetOption(ctx, args[1:])
+	case "position":
+		return i.commandPosition(ctx, args[1:])
+	case "d":
+		i.commandDraw(ctx)
+	case "bench":
+		return i.commandBench(ctx, args[1:])
+	case "go":
+		return i.commandGo(ctx, args[1:])
+	case "stop":
+		i.commandStop(ctx)
+	case "ponderhit":
+		i.commandPonderHit(ctx)
+	case "quit":
+		i.commandStop(ctx)
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+	return nil
+}
+
 func (i *Interface) commandUCI(_ context.Context) {
-	i.println(fmt.Sprintf("id name %s %s", EngineName, EngineVersion))
-	i.println(fmt.Sprintf("id author %s", EngineAuthor))
-	i.println(fmt.Sprintf("option name Debug type check default %v", defaultOptions.debug))
-	i.println(fmt.Sprintf("option name Hash type spin default %d min 0 max 2048", defaultOptions.hashTableSize))
-	i.println("uciok")
+	lines := []any{
+		fmt.Sprintf("id name %s %s", EngineName, EngineVersion),
+		fmt.Sprintf("id author %s", EngineAuthor),
+	}
+	for _, line := range i.registry.Lines() {
+		lines = append(lines, line)
+	}
+	i.println(append(lines, "uciok")...)
 }
 
 func (i *Interface) commandReady(_ context.Context) {
@@ -101,218 +273,347 @@ func (i *Interface) commandReady(_ context.Context) {
 	}
 }
 
-func (i *Interface) commandSetOption(_ context.Context, args []string) {
-	// TODO: support comma separated names
-	if len(args) < 4 || args[0] != "name" || args[2] != "value" {
-		return
+func (i *Interface) commandSetOption(_ context.Context, args []string) error {
+	name, value, err := parseSetOption(args)
+	if err != nil {
+		return fmt.Errorf("setoption: %w", err)
 	}
-	switch name, valueStr := strings.ToLower(args[1]), args[3]; name {
-	case "debug":
-		value, err := strconv.ParseBool(valueStr)
-		if err != nil {
-			return
-		}
-		i.options.debug = value
-	case "hash":
-		value, err := strconv.ParseUint(valueStr, 10, 32)
-		if err != nil || value > 4096 {
-			return
-		}
-		i.options.hashTableSize = uint32(value)
-	case "parallelperft":
-		value, err := strconv.ParseBool(valueStr)
-		if err != nil {
-			return
-		}
-		i.options.parallelPerft = value
+	if i.engineState() != engineStateIdle {
+		return errors.New("setoption: cannot set options while searching")
+	}
+	if err := i.registry.Set(name, value); err != nil {
+		return fmt.Errorf("setoption: %w", err)
 	}
+	return nil
 }
 
-func (i *Interface) commandPosition(_ context.Context, args []string) {
-	if i.engineRunning || len(args) == 0 {
-		return
+// commandPosition sets the position only if the FEN and every move are valid, keeping the current position
+// otherwise.
+func (i *Interface) commandPosition(_ context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("position: expected startpos or fen")
 	}
 
 	var fen string
 	switch args[0] {
 	case "fen":
-		if len(args) < 7 {
-			panic("bad fen")
+		end := 1
+		for end < len(args) && args[end] != "moves" {
+			end++
 		}
-		fen = strings.Join(args[1:7], " ")
-		args = args[7:]
+		fen = strings.Join(args[1:end], " ")
+		args = args[end:]
 	case "startpos":
 		fen = board.DefaultStartingPositionFEN
 		args = args[1:]
 	default:
-		return
+		return fmt.Errorf("position: expected startpos or fen: got %s", args[0])
 	}
 
 	b, err := board.NewBoard(board.WithFEN(fen))
 	if err != nil {
-		return
+		return fmt.Errorf("position: %w", err)
 	}
 
-	if len(args) > 0 && args[0] == "moves" {
+	// the moves are only checked for legality, as ending the game by repetition or the move rules is left to
+	// the GUI
+	if len(args) > 0 {
+		if args[0] != "moves" {
+			return fmt.Errorf("position: expected moves: got %s", args[0])
+		}
 		for _, notation := range args[1:] {
 			mv, err := b.NewMoveFromUCI(notation)
-			if err != nil {
-				return
+			if err != nil || !b.IsLegalMove(mv) {
+				return fmt.Errorf("position: illegal move: %s", notation)
 			}
 			b.Apply(mv)
 		}
 	}
 
 	i.board = b
+	return nil
 }
 
 func (i *Interface) commandDraw(_ context.Context) {
-	i.println(i.board.Draw())
-	i.println("FEN :", i.board.FEN())
-	i.println("Hash:", i.board.Hash())
-	i.println("Stat:", i.board.State())
-	i.println("Eval:", i.engine.Evaluate(i.board))
-	i.println("Phas:", i.board.Phase())
+	eval := "-" // the engine is busy while searching
+	if i.engineState() == engineStateIdle {
+		eval = fmt.Sprint(i.engine.Evaluate(i.board))
+	}
+	i.println(
+		i.board.Draw(),
+		fmt.Sprint("FEN : ", i.board.FEN()),
+		fmt.Sprint("Hash: ", i.board.Hash()),
+		fmt.Sprint("Stat: ", i.board.State()),
+		fmt.Sprint("Eval: ", eval),
+		fmt.Sprint("Phas: ", i.board.Phase()),
+	)
 }
 
-func (i *Interface) commandGo(ctx context.Context, args []string) {
-	if i.engineRunning {
-		return
+// commandBench searches the bench positions to the depth, blocking until done. The total node count is the
+// signature of the engine build.
+func (i *Interface) commandBench(ctx context.Context, args []string) error {
+	if i.engineState() != engineStateIdle {
+		return errors.New("bench: search already running")
 	}
-
-	var clockCfg engine.ClockConfig
+	depth := bench.DefaultSearchDepth
 	if len(args) > 0 {
-		switch args[0] {
-		case "infinite":
-			clockCfg = engine.ClockConfig{}
-
-		case "movetime":
-			if len(args) != 2 {
-				return
-			}
-			movetime, err := strconv.ParseUint(args[1], 10, 64)
-			if err != nil || movetime == 0 {
-				return
-			}
+		d, err := strconv.ParseUint(args[0], 10, 8)
+		if err != nil || d == 0 {
+			return fmt.Errorf("bench: invalid depth: %s", args[0])
+		}
+		depth = uint8(d)
+	}
 
-			clockCfg = engine.ClockConfig{
-				Movetime: time.Duration(movetime) * time.Millisecond,
-			}
+	r, err := bench.Search(ctx, depth, bench.DefaultSearchHashTableMB, func(index int, fen string, nodes uint32) {
+		i.println(fmt.Sprintf("info string position %d %s nodes %d", index+1, fen, nodes))
+	})
+	if err != nil {
+		return fmt.Errorf("bench: %w", err)
+	}
+	i.println(
+		fmt.Sprintf("Nodes searched: %d", r.Nodes),
+		fmt.Sprintf("Nodes/second: %d", r.NPS()),
+	)
+	return nil
+}
 
-		case "wtime":
-			if len(args) < 4 {
-				return
-			}
-			whiteTime, err := strconv.ParseUint(args[1], 10, 64)
-			if err != nil || whiteTime == 0 {
-				return
-			}
-			blackTime, err := strconv.ParseUint(args[3], 10, 64)
-			if err != nil || blackTime == 0 {
-				return
-			}
-			var whiteIncrement, blackIncrement uint64
-			if len(args) == 8 { // increments only supported if both White's and Black's are provided
-				whiteIncrement, err = strconv.ParseUint(args[5], 10, 64)
-				if err != nil || whiteIncrement == 0 {
-					return
-				}
-				blackIncrement, err = strconv.ParseUint(args[7], 10, 64)
-				if err != nil || blackIncrement == 0 {
-					return
-				}
-			}
+func (i *Interface) commandGo(ctx context.Context, args []string) error {
+	if i.engineState() != engineStateIdle {
+		return errors.New("go: search already running")
+	}
 
-			clockCfg = engine.ClockConfig{
-				WhiteTime:      time.Duration(whiteTime) * time.Millisecond,
-				BlackTime:      time.Duration(blackTime) * time.Millisecond,
-				WhiteIncrement: time.Duration(whiteIncrement) * time.Millisecond,
-				BlackIncrement: time.Duration(blackIncrement) * time.Millisecond,
-			}
+	if len(args) > 0 && args[0] == "perft" {
+		if len(args) != 2 {
+			return errors.New("go perft: expected depth")
+		}
+		depth, err := strconv.Atoi(args[1])
+		if err != nil || depth < 0 {
+			return fmt.Errorf("go perft: invalid depth: %s", args[1])
+		}
 
-		case "depth":
-			if len(args) != 2 {
-				return
-			}
-			depth, err := strconv.ParseUint(args[1], 10, 8)
-			if err != nil || depth == 0 {
-				return
-			}
-			clockCfg = engine.ClockConfig{
-				Depth: uint8(depth),
+		out := make(chan string, 64)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for s := range out {
+				i.println(s)
 			}
+		}()
+		err = bench.Perft(depth, i.board.FEN(), i.options.parallelPerft, true, out)
+		close(out)
+		<-done
+		if err != nil {
+			return fmt.Errorf("go perft: %w", err)
+		}
+		return nil
+	}
 
-		case "nodes":
-			if len(args) != 2 {
-				return
-			}
-			nodes, err := strconv.ParseUint(args[1], 10, 32)
-			if err != nil || nodes == 0 {
-				return
-			}
-			clockCfg = engine.ClockConfig{
-				Nodes: uint32(nodes),
-			}
+	clockCfg, ponder, err := parseGo(args)
+	if err != nil {
+		return fmt.Errorf("go: %w", err)
+	}
 
-		case "perft":
-			if len(args) != 2 {
-				return
-			}
-			depth, err := strconv.Atoi(args[1])
-			if err != nil {
-				return
-			}
+	// bestmove must wait for stop or ponderhit when pondering or searching infinitely, so the book is skipped
+	infinite := clockCfg == engine.ClockConfig{}
+	if i.options.ownBook && i.openingBook != nil && !ponder && !infinite {
+		if mv, ok := i.openingBook.Probe(i.board, book.SelectionWeighted, nil); ok {
+			i.println(fmt.Sprintf("info string book move %s", mv.UCI()))
+			i.println(fmt.Sprintf("bestmove %s", mv.UCI()))
+			return nil
+		}
+	}
 
-			out := make(chan string, 64)
-			go func() {
-				for s := range out {
-					i.println(s)
-				}
-			}()
-			defer close(out)
+	i.startSearch(ctx, i.board.Clone(), clockCfg, ponder) // cloned, as the board may be drawn while searching
+	return nil
+}
 
-			_ = bench.Perft(depth, i.board.FEN(), i.options.parallelPerft, true, out)
-			return
+// parseGo parses the search limits of go. Without any limit, the search is infinite. Game clocks take
+// precedence over the other limits.
+func parseGo(args []string) (engine.ClockConfig, bool, error) {
+	var clockCfg engine.ClockConfig
+	var ponder bool
+	for n := 0; n < len(args); n++ {
+		key := args[n]
+		switch key {
+		case "infinite":
+			continue
+		case "ponder":
+			ponder = true
+			continue
+		case "searchmoves":
+			return engine.ClockConfig{}, false, errors.New("searchmoves not supported")
+		}
 
+		if n+1 >= len(args) {
+			return engine.ClockConfig{}, false, fmt.Errorf("expected %s value", key)
+		}
+		n++
+		value, err := strconv.ParseUint(args[n], 10, 32)
+		if err != nil {
+			return engine.ClockConfig{}, false, fmt.Errorf("invalid %s value: %s", key, args[n])
+		}
+		ms := time.Duration(value) * time.Millisecond
+		switch key {
+		case "wtime":
+			clockCfg.WhiteTime = ms
+		case "btime":
+			clockCfg.BlackTime = ms
+		case "winc":
+			clockCfg.WhiteIncrement = ms
+		case "binc":
+			clockCfg.BlackIncrement = ms
+		case "movestogo", "mate":
+			// accepted, but not used by the clock
+		case "movetime":
+			clockCfg.Movetime = ms
+		case "depth":
+			if value == 0 || value > uint64(engine.MaxDepth) {
+				return engine.ClockConfig{}, false, fmt.Errorf("invalid %s value: %s", key, args[n])
+			}
+			clockCfg.Depth = uint8(value)
+		case "nodes":
+			clockCfg.Nodes = uint32(value)
 		default:
-			return
+			return engine.ClockConfig{}, false, fmt.Errorf("unknown parameter: %s", key)
 		}
 	}
+	return clockCfg, ponder, nil
+}
 
+// startSearch starts the search goroutine. A pondering search runs until stop or ponderhit, and then continues
+// as a new search limited by the clock.
+func (i *Interface) startSearch(ctx context.Context, b *board.Board, clockCfg engine.ClockConfig, ponder bool) {
+	engineCtx, cancel := context.WithCancel(ctx)
+	s := &search{
+		state:    engineStateSearching,
+		board:    b,
+		clockCfg: clockCfg,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	searchCfg := &engine.SearchConfig{
+		ClockConfig: clockCfg,
+		Debug:       i.options.debug,
+	}
+	if !i.options.debug {
+		searchCfg.OnInfo = func(info *engine.SearchInfo) {
+			i.println(formatInfo(info))
+		}
+	}
+	if ponder {
+		s.state = engineStatePondering
+		searchCfg.ClockConfig = engine.ClockConfig{}
+	}
+	i.mu.Lock()
+	prev := i.search
+	i.search = s
+	i.mu.Unlock()
+	if prev != nil {
+		<-prev.done // the previous bestmove may still be in flight
+	}
+
+	e := i.engine
 	go func() {
-		engineCtx, engineCancel := context.WithCancel(ctx)
-		i.engineCancel = engineCancel
-		i.engineRunning = true
-		defer engineCancel()
-
-		bestMove, err := i.engine.Search(engineCtx, i.board, &engine.SearchConfig{
-			ClockConfig: clockCfg,
-			Debug:       i.options.debug,
-		})
-		if err != nil && !errors.Is(err, context.Canceled) {
-			panic(err)
+		defer close(s.done)
+		defer cancel()
+
+		bestMove, err := e.Search(engineCtx, b, searchCfg)
+		if err != nil {
+			// e.g. stopped before the first iteration, still answer with a legal move if any
+			if !errors.Is(err, context.Canceled) {
+				i.println(fmt.Sprintf("info string error: %v", err))
+			}
+			bestMove = board.Move{}
+			if mvs := b.GenerateLegalMoves(nil); len(mvs) != 0 {
+				bestMove = mvs[0]
+			}
 		}
 
-		i.println(fmt.Sprintf("bestmove %s", bestMove.UCI()))
-		i.engineRunning = false
+		i.mu.Lock()
+		if s.state == engineStatePondering && !s.discard {
+			// bestmove must not be sent while pondering, even if the search has ended by itself
+			i.mu.Unlock()
+			<-engineCtx.Done()
+			i.mu.Lock()
+		}
+		discard := s.discard
+		s.state = engineStateIdle // cleared first, as the GUI may send the next position as soon as it reads bestmove
+		i.mu.Unlock()
+		if !discard {
+			i.println(fmt.Sprintf("bestmove %s", formatMoveUCI(bestMove)))
+		}
 	}()
 }
 
-func (i *Interface) commandStop(ctx context.Context) {
-	if i.engineRunning {
-		i.engineCancel()
+// commandStop stops the current search, and waits until its bestmove is sent.
+func (i *Interface) commandStop(_ context.Context) {
+	i.mu.Lock()
+	s := i.search
+	i.mu.Unlock()
+	if s == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// commandPonderHit continues the pondering search as a search limited by the clock.
+func (i *Interface) commandPonderHit(ctx context.Context) {
+	i.mu.Lock()
+	s := i.search
+	if s == nil || s.state != engineStatePondering {
+		i.mu.Unlock()
+		return
 	}
+	s.discard = true
+	i.mu.Unlock()
+	s.cancel()
+	<-s.done
+
+	i.startSearch(ctx, s.board, s.clockCfg, false)
+}
+
+func (i *Interface) engineState() engineState {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.search == nil {
+		return engineStateIdle
+	}
+	return i.search.state
 }
 
 func (i *Interface) reset(ctx context.Context) {
 	i.commandStop(ctx)
-	i.commandPosition(ctx, []string{"startpos"})
+	_ = i.commandPosition(ctx, []string{"startpos"})
+	i.newEngine()
+}
+
+// newEngine recreates the engine with the current options, clearing the transposition table.
+func (i *Interface) newEngine() {
 	i.engine = engine.NewEngine(&engine.EngineConfig{
 		HashTableSize: i.options.hashTableSize,
 		Logger:        i.println,
+		Tablebase:     i.tablebase,
 	})
 }
 
-func (i *Interface) println(a ...any) {
-	fmt.Fprintln(os.Stdout, a...)
+// println writes each line atomically, so that multi-line replies are never interleaved with search output.
+func (i *Interface) println(lines ...any) {
+	i.outMu.Lock()
+	defer i.outMu.Unlock()
+	for _, line := range lines {
+		s := fmt.Sprint(line)
+		if i.logger != nil {
+			i.logger("< " + s)
+		}
+		fmt.Fprintln(i.writer, s)
+	}
+}
+
+// formatMoveUCI returns the move in UCI notation, with "0000" as the null move.
+func formatMoveUCI(mv board.Move) string {
+	if mv.IsNull() {
+		return "0000"
+	}
+	return mv.UCI()
 }
@@ -0,0 +1,512 @@
+package uci
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/book"
+	"github.com/daystram/gambit/internal/sessiontest"
+)
+
+func newSession(t *testing.T) *sessiontest.Session {
+	return sessiontest.New(t, func(r io.Reader, w io.Writer) error {
+		return NewInterface(WithReader(r), WithWriter(w)).Run()
+	})
+}
+
+func TestInterfaceSession(t *testing.T) {
+	t.Parallel()
+	type step struct {
+		command string
+		want    []string // prefixes of the expected lines, in order
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "handshake",
+			steps: []step{
+				{command: "uci", want: []string{
+					fmt.Sprintf("id name %s %s", EngineName, EngineVersion),
+					fmt.Sprintf("id author %s", EngineAuthor),
+					"option name Debug type check default false",
+					"option name Hash type spin default 64 min 0 max 4096",
+					"option name Clear Hash type button",
+					"option name Ponder type check default false",
+					"option name OwnBook type check default false",
+					"option name BookFile type string default <empty>",
+					"option name SyzygyPath type string default <empty>",
+					"option name ParallelPerft type check default false",
+					"uciok",
+				}},
+				{command: "isready", want: []string{"readyok"}},
+			},
+		},
+		{
+			name: "search from startpos",
+			steps: []step{
+				{command: "setoption name Hash value 1", want: nil},
+				{command: "ucinewgame", want: nil},
+				{command: "isready", want: []string{"readyok"}},
+				{command: "position startpos moves e2e4 e7e5", want: nil},
+				{command: "go depth 3", want: []string{"info depth 1", "info depth 2", "info depth 3", "bestmove "}},
+			},
+		},
+		{
+			name: "search from fen",
+			steps: []step{
+				{command: "position fen 6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1", want: nil},
+				{command: "go depth 2", want: []string{"bestmove a1a8"}},
+			},
+		},
+		{
+			name: "stop infinite search",
+			steps: []step{
+				{command: "position startpos", want: nil},
+				{command: "go infinite", want: []string{"info depth 1"}},
+				{command: "stop", want: []string{"bestmove "}},
+				{command: "isready", want: []string{"readyok"}},
+			},
+		},
+		{
+			name: "draw board",
+			steps: []step{
+				{command: "position fen 4k3/8/8/8/8/8/8/4K3 w - - 0 1", want: nil},
+				{command: "d", want: []string{"FEN : 4k3/8/8/8/8/8/8/4K3 w - - 0 1", "Hash: ", "Stat: ", "Eval: ", "Phas: "}},
+			},
+		},
+		{
+			name: "set options",
+			steps: []step{
+				{command: "setoption name hash value 16", want: nil},
+				{command: "setoption name Clear Hash", want: nil},
+				{command: "setoption name BookFile value <empty>", want: nil},
+				{command: "setoption name SyzygyPath value <empty>", want: nil},
+				{command: "setoption name PARALLELPERFT value TRUE", want: nil},
+				{command: "isready", want: []string{"readyok"}},
+			},
+		},
+		{
+			name: "unknown commands reported",
+			steps: []step{
+				{command: "foo bar", want: []string{"info string error: unknown command: foo"}},
+				{command: "", want: nil},
+				{command: "isready", want: []string{"readyok"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newSession(t)
+			s.Expect(EngineName)
+			for _, st := range tt.steps {
+				s.Send(st.command)
+				s.Expect(st.want...)
+			}
+			s.Send("quit")
+			if err := s.Close(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInterfaceEndOfInput(t *testing.T) {
+	t.Parallel()
+	s := newSession(t)
+	s.Expect(EngineName)
+	s.Send("isready")
+	s.Expect("readyok")
+	if err := s.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInterfaceLogger(t *testing.T) {
+	t.Parallel()
+	var transcript []string
+	var out strings.Builder
+	err := NewInterface(
+		WithReader(strings.NewReader("isready\nquit\n")),
+		WithWriter(&out),
+		WithLogger(func(a ...any) {
+			transcript = append(transcript, fmt.Sprint(a...))
+		}),
+	).Run()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"< " + EngineName + " " + EngineVersion, "> isready", "< readyok", "> quit"}
+	if strings.Join(transcript, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected transcript: got=%q want=%q", transcript, want)
+	}
+	if got := out.String(); got != EngineName+" "+EngineVersion+"\nreadyok\n" {
+		t.Errorf("unexpected output: got=%q", got)
+	}
+}
+
+func TestInterfaceLifecycle(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s *sessiontest.Session)
+	}{
+		{
+			name: "stop waits for bestmove",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				for n := 0; n < 20; n++ {
+					s.Send("position startpos moves e2e4")
+					s.Send("go infinite")
+					s.Send("stop")
+					s.Send("isready")
+					if got := sessiontest.CountPrefix(s.Expect("readyok"), "bestmove "); got != 1 {
+						t.Fatalf("unexpected bestmove count before readyok: got=%d want=%d", got, 1)
+					}
+				}
+			},
+		},
+		{
+			name: "stop when idle",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				s.Send("stop")
+				s.Send("stop")
+				s.Send("isready")
+				if got := sessiontest.CountPrefix(s.Expect("readyok"), "bestmove "); got != 0 {
+					t.Fatalf("unexpected bestmove count before readyok: got=%d want=%d", got, 0)
+				}
+			},
+		},
+		{
+			name: "position while searching",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				s.Send("position startpos")
+				s.Send("go infinite")
+				s.Send("position fen 6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1")
+				s.Send("stop")
+				s.Expect("bestmove ")
+				s.Send("go depth 2")
+				s.Expect("bestmove a1a8")
+			},
+		},
+		{
+			name: "setoption while searching",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				s.Send("go infinite")
+				s.Send("setoption name SyzygyPath value <empty>")
+				s.Expect("info string error: setoption: cannot set options while searching")
+				s.Send("stop")
+				s.Expect("bestmove ")
+			},
+		},
+		{
+			name: "go while searching ignored",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				s.Send("go infinite")
+				s.Send("go depth 1")
+				s.Send("stop")
+				s.Send("isready")
+				if got := sessiontest.CountPrefix(s.Expect("readyok"), "bestmove "); got != 1 {
+					t.Fatalf("unexpected bestmove count before readyok: got=%d want=%d", got, 1)
+				}
+			},
+		},
+		{
+			name: "ponder holds bestmove until ponderhit",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				s.Send("position startpos moves e2e4 e7e5")
+				s.Send("go ponder depth 1")
+				time.Sleep(200 * time.Millisecond)
+				s.Send("isready")
+				if got := sessiontest.CountPrefix(s.Expect("readyok"), "bestmove "); got != 0 {
+					t.Fatalf("unexpected bestmove count while pondering: got=%d want=%d", got, 0)
+				}
+				s.Send("ponderhit")
+				s.Expect("bestmove ")
+			},
+		},
+		{
+			name: "stop while pondering",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				s.Send("go ponder wtime 1000 btime 1000")
+				s.Send("stop")
+				s.Send("isready")
+				if got := sessiontest.CountPrefix(s.Expect("readyok"), "bestmove "); got != 1 {
+					t.Fatalf("unexpected bestmove count before readyok: got=%d want=%d", got, 1)
+				}
+				s.Send("ponderhit")
+				s.Send("isready")
+				if got := sessiontest.CountPrefix(s.Expect("readyok"), "bestmove "); got != 0 {
+					t.Fatalf("unexpected bestmove count after late ponderhit: got=%d want=%d", got, 0)
+				}
+			},
+		},
+		{
+			name: "ucinewgame while searching",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				s.Send("go infinite")
+				s.Send("ucinewgame")
+				s.Send("isready")
+				if got := sessiontest.CountPrefix(s.Expect("readyok"), "bestmove "); got != 1 {
+					t.Fatalf("unexpected bestmove count before readyok: got=%d want=%d", got, 1)
+				}
+			},
+		},
+		{
+			name: "quit while searching",
+			run: func(t *testing.T, s *sessiontest.Session) {
+				s.Send("go infinite")
+				s.Send("quit")
+				s.Expect("bestmove ")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newSession(t)
+			s.Expect(EngineName)
+			s.Send("setoption name Hash value 1")
+			s.Send("ucinewgame")
+			tt.run(t, s)
+			_ = s.TrySend("quit")
+			if err := s.Close(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInterfaceHammer(t *testing.T) {
+	t.Parallel()
+	commands := []string{
+		"position startpos",
+		"position startpos moves e2e4 e7e5",
+		"position fen 6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1",
+		"go infinite",
+		"go depth 2",
+		"go movetime 10",
+		"go ponder movetime 10",
+		"ponderhit",
+		"stop",
+		"isready",
+		"ucinewgame",
+		"d",
+	}
+	s := newSession(t)
+	s.Expect(EngineName)
+	s.Send("setoption name Hash value 1")
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 300; n++ {
+		s.Send(commands[rnd.Intn(len(commands))])
+	}
+	s.Send("stop")
+	s.Send("isready")
+	s.Expect("readyok")
+	s.Send("quit")
+	if err := s.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInterfaceBook(t *testing.T) {
+	t.Parallel()
+	b, err := board.NewBoard()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mv, err := b.NewMoveFromUCI("a2a3") // a move the search would not pick
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "book.bin")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := book.Write(f, []book.Entry{{Key: book.Key(b), Move: book.EncodeMove(mv), Weight: 1}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		stop     string
+		wantBook bool
+	}{
+		{name: "depth", command: "go depth 2", wantBook: true},
+		{name: "infinite", command: "go infinite", stop: "stop"},
+		{name: "ponder", command: "go ponder wtime 1000 btime 1000", stop: "stop"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newSession(t)
+			s.Expect(EngineName)
+			s.Send("setoption name OwnBook value true")
+			s.Send("setoption name BookFile value " + path)
+			s.Send("position startpos")
+			s.Send(tt.command)
+			if tt.wantBook {
+				s.Expect("info string book move a2a3", "bestmove a2a3")
+			} else {
+				lines := s.Expect("info depth 1")
+				if got := sessiontest.CountPrefix(lines, "bestmove "); got != 0 {
+					t.Errorf("unexpected bestmove count before %s: got=%d want=%d", tt.stop, got, 0)
+				}
+				s.Send(tt.stop)
+				lines = s.Expect("bestmove ")
+				if got := sessiontest.CountPrefix(lines, "info string book move"); got != 0 {
+					t.Errorf("unexpected book move count: got=%d want=%d", got, 0)
+				}
+			}
+			s.Send("quit")
+			if err := s.Close(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInterfacePositionRepetition(t *testing.T) {
+	t.Parallel()
+	moves := strings.Repeat("g1f3 g8f6 f3g1 f6g8 ", 4) + "e2e4"
+	s := newSession(t)
+	s.Expect(EngineName)
+	s.Send("position startpos moves " + moves)
+	s.Send("d")
+	// the fivefold repetition does not end the game, which is left to the GUI
+	s.Expect("FEN : rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 9")
+	s.Send("go depth 1")
+	s.Expect("bestmove ")
+	s.Send("quit")
+	if err := s.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInterfaceErrors(t *testing.T) {
+	t.Parallel()
+	const fen = "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "foo", want: "info string error: unknown command: foo"},
+		{command: "position", want: "info string error: position: expected startpos or fen"},
+		{command: "position fen", want: "info string error: position: invalid fen"},
+		{command: "position fen 4k3/8/8", want: "info string error: position: invalid fen"},
+		{command: "position fen 4k3/8/8/8/8/8/8/8 w - - 0 1", want: "info string error: position: invalid fen"},
+		{command: "position startpos moves e2e5", want: "info string error: position: illegal move: e2e5"},
+		{command: "position startpos moves e2e4 e2e4", want: "info string error: position: illegal move: e2e4"},
+		{command: "position startpos moves e2", want: "info string error: position: illegal move: e2"},
+		{command: "position startpos e2e4", want: "info string error: position: expected moves"},
+		{command: "position kiwipete", want: "info string error: position: expected startpos or fen"},
+		{command: "setoption", want: "info string error: setoption: expected name"},
+		{command: "setoption name Hash value big", want: "info string error: setoption: Hash: invalid value: big"},
+		{command: "setoption name Hash value 8192", want: "info string error: setoption: Hash: value out of range: 8192"},
+		{command: "setoption name Debug value maybe", want: "info string error: setoption: Debug: invalid value: maybe"},
+		{command: "setoption name Unknown value 1", want: "info string error: setoption: unknown option: Unknown"},
+		{command: "setoption name BookFile value /nonexistent.bin", want: "info string error: setoption: BookFile: cannot load book"},
+		{command: "go depth", want: "info string error: go: expected depth value"},
+		{command: "go depth 0", want: "info string error: go: invalid depth value: 0"},
+		{command: "go wtime -1", want: "info string error: go: invalid wtime value: -1"},
+		{command: "go sometime 1", want: "info string error: go: unknown parameter: sometime"},
+		{command: "go perft", want: "info string error: go perft: expected depth"},
+		{command: "bench 0", want: "info string error: bench: invalid depth: 0"},
+		{command: "bench deep", want: "info string error: bench: invalid depth: deep"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.command, func(t *testing.T) {
+			t.Parallel()
+			s := newSession(t)
+			s.Expect(EngineName)
+			s.Send("position fen " + fen)
+			s.Send(tt.command)
+			s.Send("isready")
+			lines := s.Expect(tt.want, "readyok")
+			if got := sessiontest.CountPrefix(lines, "bestmove "); got != 0 {
+				t.Errorf("unexpected bestmove count: got=%d want=%d", got, 0)
+			}
+			s.Send("d")
+			s.Expect("FEN : " + fen) // the position is kept
+			s.Send("quit")
+			if err := s.Close(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInterfaceBench(t *testing.T) {
+	t.Parallel()
+	s := newSession(t)
+	s.Expect(EngineName)
+	s.Send("bench 2")
+	lines := s.Expect("Nodes searched: ", "Nodes/second: ")
+	if got := sessiontest.CountPrefix(lines, "info string position "); got != 40 {
+		t.Errorf("unexpected position count: got=%d want=%d", got, 40)
+	}
+	s.Send("quit")
+	if err := s.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInterfaceSearchFallback(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		commands []string
+		want     []string
+	}{
+		{
+			name:     "checkmated",
+			commands: []string{"position fen R5k1/5ppp/8/8/8/8/8/4K3 b - - 0 1", "go depth 2"},
+			want:     []string{"info string error: ", "bestmove 0000"},
+		},
+		{
+			name:     "stopped immediately",
+			commands: []string{"position fen 4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "go infinite", "stop"},
+			want:     []string{"bestmove "},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newSession(t)
+			s.Expect(EngineName)
+			for _, command := range tt.commands {
+				s.Send(command)
+			}
+			lines := s.Expect(tt.want...)
+			if bestMove := lines[len(lines)-1]; bestMove == "bestmove a1a1" {
+				t.Errorf("unexpected bestmove: got=%s", bestMove)
+			}
+			s.Send("quit")
+			if err := s.Close(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
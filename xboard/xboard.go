@@ -0,0 +1,502 @@
+package xboard
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/daystram/gambit/board"
+	"github.com/daystram/gambit/engine"
+	"github.com/daystram/gambit/uci"
+)
+
+const (
+	// scoreMate is the base of mate scores in thinking output, i.e. 100000 + N for mate in N moves.
+	scoreMate = 100000
+)
+
+var errQuit = errors.New("quit")
+
+// ignoredCommands are accepted without any effect.
+var ignoredCommands = map[string]bool{
+	"xboard":   true,
+	"accepted": true,
+	"rejected": true,
+	"random":   true,
+	"hard":     true,
+	"easy":     true,
+	"computer": true,
+	"name":     true,
+	"rating":   true,
+	"ics":      true,
+	"draw":     true,
+	"white":    true,
+	"black":    true,
+	".":        true,
+}
+
+// timeControl is the clock set by level, st, and sd, and updated by time and otim.
+type timeControl struct {
+	base, increment  time.Duration
+	moveTime         time.Duration // st, exact time per move
+	depth            uint8         // sd
+	engineTime       time.Duration // time
+	opponentTime     time.Duration // otim
+	engineTimeKnown  bool
+	opponentTimeSeen bool
+}
+
+// search is a search goroutine started when the engine is on move. The move is played before done is closed,
+// unless discarded.
+type search struct {
+	discard bool // guarded by Interface.mu, set to drop the result
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// Interface implements the Chess Engine Communication Protocol version 2.
+type Interface struct {
+	engine *engine.Engine
+
+	// startFEN and moves are the game, from which board is replayed on undo.
+	startFEN string
+	moves    []board.Move
+	board    *board.Board
+	hashes   map[uint64]int
+
+	force      bool
+	engineSide board.Side
+	clock      timeControl
+	gameOver   bool
+
+	mu     sync.Mutex // guards search, post, and the game while a search may be running
+	search *search
+	post   bool
+
+	reader io.Reader
+	writer io.Writer
+	logger func(...any)
+	outMu  sync.Mutex // guards writer
+}
+
+type interfaceConfig struct {
+	reader io.Reader
+	writer io.Writer
+	logger func(...any)
+}
+
+type InterfaceOption func(*interfaceConfig)
+
+// WithReader sets the source of the commands, defaulting to os.Stdin.
+func WithReader(r io.Reader) InterfaceOption {
+	return func(cfg *interfaceConfig) {
+		cfg.reader = r
+	}
+}
+
+// WithWriter sets the destination of the responses, defaulting to os.Stdout.
+func WithWriter(w io.Writer) InterfaceOption {
+	return func(cfg *interfaceConfig) {
+		cfg.writer = w
+	}
+}
+
+// WithLogger sets the logger receiving a transcript of the session, with the received commands prefixed by
+// "> " and the sent lines by "< ". Nothing is logged by default.
+func WithLogger(logger func(...any)) InterfaceOption {
+	return func(cfg *interfaceConfig) {
+		cfg.logger = logger
+	}
+}
+
+func NewInterface(opts ...InterfaceOption) *Interface {
+	cfg := &interfaceConfig{
+		reader: os.Stdin,
+		writer: os.Stdout,
+	}
+	for _, f := range opts {
+		f(cfg)
+	}
+
+	return &Interface{
+		reader: cfg.reader,
+		writer: cfg.writer,
+		logger: cfg.logger,
+	}
+}
+
+// Run reads and executes the commands until quit or the end of the input.
+func (i *Interface) Run() error {
+	i.commandNew()
+
+	reader := bufio.NewReader(i.reader)
+	for {
+		cmd, err := reader.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || cmd == "") {
+			i.stopSearch(true)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		args := strings.Fields(strings.TrimSpace(cmd))
+		if len(args) == 0 {
+			continue
+		}
+		if i.logger != nil {
+			i.logger("> " + strings.Join(args, " "))
+		}
+
+		if err := i.execute(args); err != nil {
+			if errors.Is(err, errQuit) {
+				return nil
+			}
+			i.println(fmt.Sprintf("Error (%v): %s", err, strings.Join(args, " ")))
+		}
+	}
+}
+
+// execute runs the command, returning an error if it is malformed.
+func (i *Interface) execute(args []string) error {
+	if ignoredCommands[args[0]] {
+		return nil
+	}
+	switch args[0] {
+	case "protover":
+		i.println(
+			fmt.Sprintf(`feature myname="%s %s" setboard=1 usermove=1 ping=1 playother=1 san=0 colors=0`,
+				uci.EngineName, uci.EngineVersion),
+			"feature sigint=0 sigterm=0 analyze=0 reuse=1 done=1",
+		)
+	case "new":
+		i.commandNew()
+	case "force":
+		i.stopSearch(true)
+		i.force = true
+	case "go":
+		i.stopSearch(true)
+		i.force = false
+		i.engineSide = i.board.Turn()
+		i.think()
+	case "playother":
+		i.stopSearch(true)
+		i.force = false
+		i.engineSide = i.board.Turn().Opposite()
+	case "usermove":
+		if len(args) != 2 {
+			return errors.New("expected move")
+		}
+		return i.commandUserMove(args[1])
+	case "?":
+		i.stopSearch(false)
+	case "level":
+		return i.commandLevel(args[1:])
+	case "st":
+		if len(args) != 2 {
+			return errors.New("expected seconds")
+		}
+		seconds, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || seconds <= 0 {
+			return errors.New("invalid seconds")
+		}
+		i.clock.moveTime = time.Duration(seconds * float64(time.Second))
+	case "sd":
+		if len(args) != 2 {
+			return errors.New("expected depth")
+		}
+		depth, err := strconv.ParseUint(args[1], 10, 8)
+		if err != nil || depth == 0 {
+			return errors.New("invalid depth")
+		}
+		i.clock.depth = uint8(depth)
+	case "time", "otim":
+		if len(args) != 2 {
+			return errors.New("expected centiseconds")
+		}
+		centis, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errors.New("invalid centiseconds")
+		}
+		if args[0] == "time" {
+			i.clock.engineTime, i.clock.engineTimeKnown = time.Duration(centis)*10*time.Millisecond, true
+		} else {
+			i.clock.opponentTime, i.clock.opponentTimeSeen = time.Duration(centis)*10*time.Millisecond, true
+		}
+	case "post", "nopost":
+		i.mu.Lock()
+		i.post = args[0] == "post"
+		i.mu.Unlock()
+	case "undo":
+		return i.commandUndo(1)
+	case "remove":
+		return i.commandUndo(2)
+	case "result":
+		i.stopSearch(true)
+		i.force = true
+		i.gameOver = true
+	case "setboard":
+		return i.commandSetBoard(strings.Join(args[1:], " "))
+	case "ping":
+		if len(args) != 2 {
+			return errors.New("expected ping number")
+		}
+		i.println("pong " + args[1])
+	case "quit":
+		i.stopSearch(true)
+		return errQuit
+	default:
+		return errors.New("unknown command")
+	}
+	return nil
+}
+
+func (i *Interface) commandNew() {
+	i.stopSearch(true)
+	i.engine = engine.NewEngine(&engine.EngineConfig{
+		HashTableSize: engine.DefaultHashTableSizeMB,
+		Logger:        func(...any) {},
+	})
+	i.force = false
+	i.engineSide = board.SideBlack
+	i.clock.depth = 0
+	i.clock.moveTime = 0
+	i.clock.engineTimeKnown, i.clock.opponentTimeSeen = false, false
+	_ = i.setGame(board.DefaultStartingPositionFEN, nil)
+}
+
+func (i *Interface) commandUserMove(notation string) error {
+	i.stopSearch(true)
+	mv, err := i.board.NewMoveFromUCI(notation)
+	if err != nil || !i.board.IsLegalMove(mv) {
+		if mv, err = i.board.NewMoveFromSAN(notation); err != nil {
+			i.println("Illegal move: " + notation)
+			return nil
+		}
+	}
+	if i.gameOver {
+		i.println("Illegal move (game over): " + notation)
+		return nil
+	}
+	i.play(mv)
+	if !i.force && !i.gameOver && i.board.Turn() == i.engineSide {
+		i.think()
+	}
+	return nil
+}
+
+// commandLevel sets a conventional clock of "level MPS BASE INC", with BASE in minutes or minutes:seconds, and
+// INC in seconds. The moves per session is not used.
+func (i *Interface) commandLevel(args []string) error {
+	if len(args) != 3 {
+		return errors.New("expected moves per session, base, and increment")
+	}
+	if _, err := strconv.ParseUint(args[0], 10, 32); err != nil {
+		return errors.New("invalid moves per session")
+	}
+	var base time.Duration
+	minutes, seconds, hasSeconds := strings.Cut(args[1], ":")
+	m, err := strconv.ParseUint(minutes, 10, 32)
+	if err != nil {
+		return errors.New("invalid base")
+	}
+	base = time.Duration(m) * time.Minute
+	if hasSeconds {
+		s, err := strconv.ParseUint(seconds, 10, 32)
+		if err != nil || s >= 60 {
+			return errors.New("invalid base")
+		}
+		base += time.Duration(s) * time.Second
+	}
+	inc, err := strconv.ParseFloat(args[2], 64)
+	if err != nil || inc < 0 {
+		return errors.New("invalid increment")
+	}
+	i.clock.base, i.clock.increment, i.clock.moveTime = base, time.Duration(inc*float64(time.Second)), 0
+	return nil
+}
+
+func (i *Interface) commandUndo(n int) error {
+	i.stopSearch(true)
+	if len(i.moves) < n {
+		return errors.New("no move to undo")
+	}
+	return i.setGame(i.startFEN, i.moves[:len(i.moves)-n])
+}
+
+func (i *Interface) commandSetBoard(fen string) error {
+	i.stopSearch(true)
+	if err := i.setGame(fen, nil); err != nil {
+		i.println("tellusererror Illegal position")
+		return nil
+	}
+	return nil
+}
+
+// setGame replays the game from the FEN. The current game is kept if the FEN is invalid.
+func (i *Interface) setGame(fen string, mvs []board.Move) error {
+	b, err := board.NewBoard(board.WithFEN(fen))
+	if err != nil {
+		return err
+	}
+	i.startFEN, i.board, i.moves, i.gameOver = fen, b, nil, false
+	i.hashes = map[uint64]int{b.Hash(): 1}
+	for _, mv := range mvs {
+		i.play(mv)
+	}
+	return nil
+}
+
+// play applies the move to the game, and sends the result if the game has ended.
+func (i *Interface) play(mv board.Move) {
+	i.board.Apply(mv)
+	i.moves = append(i.moves, mv)
+	i.hashes[i.board.Hash()]++
+
+	var result string
+	switch i.board.State() {
+	case board.StateCheckmateWhite:
+		result = "0-1 {Black mates}"
+	case board.StateCheckmateBlack:
+		result = "1-0 {White mates}"
+	case board.StateStalemate:
+		result = "1/2-1/2 {Stalemate}"
+	case board.StateFiftyMoveViolated:
+		result = "1/2-1/2 {Draw by fifty move rule}"
+	default:
+		if i.hashes[i.board.Hash()] >= 3 {
+			result = "1/2-1/2 {Draw by repetition}"
+		}
+	}
+	if result != "" {
+		i.gameOver = true
+		i.println(result)
+	}
+}
+
+// think starts searching for the engine move, played once found.
+func (i *Interface) think() {
+	if i.gameOver {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &search{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	i.mu.Lock()
+	i.search = s
+	i.mu.Unlock()
+
+	b, clockCfg := i.board.Clone(), i.clockConfig()
+	go func() {
+		defer close(s.done)
+		defer cancel()
+
+		mv, err := i.engine.Search(ctx, b, &engine.SearchConfig{ClockConfig: clockCfg, OnInfo: i.thinking})
+		if err != nil {
+			// answer with any legal move, or none if the game is over
+			mv = board.Move{}
+			if mvs := b.GenerateLegalMoves(nil); len(mvs) != 0 {
+				mv = mvs[0]
+			}
+		}
+
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		i.search = nil
+		if s.discard || mv.IsNull() {
+			return
+		}
+		i.println("move " + mv.UCI())
+		i.play(mv)
+	}()
+}
+
+// stopSearch stops the search and waits until it has ended. The move found is played unless discarded.
+func (i *Interface) stopSearch(discard bool) {
+	i.mu.Lock()
+	s := i.search
+	if s != nil && discard {
+		s.discard = true
+	}
+	i.mu.Unlock()
+	if s == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+// clockConfig returns the search limits from the time control, preferring the exact time per move, then the
+// game clock, and then the depth.
+func (i *Interface) clockConfig() engine.ClockConfig {
+	tc := i.clock
+	switch {
+	case tc.moveTime != 0:
+		return engine.ClockConfig{Movetime: tc.moveTime, Depth: tc.depth}
+	case tc.engineTimeKnown || tc.base != 0:
+		engineTime, opponentTime := tc.engineTime, tc.opponentTime
+		if !tc.engineTimeKnown {
+			engineTime = tc.base
+		}
+		if !tc.opponentTimeSeen {
+			opponentTime = engineTime
+		}
+		cfg := engine.ClockConfig{
+			WhiteTime:      engineTime,
+			BlackTime:      opponentTime,
+			WhiteIncrement: tc.increment,
+			BlackIncrement: tc.increment,
+			Depth:          tc.depth, // sd applies together with the clock
+		}
+		if i.engineSide == board.SideBlack {
+			cfg.WhiteTime, cfg.BlackTime = opponentTime, engineTime
+		}
+		return cfg
+	default:
+		return engine.ClockConfig{Depth: tc.depth}
+	}
+}
+
+// thinking sends the search progress as thinking output of "ply score time nodes pv", if post is enabled.
+func (i *Interface) thinking(info *engine.SearchInfo) {
+	i.mu.Lock()
+	post := i.post
+	i.mu.Unlock()
+	if !post || info.Type != engine.SearchInfoTypeIteration {
+		return
+	}
+
+	score := info.Score
+	if info.ScoreType == engine.ScoreTypeMate {
+		if score >= 0 {
+			score += scoreMate
+		} else {
+			score -= scoreMate
+		}
+	}
+	pv := make([]string, 0, len(info.PV))
+	for _, mv := range info.PV {
+		pv = append(pv, mv.UCI())
+	}
+	i.println(fmt.Sprintf("%d %d %d %d %s",
+		info.Depth, score, info.Time.Milliseconds()/10, info.Nodes, strings.Join(pv, " ")))
+}
+
+func (i *Interface) println(lines ...string) {
+	i.outMu.Lock()
+	defer i.outMu.Unlock()
+	for _, line := range lines {
+		if i.logger != nil {
+			i.logger("< " + line)
+		}
+		fmt.Fprintln(i.writer, line)
+	}
+}
@@ -0,0 +1,184 @@
+package xboard
+
+import (
+	"io"
+	"testing"
+
+	"github.com/daystram/gambit/internal/sessiontest"
+)
+
+func newSession(t *testing.T) *sessiontest.Session {
+	return sessiontest.New(t, func(r io.Reader, w io.Writer) error {
+		return NewInterface(WithReader(r), WithWriter(w)).Run()
+	})
+}
+
+func TestInterfaceSession(t *testing.T) {
+	t.Parallel()
+	type step struct {
+		command string
+		want    []string // prefixes of the expected lines, in order
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "feature negotiation",
+			steps: []step{
+				{command: "xboard", want: nil},
+				{command: "protover 2", want: []string{
+					`feature myname="Gambit Dev" setboard=1 usermove=1 ping=1 playother=1 san=0 colors=0`,
+					"feature sigint=0 sigterm=0 analyze=0 reuse=1 done=1",
+				}},
+				{command: "ping 7", want: []string{"pong 7"}},
+			},
+		},
+		{
+			name: "engine replies to user move",
+			steps: []step{
+				{command: "new", want: nil},
+				{command: "sd 2", want: nil},
+				{command: "usermove e2e4", want: []string{"move "}},
+				{command: "ping 1", want: []string{"pong 1"}},
+			},
+		},
+		{
+			name: "engine plays white after go",
+			steps: []step{
+				{command: "new", want: nil},
+				{command: "st 0.1", want: nil},
+				{command: "go", want: []string{"move "}},
+			},
+		},
+		{
+			name: "force mode does not search",
+			steps: []step{
+				{command: "force", want: nil},
+				{command: "usermove e2e4", want: nil},
+				{command: "usermove e7e5", want: nil},
+				{command: "ping 2", want: []string{"pong 2"}},
+			},
+		},
+		{
+			name: "post sends thinking output",
+			steps: []step{
+				{command: "post", want: nil},
+				{command: "sd 1", want: nil},
+				{command: "go", want: []string{"1 ", "move "}},
+			},
+		},
+		{
+			name: "illegal move",
+			steps: []step{
+				{command: "force", want: nil},
+				{command: "usermove e2e5", want: []string{"Illegal move: e2e5"}},
+				{command: "usermove Nf3", want: nil},
+				{command: "ping 3", want: []string{"pong 3"}},
+			},
+		},
+		{
+			name: "undo and remove",
+			steps: []step{
+				{command: "force", want: nil},
+				{command: "undo", want: []string{"Error (no move to undo): undo"}},
+				{command: "usermove e2e4", want: nil},
+				{command: "usermove e7e5", want: nil},
+				{command: "remove", want: nil},
+				{command: "usermove e7e5", want: []string{"Illegal move: e7e5"}},
+				{command: "usermove e2e4", want: nil},
+				{command: "undo", want: nil},
+				{command: "usermove d2d4", want: nil},
+				{command: "ping 4", want: []string{"pong 4"}},
+			},
+		},
+		{
+			name: "setboard",
+			steps: []step{
+				{command: "force", want: nil},
+				{command: "setboard invalid", want: []string{"tellusererror Illegal position"}},
+				{command: "setboard 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", want: nil},
+				{command: "usermove a1a8", want: []string{"1-0 {White mates}"}},
+				{command: "usermove g8h8", want: []string{"Illegal move: g8h8"}},
+			},
+		},
+		{
+			name: "engine mates",
+			steps: []step{
+				{command: "setboard 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", want: nil},
+				{command: "sd 3", want: nil},
+				{command: "go", want: []string{"move a1a8", "1-0 {White mates}"}},
+			},
+		},
+		{
+			name: "time controls",
+			steps: []step{
+				{command: "new", want: nil},
+				{command: "level 40 0:30 0", want: nil},
+				{command: "level 0 2 1.5", want: nil},
+				{command: "level 40 0:60 0", want: []string{"Error (invalid base): level 40 0:60 0"}},
+				{command: "time 1000", want: nil},
+				{command: "otim 1000", want: nil},
+				{command: "st x", want: []string{"Error (invalid seconds): st x"}},
+				{command: "sd 0", want: []string{"Error (invalid depth): sd 0"}},
+				{command: "go", want: []string{"move "}},
+			},
+		},
+		{
+			name: "depth with clock",
+			steps: []step{
+				{command: "new", want: nil},
+				{command: "level 0 60 0", want: nil},
+				{command: "sd 1", want: nil},
+				{command: "go", want: []string{"move "}}, // long before the clock allocation runs out
+			},
+		},
+		{
+			name: "move now",
+			steps: []step{
+				{command: "new", want: nil},
+				{command: "go", want: nil},
+				{command: "?", want: []string{"move "}},
+			},
+		},
+		{
+			name: "result stops the game",
+			steps: []step{
+				{command: "new", want: nil},
+				{command: "result 1-0 {White resigns}", want: nil},
+				{command: "usermove e2e4", want: []string{"Illegal move (game over): e2e4"}},
+			},
+		},
+		{
+			name: "unknown command",
+			steps: []step{
+				{command: "foo bar", want: []string{"Error (unknown command): foo bar"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newSession(t)
+			for _, st := range tt.steps {
+				s.Send(st.command)
+				s.Expect(st.want...)
+			}
+			s.Send("quit")
+			if err := s.Close(); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInterfaceEndOfInput(t *testing.T) {
+	t.Parallel()
+	s := newSession(t)
+	s.Send("new")
+	s.Send("go")
+	if err := s.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}